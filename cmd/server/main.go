@@ -1,17 +1,20 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
-	"path/filepath"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/joho/godotenv"
 	//yourusername, car j'ai vraiment galéré a implementer mes propre packages et j'ai plus ou moins fait un guide que chat gpt ma donné, 
 	//apres enfin avoir reussi a le faire marcher, j'ai decidé de pas changer pour pas le refaire bugger
 	"github.com/yourusername/melody-explorer/internal/api" 
+	"github.com/yourusername/melody-explorer/internal/config"
 )
 
 func main() {
@@ -20,12 +23,6 @@ func main() {
 		log.Println("Avertissement: fichier .env introuvable")
 	}
 
-	// Récupérer le port depuis la variable d'environnement ou utiliser la valeur par défaut
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
-
 	// Déterminer le répertoire racine du projet
 	wd, err := os.Getwd()
 	if err != nil {
@@ -35,64 +32,32 @@ func main() {
 	// Afficher le répertoire de travail pour le débogage
 	fmt.Printf("Répertoire de travail: %s\n", wd)
 
-	// Résoudre les chemins
-	templatesDir := filepath.Join(wd, "templates")
-	staticDir := filepath.Join(wd, "static")
-	dataDir := filepath.Join(wd, "data")
+	// Charger la configuration depuis l'environnement
+	cfg := config.Load(wd)
 
 	// Afficher le répertoire de données pour le débogage
-	fmt.Printf("Répertoire des données: %s\n", dataDir)
-
-	// Afficher le chemin de favorites.json pour le débogage
-	favoritesPath := filepath.Join(dataDir, "favorites.json")
-	fmt.Printf("Chemin du fichier des favoris: %s\n", favoritesPath)
-
-	// Vérifier si le fichier favorites.json existe
-	if _, err := os.Stat(favoritesPath); err != nil {
-		if os.IsNotExist(err) {
-			fmt.Println("favorites.json n'existe pas!")
-		} else {
-			fmt.Printf("Erreur lors de la vérification de favorites.json: %v\n", err)
-		}
-	} else {
-		fmt.Println("favorites.json existe")
-
-		// Essayer de lire le fichier et afficher son contenu
-		data, err := os.ReadFile(favoritesPath)
-		if err != nil {
-			fmt.Printf("Erreur lors de la lecture de favorites.json: %v\n", err)
-		} else {
-			fmt.Printf("Contenu de favorites.json: %q\n", string(data))
-		}
-	}
+	fmt.Printf("Répertoire des données: %s\n", cfg.DataDir)
 
 	// Créer le répertoire de données s'il n'existe pas
-	if _, err := os.Stat(dataDir); os.IsNotExist(err) {
+	if _, err := os.Stat(cfg.DataDir); os.IsNotExist(err) {
 		fmt.Println("Création du répertoire de données...")
-		if err := os.MkdirAll(dataDir, 0755); err != nil {
+		if err := os.MkdirAll(cfg.DataDir, 0755); err != nil {
 			log.Fatalf("Erreur lors de la création du répertoire de données: %v", err)
 		}
 	}
 
-	// Créer un fichier favorites.json propre avec un encodage approprié
-	fmt.Println("Création d'un fichier favorites.json propre...")
-	emptyArray := []byte("[]")
-	if err := os.WriteFile(favoritesPath, emptyArray, 0644); err != nil {
-		log.Fatalf("Erreur lors de la création de favorites.json: %v", err)
-	}
-
-	fmt.Println("Création réussie du fichier favorites.json propre")
-
 	// Créer le serveur
-	server, err := api.NewServer(templatesDir, staticDir, dataDir)
+	// Le fichier favorites.json existant est conservé : le stockage le crée s'il manque
+	// et le restaure depuis une sauvegarde s'il est corrompu
+	server, err := api.NewServer(cfg)
 	if err != nil {
 		log.Fatalf("Erreur lors de la création du serveur: %v", err)
 	}
 
 	// Créer le serveur HTTP
 	srv := &http.Server{
-		Addr:         ":" + port,
-		Handler:      server.Router,
+		Addr:         ":" + cfg.Port,
+		Handler:      server.Handler,
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
@@ -100,12 +65,25 @@ func main() {
 
 	// Démarrer le serveur dans une goroutine
 	go func() {
-		log.Printf("Serveur à l'écoute sur le port %s", port)
+		log.Printf("Serveur à l'écoute sur le port %s", cfg.Port)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Erreur lors du démarrage du serveur: %v", err)
 		}
 	}()
 
-	// Maintenir la goroutine principale active
-	select {}
+	// Attendre un signal d'arrêt
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	<-stop
+
+	// Arrêter proprement le serveur puis libérer le stockage
+	log.Println("Arrêt du serveur...")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("Erreur lors de l'arrêt du serveur HTTP: %v", err)
+	}
+	if err := server.Close(); err != nil {
+		log.Printf("Erreur lors de la fermeture du serveur: %v", err)
+	}
 }
\ No newline at end of file
@@ -3,7 +3,7 @@ module github.com/yourusername/melody-explorer
 go 1.23.0
 
 require (
-	github.com/gorilla/mux v1.8.1 // indirect
-	github.com/joho/godotenv v1.5.1 // indirect
-	golang.org/x/oauth2 v0.27.0 // indirect
+	github.com/gorilla/mux v1.8.1
+	github.com/joho/godotenv v1.5.1
+	golang.org/x/oauth2 v0.27.0
 )
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/yourusername/melody-explorer/internal/enrich"
+)
+
+// requireAdmin vérifie l'accès aux points de terminaison d'administration :
+// une session Spotify active et, si ADMIN_TOKEN est défini, l'en-tête X-Admin-Token
+func (s *Server) requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if !s.SpotifyAuth.IsTokenValid() {
+		writeJSONError(w, http.StatusUnauthorized, "Non autorisé")
+		return false
+	}
+
+	if s.adminToken != "" && !s.hasAdminToken(r) {
+		writeJSONError(w, http.StatusForbidden, "Jeton d'administration invalide")
+		return false
+	}
+
+	return true
+}
+
+// hasAdminToken indique si la requête porte le jeton d'administration configuré
+func (s *Server) hasAdminToken(r *http.Request) bool {
+	if s.adminToken == "" {
+		return false
+	}
+	token := r.Header.Get("X-Admin-Token")
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.adminToken)) == 1
+}
+
+// RefreshFavoritesHandler déclenche un rafraîchissement des favoris en arrière-plan.
+// Avec ?force=1, tous les favoris sont rafraîchis, y compris les plus récents.
+func (s *Server) RefreshFavoritesHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+
+	if s.Refresher.Running() {
+		writeJSONError(w, http.StatusConflict, enrich.ErrAlreadyRunning.Error())
+		return
+	}
+
+	force := r.URL.Query().Get("force") == "1" || r.URL.Query().Get("force") == "true"
+	go s.Refresher.Run(force)
+
+	writeJSON(w, http.StatusAccepted, map[string]interface{}{
+		"success": true,
+		"forced":  force,
+	})
+}
+
+// RefreshStatusHandler renvoie l'état du rafraîchissement des favoris
+func (s *Server) RefreshStatusHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"running":     s.Refresher.Running(),
+		"last_report": s.Refresher.LastReport(),
+	})
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/yourusername/melody-explorer/internal/models"
+	"github.com/yourusername/melody-explorer/internal/storage"
+)
+
+// maxNoteLength est la taille maximale d'une note personnelle, en caractères
+const maxNoteLength = 2000
+
+// maxTagSuggestions est le nombre maximal d'étiquettes proposées par l'autocomplétion
+const maxTagSuggestions = 10
+
+// UpdateFavoriteHandler modifie la note, l'évaluation et les étiquettes d'un favori.
+// Seuls les champs présents dans le corps de la requête sont modifiés.
+func (s *Server) UpdateFavoriteHandler(w http.ResponseWriter, r *http.Request) {
+	// Vérifier si l'utilisateur est connecté
+	if !s.isAuthenticated(r) {
+		writeJSONError(w, http.StatusUnauthorized, "Non autorisé")
+		return
+	}
+
+	vars := mux.Vars(r)
+	info, ok := models.LookupFavoriteType(models.FavoriteType(vars["type"]))
+	if !ok {
+		writeJSONError(w, http.StatusBadRequest, "Type invalide")
+		return
+	}
+
+	// Mettre l'identifiant sous forme canonique, comme à l'ajout
+	key := models.FavoriteItem{ID: vars["id"], Type: info.Type}
+	if err := info.Normalize(&key); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	itemType, id := info.Type, key.ID
+
+	// Analyser le corps de la requête
+	var req struct {
+		Note   *string   `json:"note"`
+		Rating *int      `json:"rating"`
+		Tags   *[]string `json:"tags"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Corps de requête invalide")
+		log.Printf("Erreur lors de l'analyse de la requête de modification de favori: %v", err)
+		return
+	}
+
+	if req.Note != nil && len([]rune(*req.Note)) > maxNoteLength {
+		writeJSONError(w, http.StatusBadRequest, "Note trop longue")
+		return
+	}
+	if req.Rating != nil && !models.ValidRating(*req.Rating) {
+		writeJSONError(w, http.StatusBadRequest, "La note doit être comprise entre 1 et 5 (0 pour l'effacer)")
+		return
+	}
+
+	var updated models.FavoriteItem
+	found := false
+	err := s.favoritesFor(r).Update(func(tx *storage.Tx) error {
+		item, ok := tx.Get(id, itemType)
+		if !ok {
+			return nil
+		}
+		found = true
+
+		if req.Note != nil {
+			item.Note = strings.TrimSpace(*req.Note)
+		}
+		if req.Rating != nil {
+			item.Rating = *req.Rating
+		}
+		if req.Tags != nil {
+			item.Tags = models.NormalizeTags(*req.Tags)
+		}
+
+		tx.Add(item)
+		updated = item
+		return nil
+	})
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Échec lors de la modification du favori: "+err.Error())
+		log.Printf("Erreur lors de la modification du favori: %v", err)
+		return
+	}
+	if !found {
+		writeJSONError(w, http.StatusNotFound, "Favori introuvable")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"item":    updated,
+	})
+}
+
+// tagCount associe une étiquette à son nombre d'utilisations
+type tagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// FavoriteTagsHandler renvoie les étiquettes commençant par ?q=, les plus
+// utilisées d'abord, pour l'autocomplétion
+func (s *Server) FavoriteTagsHandler(w http.ResponseWriter, r *http.Request) {
+	// Vérifier si l'utilisateur est connecté
+	if !s.isAuthenticated(r) {
+		writeJSONError(w, http.StatusUnauthorized, "Non autorisé")
+		return
+	}
+
+	prefix := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("q")))
+
+	counts := make(map[string]int)
+	for _, item := range s.FavoritesStorage.GetAll() {
+		for _, tag := range item.Tags {
+			if strings.HasPrefix(tag, prefix) {
+				counts[tag]++
+			}
+		}
+	}
+
+	tags := make([]tagCount, 0, len(counts))
+	for tag, count := range counts {
+		tags = append(tags, tagCount{Tag: tag, Count: count})
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Count != tags[j].Count {
+			return tags[i].Count > tags[j].Count
+		}
+		return tags[i].Tag < tags[j].Tag
+	})
+	if len(tags) > maxTagSuggestions {
+		tags = tags[:maxTagSuggestions]
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"tags": tags,
+	})
+}
@@ -0,0 +1,316 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/yourusername/melody-explorer/internal/enrich"
+	"github.com/yourusername/melody-explorer/internal/models"
+	"github.com/yourusername/melody-explorer/internal/storage"
+)
+
+// maxBatchOperations est le nombre maximal d'opérations par lot
+const maxBatchOperations = 500
+
+// Opérations acceptées par /api/favorites/batch
+const (
+	batchOpAdd    = "add"
+	batchOpRemove = "remove"
+	batchOpTag    = "tag"
+	batchOpMove   = "move"
+)
+
+// Modes de l'opération tag
+const (
+	tagModeAdd    = "add"
+	tagModeRemove = "remove"
+	tagModeSet    = "set"
+)
+
+// Statuts des résultats d'opération
+const (
+	batchStatusOK        = "ok"
+	batchStatusUnchanged = "unchanged"
+	batchStatusError     = "error"
+	// batchStatusSkipped marque les opérations valides d'un lot rejeté à la validation
+	batchStatusSkipped = "skipped"
+	// batchStatusReordered marque un déplacement appliqué. L'ordre des favoris
+	// n'est pas versionné : il n'est ni historisé, ni annulable, ni transmis
+	// aux appareils synchronisés et aux abonnés aux événements.
+	batchStatusReordered = "reordered"
+)
+
+// errBatchRejected annule la transaction lorsqu'une opération échoue
+var errBatchRejected = errors.New("lot rejeté")
+
+// batchOperation est une opération d'un lot
+type batchOperation struct {
+	Op       string `json:"op"`
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Name     string `json:"name,omitempty"`
+	ImageURL string `json:"image_url,omitempty"`
+	// Tags et Mode (add, remove ou set) s'appliquent à l'opération tag
+	Tags []string `json:"tags,omitempty"`
+	Mode string   `json:"mode,omitempty"`
+	// Position s'applique à l'opération move
+	Position *int `json:"position,omitempty"`
+
+	itemType models.FavoriteType
+}
+
+// batchResult est le résultat d'une opération d'un lot
+type batchResult struct {
+	Index  int                 `json:"index"`
+	Op     string              `json:"op"`
+	ID     string              `json:"id"`
+	Type   models.FavoriteType `json:"type"`
+	Status string              `json:"status"`
+	Error  string              `json:"error,omitempty"`
+}
+
+// batchRequest est le corps de POST /api/favorites/batch
+type batchRequest struct {
+	Operations []batchOperation `json:"operations"`
+}
+
+// batchResponse est la réponse de POST /api/favorites/batch
+type batchResponse struct {
+	Success bool          `json:"success"`
+	Results []batchResult `json:"results"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// validate vérifie une opération indépendamment de l'état des favoris
+func (op *batchOperation) validate() error {
+	info, ok := models.LookupFavoriteType(models.FavoriteType(op.Type))
+	if !ok {
+		return fmt.Errorf("type invalide : %q", op.Type)
+	}
+	op.itemType = info.Type
+
+	// Mettre l'identifiant sous forme canonique, et déduire le nom des
+	// favoris qui ne sont pas des ressources Spotify
+	item := models.FavoriteItem{ID: op.ID, Type: info.Type, Name: op.Name}
+	if err := info.Normalize(&item); err != nil {
+		return err
+	}
+	op.ID, op.Name = item.ID, item.Name
+
+	switch op.Op {
+	case batchOpAdd, batchOpRemove:
+	case batchOpTag:
+		if op.Mode == "" {
+			op.Mode = tagModeAdd
+		}
+		if op.Mode != tagModeAdd && op.Mode != tagModeRemove && op.Mode != tagModeSet {
+			return fmt.Errorf("mode d'étiquetage invalide : %q", op.Mode)
+		}
+		if op.Mode != tagModeSet && len(models.NormalizeTags(op.Tags)) == 0 {
+			return errors.New("aucune étiquette")
+		}
+	case batchOpMove:
+		if op.Position == nil || *op.Position < 0 {
+			return errors.New("position manquante ou négative")
+		}
+	default:
+		return fmt.Errorf("opération inconnue : %q", op.Op)
+	}
+	return nil
+}
+
+// BatchFavoritesHandler applique une liste d'opérations add, remove, tag et
+// move sur les favoris en une seule transaction. Si une opération échoue,
+// aucune n'est appliquée ; le résultat de chaque opération est renvoyé.
+func (s *Server) BatchFavoritesHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.isAuthenticated(r) {
+		writeJSONError(w, http.StatusUnauthorized, "Non autorisé")
+		return
+	}
+
+	var req batchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Corps de requête invalide")
+		return
+	}
+	if len(req.Operations) == 0 {
+		writeJSONError(w, http.StatusBadRequest, "Aucune opération")
+		return
+	}
+	if len(req.Operations) > maxBatchOperations {
+		writeJSONError(w, http.StatusBadRequest, "Trop d'opérations ("+strconv.Itoa(maxBatchOperations)+" au maximum)")
+		return
+	}
+
+	results := make([]batchResult, len(req.Operations))
+	failed := false
+	for i := range req.Operations {
+		op := &req.Operations[i]
+		results[i] = batchResult{Index: i, Op: op.Op, ID: op.ID, Type: models.FavoriteType(op.Type)}
+		results[i].Status = batchStatusSkipped
+		if err := op.validate(); err != nil {
+			results[i].Status = batchStatusError
+			results[i].Error = err.Error()
+			failed = true
+			continue
+		}
+		results[i].ID = op.ID
+	}
+	if failed {
+		writeBatchResponse(w, http.StatusBadRequest, results)
+		return
+	}
+
+	// Récupérer les métadonnées des éléments ajoutés avant d'ouvrir la transaction
+	snapshots, missing := s.fetchBatchSnapshots(req.Operations)
+
+	now := time.Now()
+	err := s.favoritesFor(r).Update(func(tx *storage.Tx) error {
+		for i, op := range req.Operations {
+			status, err := applyBatchOperation(tx, op, snapshots, missing, now)
+			results[i].Status = status
+			if err != nil {
+				results[i].Status = batchStatusError
+				results[i].Error = err.Error()
+				failed = true
+			}
+		}
+		if failed {
+			return errBatchRejected
+		}
+		return nil
+	})
+	if err == errBatchRejected {
+		writeBatchResponse(w, http.StatusBadRequest, results)
+		return
+	}
+	if err != nil {
+		log.Printf("Erreur lors de l'application d'un lot de favoris: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Échec lors de l'enregistrement des favoris: "+err.Error())
+		return
+	}
+
+	log.Printf("Lot de %d opérations appliqué aux favoris", len(req.Operations))
+	writeBatchResponse(w, http.StatusOK, results)
+}
+
+// writeBatchResponse écrit le résultat d'un lot ; success indique qu'il a été appliqué
+func writeBatchResponse(w http.ResponseWriter, status int, results []batchResult) {
+	response := batchResponse{Success: status == http.StatusOK, Results: results}
+	if status != http.StatusOK {
+		response.Error = "Le lot n'a pas été appliqué : au moins une opération est invalide"
+	}
+	writeJSON(w, status, response)
+}
+
+// fetchBatchSnapshots récupère par lots les métadonnées des éléments à ajouter.
+// Les IDs que Spotify ne connaît pas sont renvoyés dans missing ; en cas
+// d'erreur réseau, les éléments sont ajoutés sans métadonnées.
+func (s *Server) fetchBatchSnapshots(ops []batchOperation) (map[models.FavoriteKey]enrich.Snapshot, map[models.FavoriteKey]bool) {
+	ids := make(map[models.FavoriteType][]string)
+	for _, op := range ops {
+		if op.Op == batchOpAdd {
+			ids[op.itemType] = append(ids[op.itemType], op.ID)
+		}
+	}
+
+	snapshots := make(map[models.FavoriteKey]enrich.Snapshot)
+	missing := make(map[models.FavoriteKey]bool)
+	for itemType, typeIDs := range ids {
+		size := enrich.BatchSize(itemType)
+		if size == 0 {
+			continue
+		}
+		for start := 0; start < len(typeIDs); start += size {
+			end := min(start+size, len(typeIDs))
+			found, notFound, err := enrich.FetchBatch(s.SpotifyClient, itemType, typeIDs[start:end])
+			if err != nil {
+				log.Printf("Impossible de récupérer les métadonnées de %d %s : %v", end-start, itemType, err)
+				continue
+			}
+			for id, snapshot := range found {
+				snapshots[models.FavoriteKey{Type: itemType, ID: id}] = snapshot
+			}
+			for id := range notFound {
+				missing[models.FavoriteKey{Type: itemType, ID: id}] = true
+			}
+		}
+	}
+	return snapshots, missing
+}
+
+// applyBatchOperation applique une opération validée dans la transaction et
+// renvoie son statut
+func applyBatchOperation(tx *storage.Tx, op batchOperation, snapshots map[models.FavoriteKey]enrich.Snapshot, missing map[models.FavoriteKey]bool, now time.Time) (string, error) {
+	key := models.FavoriteKey{Type: op.itemType, ID: op.ID}
+	existing, exists := tx.Get(op.ID, op.itemType)
+
+	switch op.Op {
+	case batchOpAdd:
+		if missing[key] {
+			return "", errors.New("élément introuvable sur Spotify")
+		}
+		// Un favori déjà présent conserve sa date d'ajout et ses annotations
+		item := models.FavoriteItem{ID: op.ID, Type: op.itemType, Name: op.Name, ImageURL: op.ImageURL, AddedAt: now}
+		if exists {
+			item.AddedAt = existing.AddedAt
+			item.CopyAnnotations(existing)
+		}
+		if snapshot, ok := snapshots[key]; ok {
+			snapshot.ApplyTo(&item)
+			refreshedAt := now
+			item.LastRefreshedAt = &refreshedAt
+		}
+		if item.Name == "" {
+			return "", errors.New("nom manquant et métadonnées indisponibles")
+		}
+		tx.Add(item)
+		return batchStatusOK, nil
+
+	case batchOpRemove:
+		if !exists {
+			return batchStatusUnchanged, nil
+		}
+		tx.Remove(op.ID, op.itemType)
+		return batchStatusOK, nil
+
+	case batchOpTag:
+		if !exists {
+			return "", errors.New("favori introuvable")
+		}
+		existing.Tags = applyTags(existing.Tags, models.NormalizeTags(op.Tags), op.Mode)
+		tx.Add(existing)
+		return batchStatusOK, nil
+
+	case batchOpMove:
+		if !exists {
+			return "", errors.New("favori introuvable")
+		}
+		tx.Move(op.ID, op.itemType, *op.Position)
+		return batchStatusReordered, nil
+	}
+	return "", fmt.Errorf("opération inconnue : %q", op.Op)
+}
+
+// applyTags ajoute, retire ou remplace des étiquettes normalisées
+func applyTags(current, tags []string, mode string) []string {
+	switch mode {
+	case tagModeSet:
+		return tags
+	case tagModeRemove:
+		var kept []string
+		for _, tag := range current {
+			if !containsFold(tags, tag) {
+				kept = append(kept, tag)
+			}
+		}
+		return models.NormalizeTags(kept)
+	default:
+		return models.NormalizeTags(append(append([]string(nil), current...), tags...))
+	}
+}
@@ -0,0 +1,185 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+
+	"github.com/yourusername/melody-explorer/internal/enrich"
+	"github.com/yourusername/melody-explorer/internal/models"
+	"github.com/yourusername/melody-explorer/internal/spotify"
+)
+
+// Récupération du catalogue Spotify, partagée par les pages HTML et l'API JSON
+
+// defaultPageLimit est le nombre d'éléments par page par défaut
+const defaultPageLimit = 20
+
+// defaultSearchTypes sont les types recherchés lorsqu'aucun n'est demandé
+var defaultSearchTypes = []string{"artist", "album", "track"}
+
+// parsePageParams lit les paramètres limit et page ; les valeurs absentes ou
+// invalides sont remplacées par les valeurs par défaut
+func parsePageParams(query url.Values, defaultLimit int) (limit, page int) {
+	limit, page = defaultLimit, 1
+	if parsed, err := strconv.Atoi(query.Get("limit")); err == nil && parsed > 0 {
+		limit = parsed
+	}
+	if parsed, err := strconv.Atoi(query.Get("page")); err == nil && parsed > 0 {
+		page = parsed
+	}
+	return limit, page
+}
+
+// newPagination calcule la pagination d'une liste de total éléments
+func newPagination(page, limit, total int) *PaginationData {
+	return &PaginationData{
+		CurrentPage: page,
+		TotalPages:  (total + limit - 1) / limit,
+		TotalItems:  total,
+		Limit:       limit,
+		HasPrev:     page > 1,
+		HasNext:     page*limit < total,
+		PrevPage:    page - 1,
+		NextPage:    page + 1,
+	}
+}
+
+// searchCatalog recherche dans Spotify. Les sections artistes, albums et
+// pistes du résultat sont toujours renseignées, éventuellement vides.
+func (s *Server) searchCatalog(query string, types []string, limit, page int) (*spotify.SearchResults, error) {
+	if len(types) == 0 {
+		types = defaultSearchTypes
+	}
+	offset := (page - 1) * limit
+
+	// Journaliser la recherche que nous sommes sur le point d'effectuer
+	log.Printf("Exécution de la recherche: query=%s, types=%v, limit=%d, offset=%d", query, types, limit, offset)
+
+	// Effectuer une recherche pour tous les types demandés en une seule fois
+	searchResults, err := s.SpotifyClient.Search(query, types, limit, offset)
+	if err != nil && searchResults == nil {
+		return nil, fmt.Errorf("erreur de recherche: %w", err)
+	}
+
+	// Si nous n'avons pas obtenu de résultats, initialiser une structure vide
+	if searchResults == nil {
+		searchResults = &spotify.SearchResults{}
+	}
+
+	// S'assurer que les sections existent dans les résultats
+	if searchResults.Artists == nil || searchResults.Artists.Items == nil {
+		searchResults.Artists = &spotify.ArtistResults{
+			Items: []spotify.Artist{},
+		}
+	}
+	if searchResults.Albums == nil || searchResults.Albums.Items == nil {
+		searchResults.Albums = &spotify.AlbumResults{
+			Items: []spotify.Album{},
+		}
+	}
+	if searchResults.Tracks == nil || searchResults.Tracks.Items == nil {
+		searchResults.Tracks = &spotify.TrackResults{
+			Items: []spotify.Track{},
+		}
+	}
+
+	return searchResults, nil
+}
+
+// searchCategory recherche les pistes d'un genre. La pagination annonce un
+// nombre de pages fixe pour assurer une navigation cohérente.
+func (s *Server) searchCategory(genre string, limit, page int) (*spotify.SearchResults, *PaginationData, error) {
+	// Calculer le décalage
+	offset := (page - 1) * limit
+
+	// Rechercher le genre avec une meilleure requête pour obtenir plus de résultats
+	query := "genre:" + genre
+	// Ajouter quelques termes de filtre populaires pour obtenir de meilleurs résultats
+	if page > 5 {
+		// Pour les pages ultérieures, ajouter des plages d'années pour obtenir des résultats plus diversifiés
+		yearStart := 2020 - ((page / 5) * 10)
+		yearEnd := yearStart + 9
+		if yearStart < 1950 {
+			yearStart = 1950
+		}
+		query += " year:" + strconv.Itoa(yearStart) + "-" + strconv.Itoa(yearEnd)
+	}
+
+	log.Printf("Requête de recherche de catégorie: %s, page: %d, décalage: %d", query, page, offset)
+
+	// Essayer avec une limite plus élevée pour s'assurer d'obtenir suffisamment
+	// de résultats, dans la limite acceptée par Spotify
+	searchLimit := min(limit*2, spotify.MaxSearchLimit)
+	results, err := s.SpotifyClient.Search(query, []string{"track"}, searchLimit, offset)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Calculer la pagination avec des pages totales fixes pour assurer une navigation cohérente
+	totalItems := 1000 // Définir une limite fixe raisonnable
+	if results.Tracks != nil && results.Tracks.Total > 0 && results.Tracks.Total < totalItems {
+		totalItems = results.Tracks.Total
+	}
+
+	totalPages := (totalItems + limit - 1) / limit
+
+	// S'assurer que nous affichons au moins 20 pages si nous avons des résultats
+	if totalPages < 20 && results.Tracks != nil && len(results.Tracks.Items) > 0 {
+		totalPages = 20
+	}
+
+	pagination := &PaginationData{
+		CurrentPage: page,
+		TotalPages:  totalPages,
+		TotalItems:  totalItems,
+		Limit:       limit,
+		HasPrev:     page > 1,
+		HasNext:     page < totalPages,
+		PrevPage:    page - 1,
+		NextPage:    page + 1,
+	}
+
+	return results, pagination, nil
+}
+
+// fetchArtist récupère un artiste. Un artiste favori que Spotify ne connaît
+// plus est signalé comme indisponible.
+func (s *Server) fetchArtist(id string) (*spotify.Artist, error) {
+	artist, err := s.SpotifyClient.GetArtist(id)
+	if err == nil && artist.ID == "" {
+		err = enrich.ErrEmptyObject
+	}
+	if err != nil {
+		s.flagUnavailable(models.FavoriteTypeArtist, id, err)
+		return nil, err
+	}
+	return artist, nil
+}
+
+// fetchAlbum récupère un album et signale les albums favoris disparus
+func (s *Server) fetchAlbum(id string) (*spotify.Album, error) {
+	album, err := s.SpotifyClient.GetAlbum(id)
+	if err == nil && album.ID == "" {
+		err = enrich.ErrEmptyObject
+	}
+	if err != nil {
+		s.flagUnavailable(models.FavoriteTypeAlbum, id, err)
+		return nil, err
+	}
+	return album, nil
+}
+
+// fetchTrack récupère une piste et signale les pistes favorites disparues
+func (s *Server) fetchTrack(id string) (*spotify.Track, error) {
+	track, err := s.SpotifyClient.GetTrack(id)
+	if err == nil && track.ID == "" {
+		err = enrich.ErrEmptyObject
+	}
+	if err != nil {
+		s.flagUnavailable(models.FavoriteTypeTrack, id, err)
+		return nil, err
+	}
+	return track, nil
+}
@@ -0,0 +1,208 @@
+package api
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Protection contre la falsification de requêtes intersites (CSRF) : chaque
+// navigateur reçoit un cookie de session aléatoire, et les requêtes qui
+// modifient l'état doivent porter le jeton dérivé de cette session
+// (HMAC-SHA256 avec la clé du serveur). Le jeton est injecté dans les pages
+// par base.html et renvoyé par les scripts dans l'en-tête X-CSRF-Token, ou par
+// les formulaires dans le champ csrf_token. Les requêtes authentifiées par un
+// jeton d'accès personnel ou par l'en-tête X-Admin-Token, qu'un site tiers ne
+// peut pas envoyer, en sont dispensées.
+
+const (
+	// sessionCookieName est le nom du cookie de session du navigateur
+	sessionCookieName = "melody_session"
+	// csrfHeaderName est l'en-tête portant le jeton CSRF des scripts
+	csrfHeaderName = "X-CSRF-Token"
+	// csrfFormField est le champ portant le jeton CSRF des formulaires
+	csrfFormField = "csrf_token"
+	// sessionIDBytes est la taille de l'identifiant de session
+	sessionIDBytes = 32
+)
+
+var (
+	errCrossOrigin = errors.New("origine de la requête non autorisée")
+	errMissingCSRF = errors.New("jeton CSRF manquant")
+	errInvalidCSRF = errors.New("jeton CSRF invalide")
+	errNoSession   = errors.New("session du navigateur absente")
+)
+
+// sessionContextKey est la clé de la session du navigateur dans le contexte de la requête
+type sessionContextKey struct{}
+
+// newCSRFKey génère la clé de signature des jetons CSRF. Elle change à
+// chaque démarrage : les pages ouvertes auparavant doivent être rechargées.
+func newCSRFKey() ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// CSRFMiddleware attribue une session aux navigateurs et vérifie l'origine
+// et le jeton CSRF des requêtes qui modifient l'état
+func (s *Server) CSRFMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Les fichiers statiques, mis en cache, ne reçoivent pas de cookie ;
+		// les jetons d'accès n'utilisent pas la session du navigateur
+		if strings.HasPrefix(r.URL.Path, "/static/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if _, ok := apiTokenFrom(r.Context()); ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		session, ok := sessionFromCookie(r)
+		if !ok {
+			var err error
+			if session, err = s.startSession(w, r); err != nil {
+				log.Printf("Erreur lors de la création de la session: %v", err)
+				s.renderError(w, r, http.StatusInternalServerError, "Impossible de créer la session")
+				return
+			}
+		}
+		r = r.WithContext(context.WithValue(r.Context(), sessionContextKey{}, session))
+
+		if isSafeMethod(r.Method) || s.hasAdminToken(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if err := s.checkCSRF(r, session, ok); err != nil {
+			log.Printf("Requête refusée (CSRF): %s %s: %v", r.Method, r.URL.Path, err)
+			s.renderError(w, r, http.StatusForbidden, "Requête refusée : "+err.Error()+". Rechargez la page puis réessayez.")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isSafeMethod indique si la méthode HTTP ne modifie pas l'état
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// checkCSRF vérifie l'origine et le jeton d'une requête qui modifie l'état ;
+// known indique si la session existait avant la requête
+func (s *Server) checkCSRF(r *http.Request, session string, known bool) error {
+	if err := checkSameOrigin(r); err != nil {
+		return err
+	}
+	if !known {
+		return errNoSession
+	}
+
+	token := r.Header.Get(csrfHeaderName)
+	if token == "" && isFormRequest(r) {
+		token = r.PostFormValue(csrfFormField)
+	}
+	if token == "" {
+		return errMissingCSRF
+	}
+	if !hmac.Equal([]byte(token), []byte(s.csrfTokenFor(session))) {
+		return errInvalidCSRF
+	}
+	return nil
+}
+
+// checkSameOrigin compare l'origine de la requête (en-tête Origin, ou à
+// défaut Referer) à l'hôte du serveur. Les clients qui n'envoient aucun des
+// deux ne sont pas des navigateurs : seul le jeton est alors vérifié.
+func checkSameOrigin(r *http.Request) error {
+	source := r.Header.Get("Origin")
+	if source == "" {
+		source = r.Header.Get("Referer")
+	}
+	if source == "" {
+		return nil
+	}
+
+	// Une origine opaque ("null") n'a pas d'hôte et est refusée
+	u, err := url.Parse(source)
+	if err != nil || u.Host == "" || !strings.EqualFold(u.Host, r.Host) {
+		return errCrossOrigin
+	}
+	return nil
+}
+
+// isFormRequest indique si le corps de la requête est un formulaire encodé
+func isFormRequest(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == "application/x-www-form-urlencoded"
+}
+
+// sessionFromCookie renvoie la session du navigateur si son cookie est valide
+func sessionFromCookie(r *http.Request) (string, bool) {
+	cookie, err := r.Cookie(sessionCookieName)
+	if err != nil {
+		return "", false
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cookie.Value)
+	if err != nil || len(raw) != sessionIDBytes {
+		return "", false
+	}
+	return cookie.Value, true
+}
+
+// startSession attribue une nouvelle session au navigateur, par exemple à la
+// connexion et à la déconnexion pour que le jeton CSRF change avec elles
+func (s *Server) startSession(w http.ResponseWriter, r *http.Request) (string, error) {
+	raw := make([]byte, sessionIDBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	session := base64.RawURLEncoding.EncodeToString(raw)
+
+	// SameSite=Lax : le cookie n'accompagne pas les requêtes POST d'un autre
+	// site, mais reste présent quand on arrive depuis un lien externe
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    session,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+	return session, nil
+}
+
+// csrfTokenFor dérive le jeton CSRF d'une session
+func (s *Server) csrfTokenFor(session string) string {
+	mac := hmac.New(sha256.New, s.csrfKey)
+	mac.Write([]byte("csrf:" + session))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// csrfToken renvoie le jeton CSRF de la session de la requête, à injecter
+// dans les pages ; vide si le navigateur n'a pas encore de session
+func (s *Server) csrfToken(r *http.Request) string {
+	session, ok := r.Context().Value(sessionContextKey{}).(string)
+	if !ok {
+		if session, ok = sessionFromCookie(r); !ok {
+			return ""
+		}
+	}
+	return s.csrfTokenFor(session)
+}
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/melody-explorer/internal/models"
+)
+
+// testSession est une session de navigateur valide
+var testSession = base64.RawURLEncoding.EncodeToString([]byte(strings.Repeat("s", sessionIDBytes)))
+
+func TestCSRFTokenFor(t *testing.T) {
+	s := &Server{csrfKey: []byte("clé de test")}
+	other := base64.RawURLEncoding.EncodeToString([]byte(strings.Repeat("o", sessionIDBytes)))
+
+	tests := []struct {
+		name  string
+		a, b  string
+		key   []byte
+		equal bool
+	}{
+		{name: "même session", a: testSession, b: testSession, key: s.csrfKey, equal: true},
+		{name: "autre session", a: testSession, b: other, key: s.csrfKey},
+		{name: "autre clé", a: testSession, b: testSession, key: []byte("autre clé")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := s.csrfTokenFor(tt.a)
+			b := (&Server{csrfKey: tt.key}).csrfTokenFor(tt.b)
+			if (a == b) != tt.equal {
+				t.Errorf("jetons %q et %q : égalité = %v, attendu %v", a, b, a == b, tt.equal)
+			}
+			if _, err := base64.RawURLEncoding.DecodeString(a); err != nil || a == "" {
+				t.Errorf("jeton mal encodé : %q", a)
+			}
+		})
+	}
+}
+
+func TestCheckSameOrigin(t *testing.T) {
+	tests := []struct {
+		name    string
+		origin  string
+		referer string
+		wantErr bool
+	}{
+		{name: "sans origine ni référent"},
+		{name: "même origine", origin: "http://melody.local:8080"},
+		{name: "hôte en majuscules", origin: "http://MELODY.local:8080"},
+		{name: "autre site", origin: "https://evil.example", wantErr: true},
+		{name: "autre port", origin: "http://melody.local:9090", wantErr: true},
+		{name: "origine opaque", origin: "null", wantErr: true},
+		{name: "référent du même site", referer: "http://melody.local:8080/favorites?type=track"},
+		{name: "référent d'un autre site", referer: "https://evil.example/page", wantErr: true},
+		{name: "l'origine prime sur le référent", origin: "https://evil.example", referer: "http://melody.local:8080/", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "http://melody.local:8080/api/favorites", nil)
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+			if tt.referer != "" {
+				r.Header.Set("Referer", tt.referer)
+			}
+			if err := checkSameOrigin(r); (err != nil) != tt.wantErr {
+				t.Errorf("checkSameOrigin = %v, erreur attendue : %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCSRFMiddleware(t *testing.T) {
+	s := &Server{csrfKey: []byte("clé de test"), adminToken: "secret-admin"}
+	valid := s.csrfTokenFor(testSession)
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		session string
+		header  string
+		form    string
+		origin  string
+		admin   string
+		token   bool
+		want    int
+	}{
+		{name: "lecture sans session", method: http.MethodGet, path: "/api/favorites", want: http.StatusOK},
+		{name: "jeton dans l'en-tête", method: http.MethodPost, path: "/api/favorites", session: testSession, header: valid, want: http.StatusOK},
+		{name: "jeton dans le formulaire", method: http.MethodPost, path: "/lists", session: testSession, form: valid, want: http.StatusOK},
+		{name: "jeton manquant", method: http.MethodPost, path: "/api/favorites", session: testSession, want: http.StatusForbidden},
+		{name: "jeton d'une autre session", method: http.MethodDelete, path: "/api/favorites", session: testSession, header: s.csrfTokenFor("autre"), want: http.StatusForbidden},
+		{name: "session absente", method: http.MethodPost, path: "/api/favorites", header: valid, want: http.StatusForbidden},
+		{name: "cookie de session invalide", method: http.MethodPost, path: "/api/favorites", session: "court", header: valid, want: http.StatusForbidden},
+		{name: "autre origine avec un jeton valide", method: http.MethodPost, path: "/api/favorites", session: testSession, header: valid, origin: "https://evil.example", want: http.StatusForbidden},
+		{name: "même origine", method: http.MethodPut, path: "/api/favorites", session: testSession, header: valid, origin: "http://example.com", want: http.StatusOK},
+		{name: "jeton d'administration", method: http.MethodPost, path: "/api/admin/refresh", admin: "secret-admin", want: http.StatusOK},
+		{name: "mauvais jeton d'administration", method: http.MethodPost, path: "/api/admin/refresh", admin: "faux", want: http.StatusForbidden},
+		{name: "jeton d'accès personnel", method: http.MethodPost, path: "/api/favorites", token: true, want: http.StatusOK},
+		{name: "fichier statique", method: http.MethodPost, path: "/static/css/style.css", want: http.StatusOK},
+	}
+
+	handler := s.CSRFMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var r *http.Request
+			if tt.form != "" {
+				form := url.Values{csrfFormField: {tt.form}}
+				r = httptest.NewRequest(tt.method, tt.path, strings.NewReader(form.Encode()))
+				r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			} else {
+				r = httptest.NewRequest(tt.method, tt.path, nil)
+			}
+			if tt.session != "" {
+				r.AddCookie(&http.Cookie{Name: sessionCookieName, Value: tt.session})
+			}
+			if tt.header != "" {
+				r.Header.Set(csrfHeaderName, tt.header)
+			}
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+			if tt.admin != "" {
+				r.Header.Set("X-Admin-Token", tt.admin)
+			}
+			if tt.token {
+				r = r.WithContext(context.WithValue(r.Context(), tokenContextKey{}, models.APIToken{Name: "script"}))
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Errorf("statut = %d, attendu %d : %s", w.Code, tt.want, w.Body)
+			}
+
+			// Un navigateur sans session valide en reçoit une
+			newSession := tt.session != testSession && !tt.token && !strings.HasPrefix(tt.path, "/static/")
+			if got := strings.Contains(w.Header().Get("Set-Cookie"), sessionCookieName+"="); got != newSession {
+				t.Errorf("cookie de session attribué = %v, attendu %v", got, newSession)
+			}
+		})
+	}
+}
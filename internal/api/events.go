@@ -0,0 +1,194 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/yourusername/melody-explorer/internal/events"
+	"github.com/yourusername/melody-explorer/internal/history"
+	"github.com/yourusername/melody-explorer/internal/models"
+	"github.com/yourusername/melody-explorer/internal/storage"
+)
+
+// Flux d'événements (GET /api/events) : les onglets et appareils connectés
+// reçoivent les modifications des favoris et des listes au format
+// Server-Sent Events, sans recharger la page.
+
+// Types des événements publiés
+const (
+	eventFavorite = "favorite"
+	eventList     = "list"
+)
+
+const (
+	// eventsHeartbeatInterval espace les commentaires envoyés pour garder la
+	// connexion ouverte à travers les proxys
+	eventsHeartbeatInterval = 25 * time.Second
+	// eventsRetry est le délai de reconnexion conseillé au navigateur, en millisecondes
+	eventsRetry = 3000
+)
+
+// favoriteEvent est la donnée d'un événement de favori
+type favoriteEvent struct {
+	Action string              `json:"action"`
+	Type   models.FavoriteType `json:"type"`
+	ID     string              `json:"id"`
+	Key    string              `json:"key"`
+	Name   string              `json:"name"`
+	Actor  string              `json:"actor"`
+	// Item est le favori après une modification, absent après une suppression
+	Item *models.FavoriteItem `json:"item,omitempty"`
+}
+
+// accountCache mémorise l'identifiant Spotify de l'utilisateur connecté,
+// destinataire des événements des favoris et des listes
+type accountCache struct {
+	mu sync.Mutex
+	id string
+}
+
+// get renvoie l'identifiant mémorisé, vide s'il n'est pas connu
+func (c *accountCache) get() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.id
+}
+
+// set mémorise l'identifiant de l'utilisateur connecté ; vide pour l'oublier
+func (c *accountCache) set(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.id = id
+}
+
+// sessionUser renvoie l'identifiant Spotify de l'utilisateur connecté, en
+// l'obtenant de Spotify s'il n'est pas encore connu
+func (s *Server) sessionUser() (string, error) {
+	if id := s.account.get(); id != "" {
+		return id, nil
+	}
+	user, err := s.SpotifyClient.GetCurrentUser()
+	if err != nil {
+		return "", err
+	}
+	s.account.set(user.ID)
+	return user.ID, nil
+}
+
+// eventsUser renvoie l'utilisateur dont la requête suit les événements :
+// le propriétaire du jeton d'accès, ou l'utilisateur connecté
+func (s *Server) eventsUser(r *http.Request) (string, error) {
+	if token, ok := apiTokenFrom(r.Context()); ok {
+		return token.Owner, nil
+	}
+	return s.sessionUser()
+}
+
+// publish publie un événement pour le propriétaire des données. Tant que
+// celui-ci n'est pas connu, l'événement est diffusé à tous les abonnés.
+func (s *Server) publish(eventType string, data interface{}) {
+	if s.Events == nil {
+		return
+	}
+	if _, err := s.Events.Publish(s.account.get(), eventType, data); err != nil {
+		log.Printf("Erreur lors de la publication d'un événement: %v", err)
+	}
+}
+
+// publishFavoriteChanges publie les modifications des favoris enregistrées
+// dans l'historique
+func (s *Server) publishFavoriteChanges(entries []history.Entry) {
+	for _, entry := range entries {
+		s.publish(eventFavorite, favoriteEvent{
+			Action: entry.Action,
+			Type:   entry.Type,
+			ID:     entry.ID,
+			Key:    entry.Key().String(),
+			Name:   entry.Name,
+			Actor:  entry.Actor,
+			Item:   entry.New,
+		})
+	}
+}
+
+// publishListChanges publie les modifications des listes
+func (s *Server) publishListChanges(changes []storage.ListChange) {
+	for _, change := range changes {
+		s.publish(eventList, change)
+	}
+}
+
+// EventsHandler gère GET /api/events : un flux Server-Sent Events des
+// modifications des favoris et des listes. Un navigateur qui se reconnecte
+// envoie l'en-tête Last-Event-ID et reçoit les événements manqués, ou un
+// événement resync s'ils ne sont plus disponibles.
+func (s *Server) EventsHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.isAuthenticated(r) {
+		writeJSONError(w, http.StatusUnauthorized, "Non autorisé")
+		return
+	}
+
+	user, err := s.eventsUser(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadGateway, "Impossible d'identifier l'utilisateur Spotify: "+err.Error())
+		log.Printf("Erreur lors de l'obtention de l'utilisateur Spotify: %v", err)
+		return
+	}
+
+	// Le flux reste ouvert au-delà du délai d'écriture du serveur
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		log.Printf("Impossible de lever le délai d'écriture du flux d'événements: %v", err)
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	sub, missed := s.Events.Subscribe(user, lastEventID)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", eventsRetry)
+	for _, event := range missed {
+		writeEvent(w, event)
+	}
+	if err := rc.Flush(); err != nil {
+		log.Printf("Flux d'événements impossible: %v", err)
+		return
+	}
+
+	heartbeat := time.NewTicker(eventsHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-sub.Events():
+			if !ok {
+				// Hub fermé ou client trop lent : le navigateur se reconnectera
+				return
+			}
+			writeEvent(w, event)
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// writeEvent écrit un événement au format Server-Sent Events. Les données
+// JSON ne contiennent pas de saut de ligne et tiennent sur une seule ligne.
+func writeEvent(w http.ResponseWriter, event events.Event) {
+	fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/yourusername/melody-explorer/internal/export"
+	"github.com/yourusername/melody-explorer/internal/models"
+)
+
+// ExportFavoritesHandler exporte les favoris dans le format demandé par
+// ?format= (json par défaut). Les paramètres type, tag et list restreignent
+// les éléments exportés ; avec list, l'ordre de la liste est conservé.
+func (s *Server) ExportFavoritesHandler(w http.ResponseWriter, r *http.Request) {
+	// Vérifier si l'utilisateur est connecté
+	if !s.isAuthenticated(r) {
+		writeJSONError(w, http.StatusUnauthorized, "Non autorisé")
+		return
+	}
+
+	query := r.URL.Query()
+
+	name := strings.ToLower(strings.TrimSpace(query.Get("format")))
+	if name == "" {
+		name = "json"
+	}
+	format, err := export.Lookup(name)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Format inconnu, formats disponibles : "+strings.Join(export.Names(), ", "))
+		return
+	}
+
+	var itemType models.FavoriteType
+	if value := query.Get("type"); value != "" {
+		var ok bool
+		if itemType, ok = models.ParseFavoriteType(value); !ok {
+			writeJSONError(w, http.StatusBadRequest, "Type invalide")
+			return
+		}
+	}
+	tag := strings.ToLower(strings.TrimSpace(query.Get("tag")))
+
+	title := "Favoris MelodyExplorer"
+	filename := "melody-explorer-favorites"
+	var items []models.FavoriteItem
+	if listID := query.Get("list"); listID != "" {
+		list, ok := s.ListsStorage.Get(listID)
+		if !ok {
+			writeJSONError(w, http.StatusNotFound, "Liste introuvable")
+			return
+		}
+		items = s.listItems(list)
+		title = list.Name
+		filename = "melody-explorer-list-" + list.ID
+	} else {
+		items = s.FavoritesStorage.GetAll()
+	}
+
+	filtered := items[:0:0]
+	for _, item := range items {
+		if itemType != "" && item.Type != itemType {
+			continue
+		}
+		if tag != "" && !item.HasTag(tag) {
+			continue
+		}
+		if format.Playlist && item.URI() == "" {
+			continue
+		}
+		filtered = append(filtered, item)
+	}
+
+	now := time.Now()
+	w.Header().Set("Content-Type", format.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%s.%s"`, filename, now.Format("20060102"), format.Extension))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+
+	// Les éléments sont transmis au fil de l'eau : une erreur à ce stade ne
+	// peut plus changer le statut de la réponse, elle est seulement journalisée
+	meta := export.Metadata{Title: title, Creator: "MelodyExplorer", Date: now}
+	if err := export.Write(format.NewEncoder(w), meta, filtered); err != nil {
+		log.Printf("Erreur lors de l'export des favoris (%s) : %v", format.Name, err)
+	}
+}
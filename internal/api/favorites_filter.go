@@ -0,0 +1,156 @@
+package api
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/yourusername/melody-explorer/internal/models"
+)
+
+// favoritesFilter filtre les favoris à partir de leurs métadonnées enregistrées,
+// sans appel à Spotify
+type favoritesFilter struct {
+	Genre  string
+	Year   string
+	Artist string
+	Tag    string
+}
+
+// parseFavoritesFilter lit les filtres depuis les paramètres de requête
+func parseFavoritesFilter(query url.Values) favoritesFilter {
+	return favoritesFilter{
+		Genre:  strings.TrimSpace(query.Get("genre")),
+		Year:   strings.TrimSpace(query.Get("year")),
+		Artist: strings.TrimSpace(query.Get("artist")),
+		Tag:    strings.TrimSpace(query.Get("tag")),
+	}
+}
+
+// active indique si au moins un filtre est renseigné
+func (f favoritesFilter) active() bool {
+	return f.Genre != "" || f.Year != "" || f.Artist != "" || f.Tag != ""
+}
+
+// values renvoie les filtres sous forme de carte pour les templates
+func (f favoritesFilter) values() map[string]string {
+	return map[string]string{
+		"genre":  f.Genre,
+		"year":   f.Year,
+		"artist": f.Artist,
+		"tag":    f.Tag,
+	}
+}
+
+// matches indique si un favori correspond à tous les filtres renseignés
+func (f favoritesFilter) matches(item models.FavoriteItem) bool {
+	if f.Genre != "" && !containsFold(item.Genres(), f.Genre) {
+		return false
+	}
+	if f.Year != "" && item.ReleaseYear() != f.Year {
+		return false
+	}
+	if f.Artist != "" && !matchesArtist(item, f.Artist) {
+		return false
+	}
+	if f.Tag != "" && !item.HasTag(f.Tag) {
+		return false
+	}
+	return true
+}
+
+// apply renvoie les favoris correspondant aux filtres
+func (f favoritesFilter) apply(items []models.FavoriteItem) []models.FavoriteItem {
+	if !f.active() {
+		return items
+	}
+
+	var filtered []models.FavoriteItem
+	for _, item := range items {
+		if f.matches(item) {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
+}
+
+// matchesArtist indique si le favori est l'artiste recherché ou l'un de ses titres
+func matchesArtist(item models.FavoriteItem, artist string) bool {
+	if item.Type == models.FavoriteTypeArtist {
+		return strings.EqualFold(item.Name, artist)
+	}
+	if item.Metadata == nil {
+		return false
+	}
+	for _, ref := range item.Metadata.Artists {
+		if strings.EqualFold(ref.Name, artist) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsFold indique si la liste contient la valeur, sans tenir compte de la casse
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// favoritesFacets regroupe les valeurs disponibles pour les filtres des favoris
+type favoritesFacets struct {
+	Genres  []string
+	Years   []string
+	Artists []string
+	Tags    []string
+}
+
+// buildFavoritesFacets collecte les genres, années, artistes et étiquettes des favoris
+func buildFavoritesFacets(items []models.FavoriteItem) favoritesFacets {
+	genres := make(map[string]bool)
+	years := make(map[string]bool)
+	artists := make(map[string]bool)
+	tags := make(map[string]bool)
+
+	for _, item := range items {
+		for _, genre := range item.Genres() {
+			genres[genre] = true
+		}
+		for _, tag := range item.Tags {
+			tags[tag] = true
+		}
+		if year := item.ReleaseYear(); year != "" {
+			years[year] = true
+		}
+		if item.Type == models.FavoriteTypeArtist {
+			artists[item.Name] = true
+		} else if item.Metadata != nil {
+			for _, ref := range item.Metadata.Artists {
+				artists[ref.Name] = true
+			}
+		}
+	}
+
+	facets := favoritesFacets{
+		Genres:  sortedKeys(genres),
+		Years:   sortedKeys(years),
+		Artists: sortedKeys(artists),
+		Tags:    sortedKeys(tags),
+	}
+	// Les années les plus récentes d'abord
+	sort.Sort(sort.Reverse(sort.StringSlice(facets.Years)))
+	return facets
+}
+
+// sortedKeys renvoie les clés d'un ensemble triées par ordre alphabétique
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"log"
@@ -9,27 +10,54 @@ import (
 	"net/url"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/yourusername/melody-explorer/internal/config"
+	"github.com/yourusername/melody-explorer/internal/enrich"
+	"github.com/yourusername/melody-explorer/internal/events"
+	"github.com/yourusername/melody-explorer/internal/history"
+	"github.com/yourusername/melody-explorer/internal/hlc"
+	"github.com/yourusername/melody-explorer/internal/importer"
 	"github.com/yourusername/melody-explorer/internal/models"
+	"github.com/yourusername/melody-explorer/internal/releases"
+	"github.com/yourusername/melody-explorer/internal/smartlist"
 	"github.com/yourusername/melody-explorer/internal/spotify"
 	"github.com/yourusername/melody-explorer/internal/storage"
+	"github.com/yourusername/melody-explorer/internal/webhook"
 )
 
 // Server représente le serveur API
 type Server struct {
 	Router           *mux.Router
+	Handler          http.Handler // routeur enveloppé dans les middlewares
 	SpotifyAuth      *spotify.Auth
 	SpotifyClient    *spotify.Client
-	FavoritesStorage *storage.FavoritesStorage
+	FavoritesStorage storage.FavoritesStore
+	History          *history.Recorder
+	ListsStorage     *storage.ListsStorage
+	Refresher        *enrich.Refresher
+	SmartLists       *smartlist.Scheduler
+	Imports          *importer.Manager
+	Tokens           *storage.TokensStorage
+	Events           *events.Hub
+	Clock            *hlc.Clock
+	Tombstones       *storage.TombstonesStorage
+	WebhooksStorage  *storage.WebhooksStorage
+	Webhooks         *webhook.Dispatcher
+	Releases         *releases.Watcher
 	TemplatesDir     string
 	StaticDir        string
+	adminToken       string
+	csrfKey          []byte
 	templates        map[string]*template.Template
+	// account mémorise l'utilisateur Spotify connecté (voir events.go)
+	account accountCache
 }
 
 // NewServer crée une nouvelle instance de serveur
-func NewServer(templatesDir, staticDir, dataDir string) (*Server, error) {
+func NewServer(cfg *config.Config) (*Server, error) {
 	// Créer le routeur
 	router := mux.NewRouter()
 
@@ -40,25 +68,115 @@ func NewServer(templatesDir, staticDir, dataDir string) (*Server, error) {
 	}
 
 	// Créer le client Spotify
-	client := spotify.NewClient(auth)
+	client := spotify.NewClient(auth, cfg.SpotifyRateLimit)
 
 	// Créer le stockage des favoris
-	favoritesStorage, err := storage.NewFavoritesStorage(dataDir)
+	favoritesStorage, err := storage.OpenFavoritesStore(storage.Options{
+		DataDir:         cfg.DataDir,
+		Backend:         cfg.FavoritesBackend,
+		Backups:         cfg.FavoritesBackups,
+		CompactEvery:    cfg.FavoritesCompactEvery,
+		CompactInterval: cfg.FavoritesCompactInterval,
+		WatchInterval:   cfg.FavoritesWatchInterval,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("échec lors de la création du stockage des favoris: %w", err)
 	}
 
+	// Historiser les modifications des favoris faites par l'utilisateur
+	historyLog, err := history.OpenLog(cfg.DataDir)
+	if err != nil {
+		favoritesStorage.Close()
+		return nil, fmt.Errorf("échec lors de l'ouverture de l'historique des favoris: %w", err)
+	}
+	recorder := history.NewRecorder(favoritesStorage, historyLog, history.ActorWeb)
+
+	// Créer le stockage des listes personnalisées
+	listsStorage, err := storage.NewListsStorage(cfg.DataDir, storage.Options{
+		Backups:       cfg.FavoritesBackups,
+		WatchInterval: cfg.FavoritesWatchInterval,
+	})
+	if err != nil {
+		favoritesStorage.Close()
+		return nil, fmt.Errorf("échec lors de la création du stockage des listes: %w", err)
+	}
+
+	// Créer le stockage des jetons d'accès personnels
+	tokensStorage, err := storage.NewTokensStorage(cfg.DataDir)
+	if err != nil {
+		favoritesStorage.Close()
+		listsStorage.Close()
+		return nil, fmt.Errorf("échec lors de la création du stockage des jetons: %w", err)
+	}
+
+	// Horodater les modifications des favoris pour la synchronisation hors ligne
+	tombstones, err := storage.NewTombstonesStorage(cfg.DataDir, storage.DefaultTombstoneRetention)
+	if err != nil {
+		favoritesStorage.Close()
+		listsStorage.Close()
+		return nil, fmt.Errorf("échec lors de la création du stockage des suppressions: %w", err)
+	}
+	clock := hlc.NewClock(syncServerNode)
+	recorder.EnableVersioning(clock, tombstones)
+
+	// Créer le stockage des webhooks et de leurs notifications
+	webhooksStorage, err := storage.NewWebhooksStorage(cfg.DataDir)
+	if err != nil {
+		favoritesStorage.Close()
+		listsStorage.Close()
+		return nil, fmt.Errorf("échec lors de la création du stockage des webhooks: %w", err)
+	}
+
+	// Créer le stockage des sorties déjà vues des artistes favoris
+	releasesStorage, err := storage.NewReleasesStorage(cfg.DataDir)
+	if err != nil {
+		favoritesStorage.Close()
+		listsStorage.Close()
+		return nil, fmt.Errorf("échec lors de la création du stockage des sorties: %w", err)
+	}
+
+	// Générer la clé de signature des jetons CSRF
+	csrfKey, err := newCSRFKey()
+	if err != nil {
+		favoritesStorage.Close()
+		listsStorage.Close()
+		return nil, fmt.Errorf("échec lors de la génération de la clé CSRF: %w", err)
+	}
+
 	// Créer le serveur
 	server := &Server{
 		Router:           router,
 		SpotifyAuth:      auth,
 		SpotifyClient:    client,
-		FavoritesStorage: favoritesStorage,
-		TemplatesDir:     templatesDir,
-		StaticDir:        staticDir,
+		FavoritesStorage: recorder,
+		History:          recorder,
+		ListsStorage:     listsStorage,
+		Refresher:        enrich.NewRefresher(client, recorder.As(history.ActorSystem), cfg.RefreshInterval, cfg.RefreshStaleAfter),
+		SmartLists:       smartlist.NewScheduler(smartlist.NewEvaluator(client, recorder), listsStorage, cfg.SmartListsInterval),
+		Imports:          importer.NewManager(importer.NewMatcher(client), recorder.As(history.ActorImport)),
+		Tokens:           tokensStorage,
+		Events:           events.NewHub(events.DefaultHistorySize),
+		Clock:            clock,
+		Tombstones:       tombstones,
+		WebhooksStorage:  webhooksStorage,
+		Webhooks:         webhook.NewDispatcher(webhooksStorage, cfg.WebhookTimeout),
+		Releases:         releases.NewWatcher(client, recorder, releasesStorage, cfg.ReleasesInterval),
+		TemplatesDir:     cfg.TemplatesDir,
+		StaticDir:        cfg.StaticDir,
+		adminToken:       cfg.AdminToken,
+		csrfKey:          csrfKey,
 		templates:        make(map[string]*template.Template),
 	}
 
+	// Diffuser les modifications des favoris et des listes aux clients connectés
+	recorder.OnChange(server.publishFavoriteChanges)
+	listsStorage.OnChange(server.publishListChanges)
+
+	// Transmettre les événements aux webhooks abonnés
+	recorder.OnChange(server.webhookFavoriteChanges)
+	listsStorage.OnChange(server.webhookListChanges)
+	server.Releases.OnRelease(server.webhookRelease)
+
 	// Analyser les templates
 	if err := server.parseTemplates(); err != nil {
 		return nil, fmt.Errorf("échec lors de l'analyse des templates: %w", err)
@@ -67,9 +185,38 @@ func NewServer(templatesDir, staticDir, dataDir string) (*Server, error) {
 	// Initialiser les routes
 	server.initializeRoutes()
 
+	// Envelopper le routeur dans la chaîne des middlewares configurée
+	server.Handler, err = server.buildHandler(cfg)
+	if err != nil {
+		favoritesStorage.Close()
+		listsStorage.Close()
+		return nil, fmt.Errorf("échec lors de la configuration des middlewares: %w", err)
+	}
+
+	// Démarrer le rafraîchissement périodique des favoris et des listes
+	// automatiques, la recherche de nouvelles sorties et l'envoi des webhooks
+	server.Refresher.Start()
+	server.SmartLists.Start()
+	server.Releases.Start()
+	server.Webhooks.Start()
+
 	return server, nil
 }
 
+// Close arrête les tâches de fond et libère le stockage des favoris et des listes
+func (s *Server) Close() error {
+	s.Refresher.Stop()
+	s.SmartLists.Stop()
+	s.Releases.Stop()
+	s.Webhooks.Stop()
+	s.Imports.Close()
+	s.Events.Close()
+	if err := s.ListsStorage.Close(); err != nil {
+		log.Printf("Erreur lors de la fermeture du stockage des listes: %v", err)
+	}
+	return s.FavoritesStorage.Close()
+}
+
 // parseTemplates analyse tous les templates
 func (s *Server) parseTemplates() error {
 	// Créer une carte de fonctions pour les templates
@@ -93,6 +240,29 @@ func (s *Server) parseTemplates() error {
 		"mod": func(a, b int) int {
 			return a % b
 		},
+		"join":          strings.Join,
+		"favoriteTypes": models.FavoriteTypes,
+		"dict": func(pairs ...interface{}) (map[string]interface{}, error) {
+			if len(pairs)%2 != 0 {
+				return nil, fmt.Errorf("dict attend un nombre pair d'arguments")
+			}
+			m := make(map[string]interface{}, len(pairs)/2)
+			for i := 0; i < len(pairs); i += 2 {
+				key, ok := pairs[i].(string)
+				if !ok {
+					return nil, fmt.Errorf("clé dict invalide : %v", pairs[i])
+				}
+				m[key] = pairs[i+1]
+			}
+			return m, nil
+		},
+		"stars": func(rating int) []bool {
+			stars := make([]bool, models.MaxRating)
+			for i := range stars {
+				stars[i] = i < rating
+			}
+			return stars
+		},
 		"contains": func(slice []string, str string) bool {
 			for _, s := range slice {
 				if s == str {
@@ -165,7 +335,7 @@ func (s *Server) parseTemplates() error {
 }
 
 // renderTemplate rend un template avec les données fournies
-func (s *Server) renderTemplate(w http.ResponseWriter, name string, data interface{}) {
+func (s *Server) renderTemplate(w http.ResponseWriter, r *http.Request, name string, data PageData) {
 	tmpl, ok := s.templates[name]
 	if !ok {
 		// Au lieu d'appeler http.Error qui écrit un en-tête et un corps
@@ -177,6 +347,11 @@ func (s *Server) renderTemplate(w http.ResponseWriter, name string, data interfa
 	// Définir le type de contenu
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 
+	// Injecter le jeton CSRF de la session, renvoyé par les scripts et les formulaires
+	if data.CSRFToken == "" {
+		data.CSRFToken = s.csrfToken(r)
+	}
+
 	// Exécuter le template directement sur l'écrivain
 	if err := tmpl.ExecuteTemplate(w, "base", data); err != nil {
 		// Journaliser l'erreur mais ne pas essayer d'écrire une nouvelle réponse si nous avons déjà commencé
@@ -185,15 +360,19 @@ func (s *Server) renderTemplate(w http.ResponseWriter, name string, data interfa
 }
 
 // PageData représente les données transmises aux templates
+//
+// Les champs propres à la présentation ne figurent pas dans la version JSON
+// des pages (voir renderPage).
 type PageData struct {
-	Title       string
-	IsLoggedIn  bool
-	CurrentPage string
-	Data        interface{}
-	Query       string
-	Filters     map[string]string
-	Pagination  *PaginationData
-	Error       string
+	Title       string            `json:"-"`
+	IsLoggedIn  bool              `json:"-"`
+	CurrentPage string            `json:"-"`
+	CSRFToken   string            `json:"-"` // jeton de la session, voir csrf.go
+	Data        interface{}       `json:",omitempty"`
+	Query       string            `json:",omitempty"`
+	Filters     map[string]string `json:",omitempty"`
+	Pagination  *PaginationData   `json:",omitempty"`
+	Error       string            `json:",omitempty"`
 }
 
 // PaginationData représente les informations de pagination
@@ -208,6 +387,46 @@ type PaginationData struct {
 	NextPage    int
 }
 
+// flagUnavailable signale un favori comme indisponible lorsque Spotify indique
+// qu'il n'existe plus. Les autres erreurs et les éléments non favoris sont ignorés.
+func (s *Server) flagUnavailable(itemType models.FavoriteType, id string, err error) {
+	reason := enrich.UnavailableReason(err)
+	if reason == "" || !s.FavoritesStorage.Contains(id, itemType) {
+		return
+	}
+
+	if err := enrich.MarkUnavailable(s.History.As(history.ActorSystem), itemType, id, reason); err != nil {
+		log.Printf("Erreur lors du signalement du favori indisponible %s %s : %v", itemType, id, err)
+	}
+}
+
+// favoriteKeys renvoie les clés de favoris de tous les éléments des résultats,
+// pour marquer ceux qui sont déjà en favoris
+func favoriteKeys(results *spotify.SearchResults) []models.FavoriteKey {
+	var keys []models.FavoriteKey
+	if results == nil {
+		return keys
+	}
+
+	if results.Artists != nil {
+		for _, artist := range results.Artists.Items {
+			keys = append(keys, models.FavoriteKey{Type: models.FavoriteTypeArtist, ID: artist.ID})
+		}
+	}
+	if results.Albums != nil {
+		for _, album := range results.Albums.Items {
+			keys = append(keys, models.FavoriteKey{Type: models.FavoriteTypeAlbum, ID: album.ID})
+		}
+	}
+	if results.Tracks != nil {
+		for _, track := range results.Tracks.Items {
+			keys = append(keys, models.FavoriteKey{Type: models.FavoriteTypeTrack, ID: track.ID})
+		}
+	}
+
+	return keys
+}
+
 // HomeHandler gère la page d'accueil
 func (s *Server) HomeHandler(w http.ResponseWriter, r *http.Request) {
 	isLoggedIn := s.SpotifyAuth.IsTokenValid()
@@ -245,7 +464,7 @@ func (s *Server) HomeHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Rendre le template
-	s.renderTemplate(w, "home.html", data)
+	s.renderTemplate(w, r, "home.html", data)
 }
 
 // LoginHandler gère la connexion Spotify
@@ -274,16 +493,34 @@ func (s *Server) CallbackHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Identifier le nouvel utilisateur, destinataire des événements ; en cas
+	// d'échec, il le sera à sa première connexion au flux d'événements
+	s.account.set("")
+	if _, err := s.sessionUser(); err != nil {
+		log.Printf("Erreur lors de l'obtention de l'utilisateur Spotify: %v", err)
+	}
+
+	// Changer de session du navigateur, et donc de jeton CSRF, à la connexion
+	if _, err := s.startSession(w, r); err != nil {
+		log.Printf("Erreur lors de la création de la session: %v", err)
+	}
+
 	// Rediriger vers la page d'accueil
 	http.Redirect(w, r, "/", http.StatusSeeOther)
 }
 
-// LogoutHandler gère la déconnexion
+// LogoutHandler gère la déconnexion (POST, protégée contre les requêtes intersites)
 func (s *Server) LogoutHandler(w http.ResponseWriter, r *http.Request) {
 	// Effacer les jetons
 	s.SpotifyAuth.AccessToken = ""
 	s.SpotifyAuth.RefreshToken = ""
 	s.SpotifyAuth.Expiry = time.Time{}
+	s.account.set("")
+
+	// Abandonner la session du navigateur et son jeton CSRF
+	if _, err := s.startSession(w, r); err != nil {
+		log.Printf("Erreur lors de la création de la session: %v", err)
+	}
 
 	// Rediriger vers la page d'accueil
 	http.Redirect(w, r, "/", http.StatusSeeOther)
@@ -292,51 +529,20 @@ func (s *Server) LogoutHandler(w http.ResponseWriter, r *http.Request) {
 func (s *Server) SearchHandler(w http.ResponseWriter, r *http.Request) {
 	// Vérifier si l'utilisateur est connecté
 	if !s.SpotifyAuth.IsTokenValid() {
-		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		pageUnauthorized(w, r)
 		return
 	}
 
 	// Obtenir les paramètres de requête
 	query := r.URL.Query().Get("q")
-	typeParams := r.URL.Query()["type"] // Obtenir toutes les valeurs de type sous forme de slice
-	limitStr := r.URL.Query().Get("limit")
-	pageStr := r.URL.Query().Get("page")
-
-	// Valeurs par défaut
-	types := []string{"artist", "album", "track"}
-	limit := 20
-	page := 1
+	limit, page := parsePageParams(r.URL.Query(), defaultPageLimit)
 
-	// Analyser les paramètres de type
-	if len(typeParams) > 0 {
-		types = typeParams
-		log.Printf("Types de recherche: %v", types)
-	}
-
-	// S'assurer qu'au moins un type est sélectionné
+	// Analyser les paramètres de type, en recherchant tous les types par défaut
+	types := r.URL.Query()["type"]
 	if len(types) == 0 {
-		types = []string{"artist", "album", "track"}
-	}
-
-	// Analyser le paramètre de limite
-	if limitStr != "" {
-		parsedLimit, err := strconv.Atoi(limitStr)
-		if err == nil && parsedLimit > 0 {
-			limit = parsedLimit
-		}
+		types = defaultSearchTypes
 	}
 
-	// Analyser le paramètre de page
-	if pageStr != "" {
-		parsedPage, err := strconv.Atoi(pageStr)
-		if err == nil && parsedPage > 0 {
-			page = parsedPage
-		}
-	}
-
-	// Calculer le décalage
-	offset := (page - 1) * limit
-
 	// Si aucune requête, rendre la page de recherche vide
 	if query == "" {
 		data := PageData{
@@ -345,139 +551,41 @@ func (s *Server) SearchHandler(w http.ResponseWriter, r *http.Request) {
 			CurrentPage: "search",
 			Query:       "",
 		}
-		s.renderTemplate(w, "search.html", data)
+		s.renderPage(w, r, "search.html", data)
 		return
 	}
 
-	// Initialiser des SearchResults vides
-	searchResults := &spotify.SearchResults{}
-	var searchErrors []error
-
-	// Journaliser la recherche que nous sommes sur le point d'effectuer
-	log.Printf("Exécution de la recherche: query=%s, types=%v, limit=%d, offset=%d", query, types, limit, offset)
-
-	// Effectuer une recherche pour tous les types demandés en une seule fois
-	if len(types) > 0 {
-		var err error
-		searchResults, err = s.SpotifyClient.Search(query, types, limit, offset)
-		if err != nil {
-			searchErrors = append(searchErrors, fmt.Errorf("erreur de recherche: %w", err))
-		}
-	}
-
-	// Vérifier si la recherche a échoué
-	if len(searchErrors) > 0 && searchResults == nil {
-		errMessage := "Erreur lors de la recherche Spotify: "
-		for i, err := range searchErrors {
-			if i > 0 {
-				errMessage += ", "
-			}
-			errMessage += err.Error()
+	searchResults, err := s.searchCatalog(query, types, limit, page)
+	if err != nil {
+		if wantsJSON(r) {
+			writeJSONError(w, http.StatusBadGateway, "Erreur lors de la recherche Spotify: "+err.Error())
+			return
 		}
-
 		data := PageData{
 			Title:       "Recherche - MelodyExplorer",
 			IsLoggedIn:  true,
 			CurrentPage: "search",
 			Query:       query,
-			Error:       errMessage,
+			Error:       "Erreur lors de la recherche Spotify: " + err.Error(),
 		}
-		s.renderTemplate(w, "search.html", data)
+		s.renderPage(w, r, "search.html", data)
 		return
 	}
 
-	// Si nous n'avons pas obtenu de résultats, initialiser une structure vide
-	if searchResults == nil {
-		searchResults = &spotify.SearchResults{}
+	// Calculer la pagination de chaque section non vide
+	var artistsPagination, albumsPagination, tracksPagination *PaginationData
+	if len(searchResults.Artists.Items) > 0 {
+		artistsPagination = newPagination(page, limit, searchResults.Artists.Total)
 	}
-
-	// Journaliser ce que nous avons reçu
-	log.Printf("Résultats de recherche reçus:")
-	if searchResults.Artists != nil {
-		log.Printf("- Artistes: %d éléments", len(searchResults.Artists.Items))
-	}
-	if searchResults.Albums != nil {
-		log.Printf("- Albums: %d éléments", len(searchResults.Albums.Items))
+	if len(searchResults.Albums.Items) > 0 {
+		albumsPagination = newPagination(page, limit, searchResults.Albums.Total)
 	}
-	if searchResults.Tracks != nil {
-		log.Printf("- Pistes: %d éléments", len(searchResults.Tracks.Items))
-	}
-
-	responseBody, _ := json.MarshalIndent(searchResults, "", "  ")
-	log.Printf("Résultats bruts de la recherche:\n%s", string(responseBody))
-
-	// S'assurer que les sections existent dans les résultats
-	if searchResults.Artists == nil || searchResults.Artists.Items == nil {
-		log.Printf("La section Artistes est nulle, création d'une section vide")
-		searchResults.Artists = &spotify.ArtistResults{
-			Items: []spotify.Artist{},
-		}
-	}
-	if searchResults.Albums == nil || searchResults.Albums.Items == nil {
-		log.Printf("La section Albums est nulle, création d'une section vide")
-		searchResults.Albums = &spotify.AlbumResults{
-			Items: []spotify.Album{},
-		}
-	}
-	if searchResults.Tracks == nil || searchResults.Tracks.Items == nil {
-		log.Printf("La section Pistes est nulle, création d'une section vide")
-		searchResults.Tracks = &spotify.TrackResults{
-			Items: []spotify.Track{},
-		}
-	}
-
-	// Calculer la pagination pour les artistes
-	var artistsPagination *PaginationData
-	if searchResults.Artists != nil && len(searchResults.Artists.Items) > 0 {
-		artistsPagination = &PaginationData{
-			CurrentPage: page,
-			TotalPages:  (searchResults.Artists.Total + limit - 1) / limit,
-			TotalItems:  searchResults.Artists.Total,
-			Limit:       limit,
-			HasPrev:     page > 1,
-			HasNext:     page*limit < searchResults.Artists.Total,
-			PrevPage:    page - 1,
-			NextPage:    page + 1,
-		}
-	}
-
-	// Calculer la pagination pour les albums
-	var albumsPagination *PaginationData
-	if searchResults.Albums != nil && len(searchResults.Albums.Items) > 0 {
-		albumsPagination = &PaginationData{
-			CurrentPage: page,
-			TotalPages:  (searchResults.Albums.Total + limit - 1) / limit,
-			TotalItems:  searchResults.Albums.Total,
-			Limit:       limit,
-			HasPrev:     page > 1,
-			HasNext:     page*limit < searchResults.Albums.Total,
-			PrevPage:    page - 1,
-			NextPage:    page + 1,
-		}
-	}
-
-	// Calculer la pagination pour les pistes
-	var tracksPagination *PaginationData
-	if searchResults.Tracks != nil && len(searchResults.Tracks.Items) > 0 {
-		tracksPagination = &PaginationData{
-			CurrentPage: page,
-			TotalPages:  (searchResults.Tracks.Total + limit - 1) / limit,
-			TotalItems:  searchResults.Tracks.Total,
-			Limit:       limit,
-			HasPrev:     page > 1,
-			HasNext:     page*limit < searchResults.Tracks.Total,
-			PrevPage:    page - 1,
-			NextPage:    page + 1,
-		}
+	if len(searchResults.Tracks.Items) > 0 {
+		tracksPagination = newPagination(page, limit, searchResults.Tracks.Total)
 	}
 
 	// Marquer les favoris
-	favorites := s.FavoritesStorage.GetAll()
-	favoriteMap := make(map[string]bool)
-	for _, fav := range favorites {
-		key := string(fav.Type) + ":" + fav.ID
-		favoriteMap[key] = true
-	}
+	favoriteMap := s.FavoritesStorage.ContainsMany(favoriteKeys(searchResults))
 
 	// Préparer les données de la page
 	data := PageData{
@@ -489,6 +597,7 @@ func (s *Server) SearchHandler(w http.ResponseWriter, r *http.Request) {
 			"Results":           searchResults,
 			"Types":             types,
 			"Favorites":         favoriteMap,
+			"SavedSearch":       s.FavoritesStorage.Contains(models.SearchFavoriteID(query), models.FavoriteTypeSearch),
 			"ArtistsPagination": artistsPagination,
 			"AlbumsPagination":  albumsPagination,
 			"TracksPagination":  tracksPagination,
@@ -496,7 +605,7 @@ func (s *Server) SearchHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Rendre le template
-	s.renderTemplate(w, "search.html", data)
+	s.renderPage(w, r, "search.html", data)
 }
 
 // CollectionHandler gère la page de collection
@@ -646,7 +755,7 @@ func (s *Server) CollectionHandler(w http.ResponseWriter, r *http.Request) {
 				"Genres": genres,
 			},
 		}
-		s.renderTemplate(w, "collection.html", data)
+		s.renderTemplate(w, r, "collection.html", data)
 		return
 	}
 
@@ -725,12 +834,7 @@ func (s *Server) CollectionHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Ajouter un code similaire à SearchHandler aussi
 	// Marquer les favoris
-	favorites := s.FavoritesStorage.GetAll()
-	favoriteMap := make(map[string]bool)
-	for _, fav := range favorites {
-		key := string(fav.Type) + ":" + fav.ID
-		favoriteMap[key] = true
-	}
+	favoriteMap := s.FavoritesStorage.ContainsMany(favoriteKeys(combinedResults))
 
 	// Préparer les données de la page
 	data := PageData{
@@ -748,14 +852,14 @@ func (s *Server) CollectionHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Rendre le template
-	s.renderTemplate(w, "collection.html", data)
+	s.renderTemplate(w, r, "collection.html", data)
 }
 
 // ArtistHandler gère la page de détails de l'artiste
 func (s *Server) ArtistHandler(w http.ResponseWriter, r *http.Request) {
 	// Vérifier si l'utilisateur est connecté
 	if !s.SpotifyAuth.IsTokenValid() {
-		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		pageUnauthorized(w, r)
 		return
 	}
 
@@ -764,7 +868,7 @@ func (s *Server) ArtistHandler(w http.ResponseWriter, r *http.Request) {
 	id := vars["id"]
 
 	// Obtenir l'artiste depuis Spotify
-	artist, err := s.SpotifyClient.GetArtist(id)
+	artist, err := s.fetchArtist(id)
 	if err != nil {
 		s.ErrorHandler(w, r)
 		log.Printf("Erreur lors de l'obtention de l'artiste: %v", err)
@@ -796,14 +900,14 @@ func (s *Server) ArtistHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Rendre le template
-	s.renderTemplate(w, "details.html", data)
+	s.renderPage(w, r, "details.html", data)
 }
 
 // AlbumHandler gère la page de détails de l'album
 func (s *Server) AlbumHandler(w http.ResponseWriter, r *http.Request) {
 	// Vérifier si l'utilisateur est connecté
 	if !s.SpotifyAuth.IsTokenValid() {
-		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		pageUnauthorized(w, r)
 		return
 	}
 
@@ -812,7 +916,7 @@ func (s *Server) AlbumHandler(w http.ResponseWriter, r *http.Request) {
 	id := vars["id"]
 
 	// Obtenir l'album depuis Spotify
-	album, err := s.SpotifyClient.GetAlbum(id)
+	album, err := s.fetchAlbum(id)
 	if err != nil {
 		s.ErrorHandler(w, r)
 		log.Printf("Erreur lors de l'obtention de l'album: %v", err)
@@ -837,21 +941,22 @@ func (s *Server) AlbumHandler(w http.ResponseWriter, r *http.Request) {
 		CurrentPage: "album",
 		Pagination:  nil, // Définir explicitement à nil
 		Data: map[string]interface{}{
-			"Album":      album,
-			"Tracks":     tracks,
-			"IsFavorite": isFavorite,
+			"Album":         album,
+			"Tracks":        tracks,
+			"IsFavorite":    isFavorite,
+			"LabelFavorite": album.Label != "" && s.FavoritesStorage.Contains(models.LabelFavoriteID(album.Label), models.FavoriteTypeLabel),
 		},
 	}
 
 	// Rendre le template
-	s.renderTemplate(w, "details.html", data)
+	s.renderPage(w, r, "details.html", data)
 }
 
 // TrackHandler gère la page de détails de la piste
 func (s *Server) TrackHandler(w http.ResponseWriter, r *http.Request) {
 	// Vérifier si l'utilisateur est connecté
 	if !s.SpotifyAuth.IsTokenValid() {
-		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		pageUnauthorized(w, r)
 		return
 	}
 
@@ -860,7 +965,7 @@ func (s *Server) TrackHandler(w http.ResponseWriter, r *http.Request) {
 	id := vars["id"]
 
 	// Obtenir la piste depuis Spotify
-	track, err := s.SpotifyClient.GetTrack(id)
+	track, err := s.fetchTrack(id)
 	if err != nil {
 		s.ErrorHandler(w, r)
 		log.Printf("Erreur lors de l'obtention de la piste: %v", err)
@@ -883,157 +988,223 @@ func (s *Server) TrackHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Rendre le template
-	s.renderTemplate(w, "details.html", data)
+	s.renderPage(w, r, "details.html", data)
 }
 
-func (s *Server) AddFavoriteHandler(w http.ResponseWriter, r *http.Request) {
-	// Vérifier si l'utilisateur est connecté
-	if !s.SpotifyAuth.IsTokenValid() {
-		http.Error(w, "Non autorisé", http.StatusUnauthorized)
-		return
-	}
+// favoriteRequest décrit un favori à ajouter
+type favoriteRequest struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Name     string `json:"name"`
+	ImageURL string `json:"image_url"`
+}
 
-	// Analyser le corps de la requête
-	var req struct {
-		ID       string `json:"id"`
-		Type     string `json:"type"`
-		Name     string `json:"name"`
-		ImageURL string `json:"image_url"`
-	}
+// favoriteKeyRequest désigne un favori à supprimer
+type favoriteKeyRequest struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Corps de requête invalide", http.StatusBadRequest)
-		log.Printf("Erreur lors de l'analyse de la requête d'ajout de favori: %v", err)
-		return
-	}
+// inputError signale une donnée refusée par la validation ; son message est
+// destiné au client
+type inputError string
 
+func (e inputError) Error() string {
+	return string(e)
+}
+
+// addFavorite valide et ajoute un favori dans store, avec un instantané de
+// ses métadonnées. Un favori déjà présent conserve sa date d'ajout et ses
+// annotations personnelles. Les données invalides renvoient une inputError.
+func (s *Server) addFavorite(store storage.FavoritesStore, req favoriteRequest) (models.FavoriteItem, error) {
 	// Valider le type
-	var itemType models.FavoriteType
-	switch req.Type {
-	case "artist":
-		itemType = models.FavoriteTypeArtist
-	case "album":
-		itemType = models.FavoriteTypeAlbum
-	case "track":
-		itemType = models.FavoriteTypeTrack
-	default:
-		http.Error(w, "Type invalide", http.StatusBadRequest)
+	typeInfo, ok := models.LookupFavoriteType(models.FavoriteType(req.Type))
+	if !ok {
 		log.Printf("Type de favori invalide: %s", req.Type)
-		return
+		return models.FavoriteItem{}, inputError("Type invalide")
 	}
 
-	log.Printf("Ajout aux favoris: %s (%s - %s)", req.Name, req.Type, req.ID)
-
-	// Créer un élément favori
+	// Créer un élément favori, dont l'identifiant est validé selon son type
 	item := models.FavoriteItem{
 		ID:       req.ID,
-		Type:     itemType,
+		Type:     typeInfo.Type,
 		Name:     req.Name,
 		ImageURL: req.ImageURL,
 		AddedAt:  time.Now(),
 	}
+	if err := typeInfo.Normalize(&item); err != nil {
+		log.Printf("Favori invalide (%s - %s): %v", req.Type, req.ID, err)
+		return models.FavoriteItem{}, inputError("Favori invalide: " + err.Error())
+	}
+
+	log.Printf("Ajout aux favoris: %s (%s - %s)", item.Name, item.Type, item.ID)
+
+	// Enregistrer un instantané des métadonnées pour les vues hors ligne.
+	// En cas d'échec, le favori est tout de même ajouté sans métadonnées.
+	if typeInfo.Spotify {
+		snapshot, err := enrich.Fetch(s.SpotifyClient, item.Type, item.ID)
+		if err != nil {
+			log.Printf("Impossible de récupérer les métadonnées de %s (%s): %v", item.ID, item.Type, err)
+		} else {
+			snapshot.ApplyTo(&item)
+			now := time.Now()
+			item.LastRefreshedAt = &now
+		}
+	}
 
 	// Ajouter aux favoris et sauvegarder dans le fichier
-	if err := s.FavoritesStorage.Add(item); err != nil {
-		http.Error(w, "Échec lors de l'ajout aux favoris: "+err.Error(), http.StatusInternalServerError)
+	err := store.Update(func(tx *storage.Tx) error {
+		if existing, ok := tx.Get(item.ID, item.Type); ok {
+			item.AddedAt = existing.AddedAt
+			item.CopyAnnotations(existing)
+		}
+		tx.Add(item)
+		return nil
+	})
+	if err != nil {
 		log.Printf("Erreur lors de l'ajout du favori: %v", err)
+		return models.FavoriteItem{}, err
+	}
+	return item, nil
+}
+
+// AddFavoriteHandler gère l'ajout d'éléments aux favoris
+func (s *Server) AddFavoriteHandler(w http.ResponseWriter, r *http.Request) {
+	// Vérifier si l'utilisateur est connecté
+	if !s.isAuthenticated(r) {
+		http.Error(w, "Non autorisé", http.StatusUnauthorized)
+		return
+	}
+
+	// Analyser le corps de la requête
+	var req favoriteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Corps de requête invalide", http.StatusBadRequest)
+		log.Printf("Erreur lors de l'analyse de la requête d'ajout de favori: %v", err)
+		return
+	}
+
+	var invalid inputError
+	if _, err := s.addFavorite(s.favoritesFor(r), req); errors.As(err, &invalid) {
+		http.Error(w, invalid.Error(), http.StatusBadRequest)
+		return
+	} else if err != nil {
+		http.Error(w, "Échec lors de l'ajout aux favoris: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	// Retourner succès
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+	writeJSON(w, http.StatusOK, successResponse{Success: true})
 }
 
 // RemoveFavoriteHandler gère la suppression d'éléments des favoris
 func (s *Server) RemoveFavoriteHandler(w http.ResponseWriter, r *http.Request) {
 	// Vérifier si l'utilisateur est connecté
-	if !s.SpotifyAuth.IsTokenValid() {
+	if !s.isAuthenticated(r) {
 		http.Error(w, "Non autorisé", http.StatusUnauthorized)
 		return
 	}
 
 	// Analyser le corps de la requête
-	var req struct {
-		ID   string `json:"id"`
-		Type string `json:"type"`
-	}
-
+	var req favoriteKeyRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Corps de requête invalide", http.StatusBadRequest)
 		log.Printf("Erreur lors de l'analyse de la requête de suppression de favori: %v", err)
 		return
 	}
 
-	// Valider le type
-	var itemType models.FavoriteType
-	switch req.Type {
-	case "artist":
-		itemType = models.FavoriteTypeArtist
-	case "album":
-		itemType = models.FavoriteTypeAlbum
-	case "track":
-		itemType = models.FavoriteTypeTrack
-	default:
+	// Valider le type et retrouver l'identifiant canonique de l'élément
+	typeInfo, ok := models.LookupFavoriteType(models.FavoriteType(req.Type))
+	if !ok {
 		http.Error(w, "Type invalide", http.StatusBadRequest)
 		log.Printf("Type de favori invalide pour la suppression: %s", req.Type)
 		return
 	}
+	item := models.FavoriteItem{ID: req.ID, Type: typeInfo.Type}
+	if err := typeInfo.Normalize(&item); err != nil {
+		http.Error(w, "Favori invalide: "+err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	log.Printf("Suppression du favori: %s (%s)", req.ID, req.Type)
+	log.Printf("Suppression du favori: %s (%s)", item.ID, item.Type)
 
 	// Supprimer des favoris et sauvegarder dans le fichier
-	if err := s.FavoritesStorage.Remove(req.ID, itemType); err != nil {
+	if err := s.favoritesFor(r).Remove(item.ID, item.Type); err != nil {
 		http.Error(w, "Échec lors de la suppression des favoris: "+err.Error(), http.StatusInternalServerError)
 		log.Printf("Erreur lors de la suppression du favori: %v", err)
 		return
 	}
 
 	// Retourner succès
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+	writeJSON(w, http.StatusOK, successResponse{Success: true})
+}
+
+// favoritesSection regroupe les favoris d'un type sur la page des favoris
+type favoritesSection struct {
+	Type  models.FavoriteTypeInfo
+	Items []models.FavoriteItem
 }
 
 // FavoritesHandler gère la page des favoris
 func (s *Server) FavoritesHandler(w http.ResponseWriter, r *http.Request) {
 	// Vérifier si l'utilisateur est connecté
 	if !s.SpotifyAuth.IsTokenValid() {
-		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		pageUnauthorized(w, r)
 		return
 	}
 
 	// Obtenir les favoris
-	favorites := s.FavoritesStorage.GetAll()
-
-	// Organiser les favoris par type
-	artists := s.FavoritesStorage.GetByType(models.FavoriteTypeArtist)
-	albums := s.FavoritesStorage.GetByType(models.FavoriteTypeAlbum)
-	tracks := s.FavoritesStorage.GetByType(models.FavoriteTypeTrack)
+	all := s.FavoritesStorage.GetAll()
+
+	// Filtrer les favoris à partir de leurs métadonnées enregistrées
+	filter := parseFavoritesFilter(r.URL.Query())
+	favorites := filter.apply(all)
+
+	// Organiser les favoris par type à partir du même instantané, dans l'ordre
+	// du registre des types ; les favoris indisponibles sur Spotify sont
+	// regroupés dans une section à part
+	byType := make(map[models.FavoriteType][]models.FavoriteItem)
+	var unavailable []models.FavoriteItem
+	for _, item := range favorites {
+		if item.IsUnavailable() {
+			unavailable = append(unavailable, item)
+			continue
+		}
+		byType[item.Type] = append(byType[item.Type], item)
+	}
+	var sections []favoritesSection
+	for _, info := range models.FavoriteTypes() {
+		if items := byType[info.Type]; len(items) > 0 {
+			sections = append(sections, favoritesSection{Type: info, Items: items})
+		}
+	}
 
 	// Préparer les données de la page
 	data := PageData{
 		Title:       "Mes Favoris - MelodyExplorer",
 		IsLoggedIn:  true,
 		CurrentPage: "favorites",
+		Filters:     filter.values(),
 		Data: map[string]interface{}{
-			"Favorites": favorites,
-			"Artists":   artists,
-			"Albums":    albums,
-			"Tracks":    tracks,
+			"Favorites":   favorites,
+			"Sections":    sections,
+			"Unavailable": unavailable,
+			"Lists":       s.ListsStorage.All(),
+			"Facets":      buildFavoritesFacets(all),
+			"Filtered":    filter.active(),
+			"Total":       len(all),
 		},
 	}
 
 	// Rendre le template
-	s.renderTemplate(w, "favorites.html", data)
+	s.renderPage(w, r, "favorites.html", data)
 }
 
 // CategoryHandler gère la page de catégorie/genre
 func (s *Server) CategoryHandler(w http.ResponseWriter, r *http.Request) {
 	// Vérifier si l'utilisateur est connecté
 	if !s.SpotifyAuth.IsTokenValid() {
-		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		pageUnauthorized(w, r)
 		return
 	}
 
@@ -1042,86 +1213,20 @@ func (s *Server) CategoryHandler(w http.ResponseWriter, r *http.Request) {
 	genre := vars["genre"]
 
 	// Obtenir les paramètres de pagination
-	limitStr := r.URL.Query().Get("limit")
-	pageStr := r.URL.Query().Get("page")
-
-	// Valeurs par défaut
-	limit := 20
-	page := 1
-
-	// Analyser le paramètre de limite
-	if limitStr != "" {
-		parsedLimit, err := strconv.Atoi(limitStr)
-		if err == nil && parsedLimit > 0 {
-			limit = parsedLimit
-		}
-	}
-
-	// Analyser le paramètre de page
-	if pageStr != "" {
-		parsedPage, err := strconv.Atoi(pageStr)
-		if err == nil && parsedPage > 0 {
-			page = parsedPage
-		}
-	}
+	limit, page := parsePageParams(r.URL.Query(), defaultPageLimit)
 
-	// Calculer le décalage
-	offset := (page - 1) * limit
-
-	// Rechercher le genre avec une meilleure requête pour obtenir plus de résultats
-	query := "genre:" + genre
-	// Ajouter quelques termes de filtre populaires pour obtenir de meilleurs résultats
-	if page > 5 {
-		// Pour les pages ultérieures, ajouter des plages d'années pour obtenir des résultats plus diversifiés
-		yearStart := 2020 - ((page / 5) * 10)
-		yearEnd := yearStart + 9
-		if yearStart < 1950 {
-			yearStart = 1950
-		}
-		query += " year:" + strconv.Itoa(yearStart) + "-" + strconv.Itoa(yearEnd)
-	}
-
-	log.Printf("Requête de recherche de catégorie: %s, page: %d, décalage: %d", query, page, offset)
-
-	// Essayer avec une limite plus élevée pour s'assurer d'obtenir suffisamment de résultats
-	searchLimit := limit * 2
-	results, err := s.SpotifyClient.Search(query, []string{"track"}, searchLimit, offset)
+	results, pagination, err := s.searchCategory(genre, limit, page)
 	if err != nil {
+		if wantsJSON(r) {
+			writeJSONError(w, http.StatusBadGateway, "Erreur lors de la recherche Spotify: "+err.Error())
+			return
+		}
 		http.Error(w, "Erreur lors de la recherche Spotify: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// Calculer la pagination avec des pages totales fixes pour assurer une navigation cohérente
-	totalItems := 1000 // Définir une limite fixe raisonnable
-	if results.Tracks != nil && results.Tracks.Total > 0 && results.Tracks.Total < totalItems {
-		totalItems = results.Tracks.Total
-	}
-
-	totalPages := (totalItems + limit - 1) / limit
-
-	// S'assurer que nous affichons au moins 20 pages si nous avons des résultats
-	if totalPages < 20 && results.Tracks != nil && len(results.Tracks.Items) > 0 {
-		totalPages = 20
-	}
-
-	pagination := &PaginationData{
-		CurrentPage: page,
-		TotalPages:  totalPages,
-		TotalItems:  totalItems,
-		Limit:       limit,
-		HasPrev:     page > 1,
-		HasNext:     page < totalPages,
-		PrevPage:    page - 1,
-		NextPage:    page + 1,
-	}
-
 	// Marquer les favoris
-	favorites := s.FavoritesStorage.GetAll()
-	favoriteMap := make(map[string]bool)
-	for _, fav := range favorites {
-		key := string(fav.Type) + ":" + fav.ID
-		favoriteMap[key] = true
-	}
+	favoriteMap := s.FavoritesStorage.ContainsMany(favoriteKeys(results))
 
 	// Préparer les données de la page
 	data := PageData{
@@ -1130,14 +1235,15 @@ func (s *Server) CategoryHandler(w http.ResponseWriter, r *http.Request) {
 		CurrentPage: "category",
 		Pagination:  pagination,
 		Data: map[string]interface{}{
-			"Genre":     genre,
-			"Results":   results,
-			"Favorites": favoriteMap,
+			"Genre":      genre,
+			"Results":    results,
+			"Favorites":  favoriteMap,
+			"IsFavorite": s.FavoritesStorage.Contains(genre, models.FavoriteTypeCategory),
 		},
 	}
 
 	// Rendre le template
-	s.renderTemplate(w, "category.html", data)
+	s.renderPage(w, r, "category.html", data)
 }
 
 // AboutHandler gère la page à propos
@@ -1148,11 +1254,16 @@ func (s *Server) AboutHandler(w http.ResponseWriter, r *http.Request) {
 		CurrentPage: "about",
 	}
 
-	s.renderTemplate(w, "about.html", data)
+	s.renderTemplate(w, r, "about.html", data)
 }
 
 // ErrorHandler gère les erreurs
 func (s *Server) ErrorHandler(w http.ResponseWriter, r *http.Request) {
+	if wantsJSON(r) {
+		writeJSONError(w, http.StatusNotFound, "La page que vous avez demandée est introuvable")
+		return
+	}
+
 	data := PageData{
 		Title:       "Erreur - MelodyExplorer",
 		IsLoggedIn:  s.SpotifyAuth.IsTokenValid(),
@@ -1160,7 +1271,7 @@ func (s *Server) ErrorHandler(w http.ResponseWriter, r *http.Request) {
 		Error:       "La page que vous avez demandée est introuvable",
 	}
 
-	s.renderTemplate(w, "error.html", data)
+	s.renderTemplate(w, r, "error.html", data)
 }
 
 // RecommendationHandler gère la page de recommandation personnelle (Tame Impala)
@@ -1221,5 +1332,5 @@ func (s *Server) RecommendationHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Rendre le template
-	s.renderTemplate(w, "recommandation.html", data)
+	s.renderTemplate(w, r, "recommandation.html", data)
 }
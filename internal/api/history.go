@@ -0,0 +1,172 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/yourusername/melody-explorer/internal/history"
+	"github.com/yourusername/melody-explorer/internal/models"
+)
+
+// historyPageSize est le nombre d'entrées affichées par page de l'historique
+const historyPageSize = 50
+
+// maxHistoryLimit est le nombre maximal d'entrées renvoyées par l'API
+const maxHistoryLimit = 200
+
+// historyResponse est la réponse de GET /api/favorites/history
+type historyResponse struct {
+	Entries []history.Entry `json:"entries"`
+	// NextBefore est la valeur de ?before= de la page suivante, s'il y en a une
+	NextBefore int64 `json:"next_before,omitempty"`
+}
+
+// historyChangesResponse est la réponse d'une annulation ou d'une
+// restauration : les modifications effectuées
+type historyChangesResponse struct {
+	Success bool            `json:"success"`
+	Entries []history.Entry `json:"entries"`
+}
+
+// restoreRequest est le corps de POST /api/favorites/restore
+type restoreRequest struct {
+	At time.Time `json:"at"`
+}
+
+// historyQuery lit les filtres type et id de l'historique
+func historyQuery(r *http.Request) (history.Query, bool) {
+	q := history.Query{ID: r.URL.Query().Get("id")}
+	if value := r.URL.Query().Get("type"); value != "" {
+		itemType, ok := models.ParseFavoriteType(value)
+		if !ok {
+			return q, false
+		}
+		q.Type = itemType
+	}
+	return q, true
+}
+
+// HistoryHandler gère la page de l'historique des favoris
+func (s *Server) HistoryHandler(w http.ResponseWriter, r *http.Request) {
+	// Vérifier si l'utilisateur est connecté
+	if !s.SpotifyAuth.IsTokenValid() {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	q, _ := historyQuery(r)
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	total := s.History.Log().Count(q)
+	q.Offset = (page - 1) * historyPageSize
+	q.Limit = historyPageSize
+
+	data := PageData{
+		Title:       "Historique des favoris - MelodyExplorer",
+		IsLoggedIn:  true,
+		CurrentPage: "favorites",
+		Data: map[string]interface{}{
+			"Entries": s.History.Log().Entries(q),
+		},
+		Filters: map[string]string{
+			"type": string(q.Type),
+			"id":   q.ID,
+		},
+		Pagination: &PaginationData{
+			CurrentPage: page,
+			TotalPages:  (total + historyPageSize - 1) / historyPageSize,
+			TotalItems:  total,
+			Limit:       historyPageSize,
+			HasPrev:     page > 1,
+			HasNext:     page*historyPageSize < total,
+			PrevPage:    page - 1,
+			NextPage:    page + 1,
+		},
+	}
+
+	s.renderTemplate(w, r, "history.html", data)
+}
+
+// HistoryAPIHandler renvoie l'historique des favoris, les entrées les plus
+// récentes d'abord. ?before= reprend la lecture avant un numéro d'entrée.
+func (s *Server) HistoryAPIHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.isAuthenticated(r) {
+		writeJSONError(w, http.StatusUnauthorized, "Non autorisé")
+		return
+	}
+
+	q, ok := historyQuery(r)
+	if !ok {
+		writeJSONError(w, http.StatusBadRequest, "Type invalide")
+		return
+	}
+	q.Before, _ = strconv.ParseInt(r.URL.Query().Get("before"), 10, 64)
+	q.Limit, _ = strconv.Atoi(r.URL.Query().Get("limit"))
+	if q.Limit <= 0 || q.Limit > maxHistoryLimit {
+		q.Limit = historyPageSize
+	}
+
+	entries := s.History.Log().Entries(q)
+	response := historyResponse{Entries: entries}
+	if len(entries) == q.Limit {
+		response.NextBefore = entries[len(entries)-1].Seq
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// UndoFavoritesHandler annule la dernière opération sur les favoris
+func (s *Server) UndoFavoritesHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.isAuthenticated(r) {
+		writeJSONError(w, http.StatusUnauthorized, "Non autorisé")
+		return
+	}
+
+	entries, err := s.History.Undo()
+	if errors.Is(err, history.ErrNothingToUndo) {
+		writeJSONError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("Erreur lors de l'annulation: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Échec de l'annulation")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, historyChangesResponse{Success: true, Entries: entries})
+}
+
+// RestoreFavoritesHandler ramène les favoris à leur état à la date donnée
+// (champ « at » au format RFC 3339)
+func (s *Server) RestoreFavoritesHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.isAuthenticated(r) {
+		writeJSONError(w, http.StatusUnauthorized, "Non autorisé")
+		return
+	}
+
+	var req restoreRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.At.IsZero() {
+		writeJSONError(w, http.StatusBadRequest, "Date invalide, format attendu : 2006-01-02T15:04:05Z07:00")
+		return
+	}
+	if req.At.After(time.Now()) {
+		writeJSONError(w, http.StatusBadRequest, "La date doit être dans le passé")
+		return
+	}
+
+	entries, err := s.History.RestoreAt(req.At)
+	if err != nil {
+		log.Printf("Erreur lors de la restauration des favoris: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Échec de la restauration")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, historyChangesResponse{Success: true, Entries: entries})
+}
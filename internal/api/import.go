@@ -0,0 +1,157 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/yourusername/melody-explorer/internal/importer"
+)
+
+// maxImportSize est la taille maximale d'un fichier importé
+const maxImportSize = 10 << 20
+
+// importCommitRequest est le corps de la validation d'un import. Sans items,
+// les correspondances retenues par défaut sont ajoutées.
+type importCommitRequest struct {
+	Items []importer.Selection `json:"items"`
+}
+
+// ImportHandler gère la page d'import de bibliothèques
+func (s *Server) ImportHandler(w http.ResponseWriter, r *http.Request) {
+	// Vérifier si l'utilisateur est connecté
+	if !s.SpotifyAuth.IsTokenValid() {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	data := PageData{
+		Title:       "Importer des favoris - MelodyExplorer",
+		IsLoggedIn:  true,
+		CurrentPage: "favorites",
+		Data: map[string]interface{}{
+			"ReportID": r.URL.Query().Get("report"),
+		},
+	}
+
+	s.renderTemplate(w, r, "import.html", data)
+}
+
+// StartImportHandler reçoit un fichier (champ « file » d'un formulaire
+// multipart, ou corps brut avec ?filename=) et lance la recherche des
+// correspondances. Le format est détecté si ?format= est absent.
+func (s *Server) StartImportHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.isAuthenticated(r) {
+		writeJSONError(w, http.StatusUnauthorized, "Non autorisé")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	filename := r.URL.Query().Get("filename")
+	format := r.URL.Query().Get("format")
+
+	var data []byte
+	var err error
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, header, ferr := r.FormFile("file")
+		if ferr != nil {
+			writeJSONError(w, http.StatusBadRequest, "Fichier manquant ou trop volumineux")
+			return
+		}
+		defer file.Close()
+		filename = header.Filename
+		if value := r.FormValue("format"); value != "" {
+			format = value
+		}
+		data, err = io.ReadAll(file)
+	} else {
+		data, err = io.ReadAll(r.Body)
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Fichier illisible ou trop volumineux")
+		return
+	}
+
+	report, err := s.Imports.Start(filepath.Base(filename), format, data)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, map[string]interface{}{
+		"success": true,
+		"report":  report,
+	})
+}
+
+// ImportReportHandler renvoie le rapport de correspondance d'un import
+func (s *Server) ImportReportHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.isAuthenticated(r) {
+		writeJSONError(w, http.StatusUnauthorized, "Non autorisé")
+		return
+	}
+
+	report, ok := s.Imports.Get(mux.Vars(r)["id"])
+	if !ok {
+		writeJSONError(w, http.StatusNotFound, importer.ErrReportNotFound.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"report": report,
+	})
+}
+
+// CommitImportHandler ajoute aux favoris les correspondances validées
+func (s *Server) CommitImportHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.isAuthenticated(r) {
+		writeJSONError(w, http.StatusUnauthorized, "Non autorisé")
+		return
+	}
+
+	var req importCommitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		writeJSONError(w, http.StatusBadRequest, "Corps de requête invalide")
+		return
+	}
+
+	result, err := s.Imports.Commit(mux.Vars(r)["id"], req.Items)
+	switch {
+	case errors.Is(err, importer.ErrReportNotFound):
+		writeJSONError(w, http.StatusNotFound, err.Error())
+		return
+	case errors.Is(err, importer.ErrNotReady):
+		writeJSONError(w, http.StatusConflict, err.Error())
+		return
+	case err != nil:
+		log.Printf("Erreur lors de la validation de l'import: %v", err)
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"added":   result.Added,
+		"skipped": result.Skipped,
+	})
+}
+
+// DiscardImportHandler abandonne un import
+func (s *Server) DiscardImportHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.isAuthenticated(r) {
+		writeJSONError(w, http.StatusUnauthorized, "Non autorisé")
+		return
+	}
+
+	if !s.Imports.Discard(mux.Vars(r)["id"]) {
+		writeJSONError(w, http.StatusNotFound, importer.ErrReportNotFound.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
@@ -0,0 +1,32 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+// writeJSON écrit une réponse JSON avec le code d'état donné
+func writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		log.Printf("Erreur lors de l'encodage de la réponse JSON: %v", err)
+	}
+}
+
+// successResponse est la réponse des opérations qui ne renvoient pas de données
+type successResponse struct {
+	Success bool `json:"success"`
+}
+
+// failureResponse est la réponse d'erreur des routes JSON hors de /api/v1
+type failureResponse struct {
+	Success bool   `json:"success"`
+	Error   string `json:"error"`
+}
+
+// writeJSONError écrit une erreur JSON avec le code d'état donné
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, failureResponse{Success: false, Error: message})
+}
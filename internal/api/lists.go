@@ -0,0 +1,554 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/yourusername/melody-explorer/internal/models"
+	"github.com/yourusername/melody-explorer/internal/storage"
+)
+
+// maxListNameLength est la taille maximale du nom d'une liste, en caractères
+const maxListNameLength = 100
+
+var (
+	// errNotInList est renvoyée lorsque l'élément visé n'est pas dans la liste
+	errNotInList = errors.New("élément absent de la liste")
+	// errSmartList est renvoyée lors d'une modification manuelle d'une liste automatique
+	errSmartList = errors.New("les éléments d'une liste automatique sont calculés par sa règle")
+)
+
+// listRequest est le corps des requêtes de création et de modification de liste.
+// Lors d'une modification, seuls les champs présents sont modifiés.
+type listRequest struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	CoverURL    *string `json:"cover_url"`
+	// Rule transforme la liste en liste automatique
+	Rule *models.SmartRule `json:"rule"`
+}
+
+// validate vérifie les champs renseignés et renvoie un message d'erreur
+func (req listRequest) validate() string {
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return "Le nom de la liste est obligatoire"
+		}
+		if len([]rune(name)) > maxListNameLength {
+			return "Nom de liste trop long"
+		}
+	}
+	if req.Description != nil && len([]rune(*req.Description)) > maxNoteLength {
+		return "Description trop longue"
+	}
+	if req.CoverURL != nil && *req.CoverURL != "" {
+		u, err := url.Parse(*req.CoverURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "URL de couverture invalide"
+		}
+	}
+	if req.Rule != nil {
+		req.Rule.Normalize()
+		if err := req.Rule.Validate(); err != nil {
+			return "Règle invalide : " + err.Error()
+		}
+	}
+	return ""
+}
+
+// apply reporte les champs renseignés sur la liste
+func (req listRequest) apply(list *models.List) {
+	if req.Name != nil {
+		list.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Description != nil {
+		list.Description = strings.TrimSpace(*req.Description)
+	}
+	if req.CoverURL != nil {
+		list.CoverURL = strings.TrimSpace(*req.CoverURL)
+	}
+	if req.Rule != nil {
+		rule := *req.Rule
+		list.Rule = &rule
+	}
+}
+
+// listSummary décrit une liste sans ses éléments
+type listSummary struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Cover       string `json:"cover,omitempty"`
+	Count       int    `json:"count"`
+	// Rule décrit la règle d'une liste automatique
+	Rule string `json:"rule,omitempty"`
+}
+
+// listsResponse est la réponse de GET /api/lists
+type listsResponse struct {
+	Lists []listSummary `json:"lists"`
+}
+
+// listResponse renvoie une liste avec ses éléments ; success est présent
+// après une modification
+type listResponse struct {
+	Success bool        `json:"success,omitempty"`
+	List    models.List `json:"list"`
+}
+
+// listItemRequest est le corps de l'ajout d'un élément à une liste
+type listItemRequest struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Name     string `json:"name"`
+	ImageURL string `json:"image_url"`
+	// Position est la position de l'élément, à la fin si absente
+	Position *int `json:"position"`
+}
+
+// listItemMoveRequest est le corps du déplacement d'un élément d'une liste
+type listItemMoveRequest struct {
+	Position *int `json:"position"`
+}
+
+// summarizeList construit le résumé d'une liste
+func summarizeList(list models.List) listSummary {
+	summary := listSummary{
+		ID:          list.ID,
+		Name:        list.Name,
+		Description: list.Description,
+		Cover:       list.Cover(),
+		Count:       list.Len(),
+	}
+	if list.IsSmart() {
+		summary.Rule = list.Rule.Describe()
+	}
+	return summary
+}
+
+// ListsAPIHandler renvoie le résumé de toutes les listes
+func (s *Server) ListsAPIHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.isAuthenticated(r) {
+		writeJSONError(w, http.StatusUnauthorized, "Non autorisé")
+		return
+	}
+
+	lists := s.ListsStorage.All()
+	summaries := make([]listSummary, 0, len(lists))
+	for _, list := range lists {
+		summaries = append(summaries, summarizeList(list))
+	}
+
+	writeJSON(w, http.StatusOK, listsResponse{Lists: summaries})
+}
+
+// createList valide et crée une liste. Une liste automatique est évaluée
+// immédiatement ; une erreur d'évaluation est enregistrée sur la liste sans
+// faire échouer la création. Les données invalides renvoient une inputError.
+func (s *Server) createList(req listRequest) (models.List, error) {
+	if req.Name == nil {
+		return models.List{}, inputError("Le nom de la liste est obligatoire")
+	}
+	if msg := req.validate(); msg != "" {
+		return models.List{}, inputError(msg)
+	}
+
+	list := models.NewList("", "", "")
+	req.apply(&list)
+
+	err := s.ListsStorage.Update(func(tx *storage.ListsTx) error {
+		tx.Create(list)
+		return nil
+	})
+	if err != nil {
+		log.Printf("Erreur lors de la création de la liste: %v", err)
+		return models.List{}, err
+	}
+
+	log.Printf("Liste créée: %s (%s)", list.Name, list.ID)
+
+	if list.IsSmart() {
+		list = s.refreshSmartList(list)
+	}
+	return list, nil
+}
+
+// CreateListHandler crée une nouvelle liste
+func (s *Server) CreateListHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.isAuthenticated(r) {
+		writeJSONError(w, http.StatusUnauthorized, "Non autorisé")
+		return
+	}
+
+	var req listRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Corps de requête invalide")
+		log.Printf("Erreur lors de l'analyse de la requête de création de liste: %v", err)
+		return
+	}
+
+	var invalid inputError
+	list, err := s.createList(req)
+	if errors.As(err, &invalid) {
+		writeJSONError(w, http.StatusBadRequest, invalid.Error())
+		return
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Échec lors de la création de la liste: "+err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, listResponse{Success: true, List: list})
+}
+
+// GetListHandler renvoie une liste avec ses éléments
+func (s *Server) GetListHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.isAuthenticated(r) {
+		writeJSONError(w, http.StatusUnauthorized, "Non autorisé")
+		return
+	}
+
+	list, ok := s.ListsStorage.Get(mux.Vars(r)["id"])
+	if !ok {
+		writeJSONError(w, http.StatusNotFound, "Liste introuvable")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, listResponse{List: list})
+}
+
+// UpdateListHandler modifie le nom, la description ou la couverture d'une liste
+func (s *Server) UpdateListHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.isAuthenticated(r) {
+		writeJSONError(w, http.StatusUnauthorized, "Non autorisé")
+		return
+	}
+
+	var req listRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Corps de requête invalide")
+		log.Printf("Erreur lors de l'analyse de la requête de modification de liste: %v", err)
+		return
+	}
+	if msg := req.validate(); msg != "" {
+		writeJSONError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	list, ok := s.updateList(w, mux.Vars(r)["id"], func(list *models.List) error {
+		req.apply(list)
+		return nil
+	})
+	if !ok {
+		return
+	}
+
+	// Réévaluer une liste dont la règle a changé
+	if req.Rule != nil {
+		list = s.refreshSmartList(list)
+	}
+
+	writeListResponse(w, list)
+}
+
+// RefreshListHandler réévalue une liste automatique à la demande
+func (s *Server) RefreshListHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.isAuthenticated(r) {
+		writeJSONError(w, http.StatusUnauthorized, "Non autorisé")
+		return
+	}
+
+	list, ok := s.ListsStorage.Get(mux.Vars(r)["id"])
+	if !ok {
+		writeJSONError(w, http.StatusNotFound, "Liste introuvable")
+		return
+	}
+	if !list.IsSmart() {
+		writeJSONError(w, http.StatusConflict, "Cette liste n'est pas une liste automatique")
+		return
+	}
+
+	list, err := s.SmartLists.Refresh(list.ID)
+	if err == storage.ErrListNotFound {
+		writeJSONError(w, http.StatusNotFound, "Liste introuvable")
+		return
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusBadGateway, "Échec lors de l'évaluation de la liste: "+err.Error())
+		return
+	}
+
+	writeListResponse(w, list)
+}
+
+// refreshSmartList réévalue une liste automatique et renvoie sa dernière
+// version ; les erreurs d'évaluation sont enregistrées sur la liste
+func (s *Server) refreshSmartList(list models.List) models.List {
+	refreshed, err := s.SmartLists.Refresh(list.ID)
+	if err != nil {
+		log.Printf("Erreur lors de l'évaluation de la liste automatique %s: %v", list.ID, err)
+		if refreshed.ID == "" {
+			return list
+		}
+	}
+	return refreshed
+}
+
+// DeleteListHandler supprime une liste
+func (s *Server) DeleteListHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.isAuthenticated(r) {
+		writeJSONError(w, http.StatusUnauthorized, "Non autorisé")
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	err := s.ListsStorage.Update(func(tx *storage.ListsTx) error {
+		if !tx.Delete(id) {
+			return storage.ErrListNotFound
+		}
+		return nil
+	})
+	if err == storage.ErrListNotFound {
+		writeJSONError(w, http.StatusNotFound, "Liste introuvable")
+		return
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Échec lors de la suppression de la liste: "+err.Error())
+		log.Printf("Erreur lors de la suppression de la liste: %v", err)
+		return
+	}
+
+	log.Printf("Liste supprimée: %s", id)
+	writeJSON(w, http.StatusOK, successResponse{Success: true})
+}
+
+// AddListItemHandler ajoute un élément à une liste, à la position demandée ou
+// à la fin. Un élément déjà présent est déplacé.
+func (s *Server) AddListItemHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.isAuthenticated(r) {
+		writeJSONError(w, http.StatusUnauthorized, "Non autorisé")
+		return
+	}
+
+	var req listItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Corps de requête invalide")
+		log.Printf("Erreur lors de l'analyse de la requête d'ajout à une liste: %v", err)
+		return
+	}
+
+	typeInfo, ok := models.LookupFavoriteType(models.FavoriteType(req.Type))
+	if !ok {
+		writeJSONError(w, http.StatusBadRequest, "Type ou identifiant invalide")
+		return
+	}
+	item := models.FavoriteItem{ID: req.ID, Type: typeInfo.Type, Name: req.Name}
+	if err := typeInfo.Normalize(&item); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Type ou identifiant invalide : "+err.Error())
+		return
+	}
+
+	// Reprendre le nom et l'image du favori s'ils ne sont pas fournis
+	entry := models.ListEntry{ID: item.ID, Type: item.Type, Name: req.Name, ImageURL: req.ImageURL}
+	if favorite, ok := s.findFavorite(item.ID, item.Type); ok {
+		if entry.Name == "" {
+			entry.Name = favorite.Name
+		}
+		if entry.ImageURL == "" {
+			entry.ImageURL = favorite.ImageURL
+		}
+	}
+
+	position := -1
+	if req.Position != nil {
+		position = *req.Position
+	}
+
+	s.updateListEntries(w, mux.Vars(r)["id"], func(list *models.List) error {
+		list.Add(entry, position)
+		return nil
+	})
+}
+
+// UpdateListItemHandler déplace un élément d'une liste à une nouvelle position
+func (s *Server) UpdateListItemHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.isAuthenticated(r) {
+		writeJSONError(w, http.StatusUnauthorized, "Non autorisé")
+		return
+	}
+
+	vars := mux.Vars(r)
+	itemType, ok := models.ParseFavoriteType(vars["type"])
+	if !ok {
+		writeJSONError(w, http.StatusBadRequest, "Type invalide")
+		return
+	}
+
+	var req listItemMoveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Position == nil {
+		writeJSONError(w, http.StatusBadRequest, "Position manquante")
+		return
+	}
+
+	s.updateListEntries(w, vars["id"], func(list *models.List) error {
+		if !list.Move(vars["itemID"], itemType, *req.Position) {
+			return errNotInList
+		}
+		return nil
+	})
+}
+
+// RemoveListItemHandler retire un élément d'une liste
+func (s *Server) RemoveListItemHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.isAuthenticated(r) {
+		writeJSONError(w, http.StatusUnauthorized, "Non autorisé")
+		return
+	}
+
+	vars := mux.Vars(r)
+	itemType, ok := models.ParseFavoriteType(vars["type"])
+	if !ok {
+		writeJSONError(w, http.StatusBadRequest, "Type invalide")
+		return
+	}
+
+	s.updateListEntries(w, vars["id"], func(list *models.List) error {
+		if !list.Remove(vars["itemID"], itemType) {
+			return errNotInList
+		}
+		return nil
+	})
+}
+
+// updateListEntries modifie manuellement les éléments d'une liste et écrit la
+// réponse. Les listes automatiques ne peuvent pas être modifiées ainsi.
+func (s *Server) updateListEntries(w http.ResponseWriter, id string, fn func(list *models.List) error) {
+	list, ok := s.updateList(w, id, func(list *models.List) error {
+		if list.IsSmart() {
+			return errSmartList
+		}
+		return fn(list)
+	})
+	if ok {
+		writeListResponse(w, list)
+	}
+}
+
+// updateList applique fn à une liste dans une transaction et renvoie la liste
+// modifiée. En cas d'échec, la réponse d'erreur est écrite et ok vaut false.
+func (s *Server) updateList(w http.ResponseWriter, id string, fn func(list *models.List) error) (models.List, bool) {
+	var updated models.List
+
+	err := s.ListsStorage.Update(func(tx *storage.ListsTx) error {
+		list, ok := tx.Get(id)
+		if !ok {
+			return storage.ErrListNotFound
+		}
+		if err := fn(list); err != nil {
+			return err
+		}
+		updated = list.Clone()
+		return nil
+	})
+	switch err {
+	case nil:
+		return updated, true
+	case storage.ErrListNotFound:
+		writeJSONError(w, http.StatusNotFound, "Liste introuvable")
+	case errNotInList:
+		writeJSONError(w, http.StatusNotFound, "Élément absent de la liste")
+	case errSmartList:
+		writeJSONError(w, http.StatusConflict, "Les éléments d'une liste automatique sont calculés par sa règle")
+	default:
+		writeJSONError(w, http.StatusInternalServerError, "Échec lors de la modification de la liste: "+err.Error())
+		log.Printf("Erreur lors de la modification de la liste %s: %v", id, err)
+	}
+	return models.List{}, false
+}
+
+// writeListResponse écrit une liste modifiée avec succès
+func writeListResponse(w http.ResponseWriter, list models.List) {
+	writeJSON(w, http.StatusOK, listResponse{Success: true, List: list})
+}
+
+// findFavorite renvoie le favori correspondant s'il existe
+func (s *Server) findFavorite(id string, itemType models.FavoriteType) (models.FavoriteItem, bool) {
+	for _, item := range s.FavoritesStorage.GetByType(itemType) {
+		if item.ID == id {
+			return item, true
+		}
+	}
+	return models.FavoriteItem{}, false
+}
+
+// listItems renvoie les éléments d'une liste dans leur ordre, enrichis des
+// métadonnées et annotations des favoris correspondants
+func (s *Server) listItems(list models.List) []models.FavoriteItem {
+	favorites := make(map[models.FavoriteKey]models.FavoriteItem)
+	for _, item := range s.FavoritesStorage.GetAll() {
+		favorites[item.Key()] = item
+	}
+
+	items := make([]models.FavoriteItem, 0, list.Len())
+	for _, entry := range list.Entries {
+		if item, ok := favorites[entry.Key()]; ok {
+			items = append(items, item)
+		} else {
+			items = append(items, entry.FavoriteItem())
+		}
+	}
+	return items
+}
+
+// ListsHandler gère la page des listes personnalisées
+func (s *Server) ListsHandler(w http.ResponseWriter, r *http.Request) {
+	// Vérifier si l'utilisateur est connecté
+	if !s.SpotifyAuth.IsTokenValid() {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	data := PageData{
+		Title:       "Mes Listes - MelodyExplorer",
+		IsLoggedIn:  true,
+		CurrentPage: "lists",
+		Data: map[string]interface{}{
+			"Lists": s.ListsStorage.All(),
+		},
+	}
+
+	s.renderTemplate(w, r, "lists.html", data)
+}
+
+// ListHandler gère la page d'une liste personnalisée
+func (s *Server) ListHandler(w http.ResponseWriter, r *http.Request) {
+	// Vérifier si l'utilisateur est connecté
+	if !s.SpotifyAuth.IsTokenValid() {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	list, ok := s.ListsStorage.Get(mux.Vars(r)["id"])
+	if !ok {
+		s.ErrorHandler(w, r)
+		return
+	}
+
+	data := PageData{
+		Title:       list.Name + " - MelodyExplorer",
+		IsLoggedIn:  true,
+		CurrentPage: "lists",
+		Data: map[string]interface{}{
+			"List":  list,
+			"Items": s.listItems(list),
+		},
+	}
+
+	s.renderTemplate(w, r, "list.html", data)
+}
@@ -1,72 +1,231 @@
 package api
 
 import (
+	"fmt"
 	"log"
 	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/yourusername/melody-explorer/internal/config"
 )
 
+// Middlewares disponibles pour la chaîne configurée par MIDDLEWARES (voir
+// buildHandler). Ils enveloppent le routeur entier : ils s'appliquent aussi
+// aux pages introuvables et aux requêtes préliminaires CORS, avant les
+// middlewares d'authentification enregistrés sur le routeur.
+var middlewares = map[string]func(s *Server, cfg *config.Config) func(http.Handler) http.Handler{
+	"recover": func(s *Server, cfg *config.Config) func(http.Handler) http.Handler {
+		return s.RecoverMiddleware
+	},
+	"logging": func(s *Server, cfg *config.Config) func(http.Handler) http.Handler {
+		return LoggingMiddleware
+	},
+	"cors": func(s *Server, cfg *config.Config) func(http.Handler) http.Handler {
+		return CORSMiddleware(cfg.CORSAllowedOrigins, cfg.CORSMaxAge)
+	},
+	"cache": func(s *Server, cfg *config.Config) func(http.Handler) http.Handler {
+		return CacheControlMiddleware(cfg.StaticCacheMaxAge)
+	},
+}
+
+// buildHandler enveloppe le routeur dans les middlewares nommés par la
+// configuration ; le premier nommé est le plus extérieur
+func (s *Server) buildHandler(cfg *config.Config) (http.Handler, error) {
+	var handler http.Handler = s.Router
+	for i := len(cfg.Middlewares) - 1; i >= 0; i-- {
+		name := cfg.Middlewares[i]
+		build, ok := middlewares[name]
+		if !ok {
+			return nil, fmt.Errorf("middleware inconnu : %q", name)
+		}
+		handler = build(s, cfg)(handler)
+	}
+	return handler, nil
+}
+
+// responseRecorder mémorise le code et la taille de la réponse
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	size   int64
+}
+
+func (w *responseRecorder) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.size += int64(n)
+	return n, err
+}
+
+// Flush transmet les données en attente (flux d'événements)
+func (w *responseRecorder) Flush() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap donne accès à l'écrivain d'origine (http.ResponseController)
+func (w *responseRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 // LoggingMiddleware enregistre des informations sur chaque requête
 func LoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
+		recorder := &responseRecorder{ResponseWriter: w}
 
 		// Appeler le gestionnaire suivant
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(recorder, r)
 
 		// Journaliser la requête
+		status := recorder.status
+		if status == 0 {
+			status = http.StatusOK
+		}
 		log.Printf(
-			"%s %s %s %s",
+			"%s %s %s %d %d %s",
 			r.RemoteAddr,
 			r.Method,
 			r.URL.Path,
+			status,
+			recorder.size,
 			time.Since(start),
 		)
 	})
 }
 
-// RecoverMiddleware récupère les paniques et journalise l'erreur
-func RecoverMiddleware(next http.Handler) http.Handler {
+// RecoverMiddleware récupère les paniques, journalise l'erreur et sa pile,
+// puis affiche la page d'erreur (une erreur JSON pour l'API) si la réponse
+// n'a pas encore commencé
+func (s *Server) RecoverMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := &responseRecorder{ResponseWriter: w}
 		defer func() {
-			if err := recover(); err != nil {
-				log.Printf("Panique: %v", err)
-				http.Error(w, "Erreur Interne du Serveur", http.StatusInternalServerError)
+			err := recover()
+			if err == nil {
+				return
+			}
+			if err == http.ErrAbortHandler {
+				// Interruption volontaire de la réponse : laisser net/http la gérer
+				panic(err)
 			}
+
+			log.Printf("Panique: %s %s: %v\n%s", r.Method, r.URL.Path, err, debug.Stack())
+			if recorder.status != 0 {
+				// La réponse a commencé : impossible d'afficher la page d'erreur
+				return
+			}
+			s.renderError(w, r, http.StatusInternalServerError, "Une erreur interne est survenue. Veuillez réessayer plus tard.")
 		}()
 
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(recorder, r)
 	})
 }
 
-// CORSMiddleware ajoute des en-têtes CORS aux réponses
-func CORSMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Définir les en-têtes CORS
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, Authorization")
-
-		// Gérer les requêtes préliminaires
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
+// renderError répond par une erreur au format attendu par la requête : une
+// erreur JSON pour l'API, la page d'erreur sinon
+func (s *Server) renderError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	if strings.HasPrefix(r.URL.Path, "/api/") || r.URL.Path == "/rpc" || wantsJSON(r) {
+		writeJSONError(w, status, message)
+		return
+	}
 
-		// Appeler le gestionnaire suivant
-		next.ServeHTTP(w, r)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	s.renderTemplate(w, r, "error.html", PageData{
+		Title:       "Erreur - MelodyExplorer",
+		IsLoggedIn:  s.SpotifyAuth.IsTokenValid(),
+		CurrentPage: "error",
+		Error:       message,
 	})
 }
 
-// CacheControlMiddleware ajoute des en-têtes de contrôle de cache pour les ressources statiques
-func CacheControlMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Ajouter des en-têtes de contrôle de cache pour les ressources statiques
-		if r.URL.Path[:8] == "/static/" {
-			w.Header().Set("Cache-Control", "public, max-age=86400") // 1 jour
-		}
+// CORSMiddleware autorise les requêtes des origines listées (par exemple
+// https://outil.example.com) ; les autres ne reçoivent pas d'en-têtes CORS,
+// et leurs requêtes préliminaires sont refusées. Sans origine listée, le
+// middleware n'autorise aucune requête d'une autre origine.
+func CORSMiddleware(allowed []string, maxAge time.Duration) func(http.Handler) http.Handler {
+	origins := make(map[string]bool, len(allowed))
+	for _, origin := range allowed {
+		origins[normalizeOrigin(origin)] = true
+	}
 
-		// Appeler le gestionnaire suivant
-		next.ServeHTTP(w, r)
-	})
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// La réponse dépend de l'origine : ne pas la partager entre origines en cache
+			w.Header().Add("Vary", "Origin")
+			if !origins[normalizeOrigin(origin)] {
+				if preflight {
+					http.Error(w, "Origine non autorisée", http.StatusForbidden)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Définir les en-têtes CORS
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			if !preflight {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Gérer les requêtes préliminaires
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PATCH, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Authorization, Last-Event-ID, X-Admin-Token")
+			if maxAge > 0 {
+				w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(maxAge.Seconds())))
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
+
+// normalizeOrigin met une origine sous la forme envoyée par les navigateurs
+func normalizeOrigin(origin string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(origin), "/"))
+}
+
+// CacheControlMiddleware ajoute des en-têtes de contrôle de cache pour les
+// ressources statiques, conservées maxAge par les navigateurs
+func CacheControlMiddleware(maxAge time.Duration) func(http.Handler) http.Handler {
+	value := "public, max-age=" + strconv.Itoa(int(maxAge.Seconds()))
+	if maxAge <= 0 {
+		value = "no-cache"
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Ajouter des en-têtes de contrôle de cache pour les ressources statiques
+			if strings.HasPrefix(r.URL.Path, "/static/") {
+				w.Header().Set("Cache-Control", value)
+			}
+
+			// Appeler le gestionnaire suivant
+			next.ServeHTTP(w, r)
+		})
+	}
 }
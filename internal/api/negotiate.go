@@ -0,0 +1,87 @@
+package api
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Négociation de contenu des pages : les pages du catalogue et des favoris
+// renvoient les données de leur template en JSON lorsque le client le
+// demande, ce qui évite de maintenir des gestionnaires en double.
+
+// presentationKeys liste, par template, les clés de PageData.Data qui ne
+// servent qu'à l'affichage et sont omises de la version JSON
+var presentationKeys = map[string][]string{
+	// Sections regroupe Favorites par type pour l'affichage ; Lists alimente
+	// le menu d'ajout à une liste
+	"favorites.html": {"Sections", "Lists", "Filtered"},
+}
+
+// wantsJSON indique si la requête demande une réponse JSON : le paramètre
+// format=json l'emporte sur l'en-tête Accept, et format=html force le HTML
+func wantsJSON(r *http.Request) bool {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return format == "json"
+	}
+	return acceptsJSON(r.Header.Get("Accept"))
+}
+
+// acceptsJSON indique si l'en-tête Accept préfère application/json à
+// text/html. Les jokers (*/*) ne suffisent pas : les navigateurs et fetch les
+// envoient par défaut.
+func acceptsJSON(accept string) bool {
+	jsonQ, htmlQ := 0.0, 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		switch mediaType {
+		case "application/json":
+			jsonQ = max(jsonQ, q)
+		case "text/html":
+			htmlQ = max(htmlQ, q)
+		}
+	}
+	return jsonQ > 0 && jsonQ >= htmlQ
+}
+
+// renderPage rend une page négociée : le template, ou ses données en JSON
+// sans les champs de présentation
+func (s *Server) renderPage(w http.ResponseWriter, r *http.Request, name string, data PageData) {
+	w.Header().Add("Vary", "Accept")
+	if !wantsJSON(r) {
+		s.renderTemplate(w, r, name, data)
+		return
+	}
+
+	if values, ok := data.Data.(map[string]interface{}); ok && len(presentationKeys[name]) > 0 {
+		filtered := make(map[string]interface{}, len(values))
+		for key, value := range values {
+			filtered[key] = value
+		}
+		for _, key := range presentationKeys[name] {
+			delete(filtered, key)
+		}
+		data.Data = filtered
+	}
+	writeJSON(w, http.StatusOK, data)
+}
+
+// pageUnauthorized répond à une page négociée demandée sans connexion :
+// erreur 401 pour les clients JSON, redirection vers la connexion sinon
+func pageUnauthorized(w http.ResponseWriter, r *http.Request) {
+	if wantsJSON(r) {
+		writeJSONError(w, http.StatusUnauthorized, "Non autorisé")
+		return
+	}
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
@@ -0,0 +1,743 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+
+	"github.com/yourusername/melody-explorer/internal/jsonrpc"
+	"github.com/yourusername/melody-explorer/internal/models"
+	"github.com/yourusername/melody-explorer/internal/openapi"
+	"github.com/yourusername/melody-explorer/internal/spotify"
+)
+
+// Description OpenAPI de l'API JSON. Le document est généré à partir des
+// routes enregistrées dans initializeRoutes : chaque route nommée qui
+// figure dans apiOperations y apparaît avec son chemin, ses méthodes et ses
+// paramètres de chemin ; les schémas des corps de requête et de réponse sont
+// déduits des types Go. Les réponses d'erreur de l'authentification par
+// jeton (voir tokens.go) et de la protection CSRF sont ajoutées à chaque route.
+
+// apiOperation documente une route de l'API, associée par son nom
+type apiOperation struct {
+	Summary string
+	Tag     string
+	// PathParams décrit les paramètres de chemin propres à l'opération ;
+	// les autres sont décrits par apiPathParams
+	PathParams map[string]string
+	// Query liste les paramètres de requête propres à l'opération
+	Query []openapi.Parameter
+	// Body est une valeur dont le type décrit le corps de la requête
+	Body interface{}
+	// Data est une valeur dont le type décrit le champ data de la réponse
+	// des routes de /api/v1
+	Data interface{}
+	// Response est une valeur dont le type décrit la réponse entière des
+	// routes hors de /api/v1, qui n'est pas enveloppée dans data et meta
+	Response interface{}
+	// Status est le code HTTP de la réponse réussie (200 par défaut)
+	Status int
+	// Paginated ajoute les paramètres limit et page
+	Paginated bool
+	// Errors liste les codes HTTP d'erreur possibles, hors erreurs
+	// d'authentification
+	Errors []int
+	// TextErrors indique que le traitement renvoie ses erreurs en texte brut
+	TextErrors bool
+}
+
+// Tags des opérations
+const (
+	apiTagCatalog   = "Catalogue"
+	apiTagFavorites = "Favoris"
+	apiTagHistory   = "Historique"
+	apiTagLists     = "Listes"
+	apiTagTokens    = "Jetons d'accès"
+	apiTagWebhooks  = "Webhooks"
+	apiTagRPC       = "JSON-RPC"
+)
+
+// Descriptions des paramètres de chemin propres à certaines opérations
+var (
+	listPathParams = map[string]string{
+		"id": "Identifiant de la liste",
+	}
+	listItemPathParams = map[string]string{
+		"id":     "Identifiant de la liste",
+		"type":   "Type de l'élément",
+		"itemID": "Identifiant de l'élément",
+	}
+	tokenPathParams = map[string]string{
+		"id": "Identifiant du jeton",
+	}
+	webhookPathParams = map[string]string{
+		"id":       "Identifiant du webhook",
+		"delivery": "Identifiant de la notification",
+	}
+)
+
+// apiOperations documente les routes de l'API par nom de route
+var apiOperations = map[string]apiOperation{
+	"searchCatalog": {
+		Summary: "Rechercher des artistes, albums et pistes",
+		Tag:     apiTagCatalog,
+		Query: []openapi.Parameter{
+			{Name: "q", In: "query", Required: true, Description: "Texte recherché", Schema: &openapi.Schema{Type: "string"}},
+			{Name: "type", In: "query", Description: "Types recherchés, répétable ou séparés par des virgules (artist, album, track)", Schema: &openapi.Schema{Type: "string"}},
+		},
+		Data:      apiSearchData{},
+		Paginated: true,
+		Errors:    []int{http.StatusBadRequest, http.StatusBadGateway},
+	},
+	"getArtist": {
+		Summary: "Obtenir un artiste",
+		Tag:     apiTagCatalog,
+		Data:    spotify.Artist{},
+		Errors:  []int{http.StatusNotFound, http.StatusBadGateway},
+	},
+	"listArtistAlbums": {
+		Summary:   "Lister les albums d'un artiste",
+		Tag:       apiTagCatalog,
+		Data:      []spotify.Album{},
+		Paginated: true,
+		Errors:    []int{http.StatusBadRequest, http.StatusNotFound, http.StatusBadGateway},
+	},
+	"getAlbum": {
+		Summary: "Obtenir un album",
+		Tag:     apiTagCatalog,
+		Data:    spotify.Album{},
+		Errors:  []int{http.StatusNotFound, http.StatusBadGateway},
+	},
+	"listAlbumTracks": {
+		Summary:   "Lister les pistes d'un album",
+		Tag:       apiTagCatalog,
+		Data:      []spotify.Track{},
+		Paginated: true,
+		Errors:    []int{http.StatusBadRequest, http.StatusNotFound, http.StatusBadGateway},
+	},
+	"getTrack": {
+		Summary: "Obtenir une piste",
+		Tag:     apiTagCatalog,
+		Data:    spotify.Track{},
+		Errors:  []int{http.StatusNotFound, http.StatusBadGateway},
+	},
+	"listCategoryTracks": {
+		Summary:   "Lister les pistes d'une catégorie",
+		Tag:       apiTagCatalog,
+		Data:      []spotify.Track{},
+		Paginated: true,
+		Errors:    []int{http.StatusBadRequest, http.StatusBadGateway},
+	},
+	"listFavorites": {
+		Summary: "Lister les favoris",
+		Tag:     apiTagFavorites,
+		Query: []openapi.Parameter{
+			{Name: "type", In: "query", Description: "Type de favori", Schema: favoriteTypeSchema()},
+			{Name: "genre", In: "query", Description: "Genre de l'artiste", Schema: &openapi.Schema{Type: "string"}},
+			{Name: "year", In: "query", Description: "Année de sortie", Schema: &openapi.Schema{Type: "string"}},
+			{Name: "artist", In: "query", Description: "Nom d'artiste", Schema: &openapi.Schema{Type: "string"}},
+			{Name: "tag", In: "query", Description: "Tag", Schema: &openapi.Schema{Type: "string"}},
+		},
+		Data:      []models.FavoriteItem{},
+		Paginated: true,
+		Errors:    []int{http.StatusBadRequest},
+	},
+
+	// Favoris
+	"addFavorite": {
+		Summary:    "Ajouter un favori, avec un instantané de ses métadonnées",
+		Tag:        apiTagFavorites,
+		Body:       favoriteRequest{},
+		Response:   successResponse{},
+		Errors:     []int{http.StatusBadRequest, http.StatusInternalServerError},
+		TextErrors: true,
+	},
+	"removeFavorite": {
+		Summary:    "Supprimer un favori",
+		Tag:        apiTagFavorites,
+		Body:       favoriteKeyRequest{},
+		Response:   successResponse{},
+		Errors:     []int{http.StatusBadRequest, http.StatusInternalServerError},
+		TextErrors: true,
+	},
+	"batchFavorites": {
+		Summary:  "Appliquer un lot d'opérations add, remove, tag et move (non versionnée) en une seule transaction",
+		Tag:      apiTagFavorites,
+		Body:     batchRequest{},
+		Response: batchResponse{},
+		Errors:   []int{http.StatusBadRequest, http.StatusInternalServerError},
+	},
+	"syncFavorites": {
+		Summary:  "Synchroniser les favoris d'un appareil hors ligne",
+		Tag:      apiTagFavorites,
+		Body:     syncRequest{},
+		Response: syncResponse{},
+		Errors:   []int{http.StatusBadRequest, http.StatusInternalServerError},
+	},
+
+	// Historique des favoris
+	"getFavoritesHistory": {
+		Summary: "Consulter l'historique des favoris, les entrées les plus récentes d'abord",
+		Tag:     apiTagHistory,
+		Query: []openapi.Parameter{
+			{Name: "type", In: "query", Description: "Type de favori", Schema: favoriteTypeSchema()},
+			{Name: "id", In: "query", Description: "Identifiant du favori", Schema: &openapi.Schema{Type: "string"}},
+			{Name: "before", In: "query", Description: "Numéro d'entrée avant lequel reprendre la lecture (next_before)", Schema: &openapi.Schema{Type: "integer", Format: "int64"}},
+			{Name: "limit", In: "query", Description: "Nombre d'entrées", Schema: &openapi.Schema{Type: "integer", Minimum: openapi.Int(1), Maximum: openapi.Int(maxHistoryLimit)}},
+		},
+		Response: historyResponse{},
+		Errors:   []int{http.StatusBadRequest},
+	},
+	"undoFavorites": {
+		Summary:  "Annuler la dernière opération sur les favoris",
+		Tag:      apiTagHistory,
+		Response: historyChangesResponse{},
+		Errors:   []int{http.StatusConflict, http.StatusInternalServerError},
+	},
+	"restoreFavorites": {
+		Summary:  "Ramener les favoris à leur état à une date passée",
+		Tag:      apiTagHistory,
+		Body:     restoreRequest{},
+		Response: historyChangesResponse{},
+		Errors:   []int{http.StatusBadRequest, http.StatusInternalServerError},
+	},
+
+	// Listes personnalisées
+	"listLists": {
+		Summary:  "Lister les listes, sans leurs éléments",
+		Tag:      apiTagLists,
+		Response: listsResponse{},
+	},
+	"createList": {
+		Summary:  "Créer une liste, automatique si une règle est fournie",
+		Tag:      apiTagLists,
+		Body:     listRequest{},
+		Response: listResponse{},
+		Status:   http.StatusCreated,
+		Errors:   []int{http.StatusBadRequest, http.StatusInternalServerError},
+	},
+	"getList": {
+		Summary:    "Obtenir une liste avec ses éléments",
+		Tag:        apiTagLists,
+		PathParams: listPathParams,
+		Response:   listResponse{},
+		Errors:     []int{http.StatusNotFound},
+	},
+	"updateList": {
+		Summary:    "Modifier une liste ; seuls les champs présents sont modifiés",
+		Tag:        apiTagLists,
+		PathParams: listPathParams,
+		Body:       listRequest{},
+		Response:   listResponse{},
+		Errors:     []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+	},
+	"deleteList": {
+		Summary:    "Supprimer une liste",
+		Tag:        apiTagLists,
+		PathParams: listPathParams,
+		Response:   successResponse{},
+		Errors:     []int{http.StatusNotFound, http.StatusInternalServerError},
+	},
+	"refreshList": {
+		Summary:    "Réévaluer une liste automatique",
+		Tag:        apiTagLists,
+		PathParams: listPathParams,
+		Response:   listResponse{},
+		Errors:     []int{http.StatusNotFound, http.StatusConflict, http.StatusBadGateway},
+	},
+	"addListItem": {
+		Summary:    "Ajouter un élément à une liste, ou le déplacer s'il y est déjà",
+		Tag:        apiTagLists,
+		PathParams: listPathParams,
+		Body:       listItemRequest{},
+		Response:   listResponse{},
+		Errors:     []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError},
+	},
+	"moveListItem": {
+		Summary:    "Déplacer un élément d'une liste",
+		Tag:        apiTagLists,
+		PathParams: listItemPathParams,
+		Body:       listItemMoveRequest{},
+		Response:   listResponse{},
+		Errors:     []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError},
+	},
+	"removeListItem": {
+		Summary:    "Retirer un élément d'une liste",
+		Tag:        apiTagLists,
+		PathParams: listItemPathParams,
+		Response:   listResponse{},
+		Errors:     []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError},
+	},
+
+	// Jetons d'accès personnels
+	"listTokens": {
+		Summary:  "Lister les jetons d'accès de l'utilisateur connecté, sans leur secret",
+		Tag:      apiTagTokens,
+		Response: tokensResponse{},
+		Errors:   []int{http.StatusBadGateway},
+	},
+	"createToken": {
+		Summary:  "Créer un jeton d'accès ; le secret n'est renvoyé qu'une fois",
+		Tag:      apiTagTokens,
+		Body:     tokenRequest{},
+		Response: tokenResponse{},
+		Status:   http.StatusCreated,
+		Errors:   []int{http.StatusBadRequest, http.StatusInternalServerError, http.StatusBadGateway},
+	},
+	"revokeToken": {
+		Summary:    "Révoquer un jeton d'accès de l'utilisateur connecté",
+		Tag:        apiTagTokens,
+		PathParams: tokenPathParams,
+		Response:   tokenResponse{},
+		Errors:     []int{http.StatusNotFound, http.StatusInternalServerError, http.StatusBadGateway},
+	},
+
+	// Webhooks
+	"listWebhooks": {
+		Summary:  "Lister les webhooks et les événements disponibles",
+		Tag:      apiTagWebhooks,
+		Response: webhooksResponse{},
+	},
+	"createWebhook": {
+		Summary:  "Créer un webhook ; le secret de signature n'est renvoyé qu'une fois",
+		Tag:      apiTagWebhooks,
+		Body:     webhookRequest{},
+		Response: webhookResponse{},
+		Status:   http.StatusCreated,
+		Errors:   []int{http.StatusBadRequest, http.StatusInternalServerError},
+	},
+	"updateWebhook": {
+		Summary:    "Modifier un webhook ; seuls les champs présents sont modifiés",
+		Tag:        apiTagWebhooks,
+		PathParams: webhookPathParams,
+		Body:       webhookRequest{},
+		Response:   webhookResponse{},
+		Errors:     []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+	},
+	"deleteWebhook": {
+		Summary:    "Supprimer un webhook et ses notifications",
+		Tag:        apiTagWebhooks,
+		PathParams: webhookPathParams,
+		Response:   successResponse{},
+		Errors:     []int{http.StatusNotFound, http.StatusInternalServerError},
+	},
+	"pingWebhook": {
+		Summary:    "Envoyer un événement ping à un webhook",
+		Tag:        apiTagWebhooks,
+		PathParams: webhookPathParams,
+		Response:   deliveryResponse{},
+		Status:     http.StatusAccepted,
+		Errors:     []int{http.StatusNotFound, http.StatusInternalServerError},
+	},
+	"listWebhookDeliveries": {
+		Summary:    "Consulter le journal des notifications d'un webhook",
+		Tag:        apiTagWebhooks,
+		PathParams: webhookPathParams,
+		Response:   deliveriesResponse{},
+		Errors:     []int{http.StatusNotFound},
+	},
+	"redeliverWebhook": {
+		Summary:    "Renvoyer une notification",
+		Tag:        apiTagWebhooks,
+		PathParams: webhookPathParams,
+		Response:   deliveryResponse{},
+		Status:     http.StatusAccepted,
+		Errors:     []int{http.StatusNotFound, http.StatusInternalServerError},
+	},
+
+	// JSON-RPC
+	"rpc": {
+		Summary:  "Appeler une méthode JSON-RPC 2.0 (catalog.search, catalog.getArtist, favorites.list, favorites.add, lists.create) ; un lot est un tableau d'appels",
+		Tag:      apiTagRPC,
+		Body:     jsonrpc.Request{},
+		Response: jsonrpc.Response{},
+	},
+}
+
+// apiPathParams décrit les paramètres de chemin des routes de l'API
+var apiPathParams = map[string]string{
+	"id":    "Identifiant Spotify",
+	"genre": "Genre musical",
+}
+
+// apiErrorDescriptions décrit les réponses d'erreur par code HTTP
+var apiErrorDescriptions = map[int]string{
+	http.StatusBadRequest:          "Paramètres ou corps de requête invalides",
+	http.StatusNotFound:            "Élément introuvable",
+	http.StatusConflict:            "Opération impossible dans l'état actuel",
+	http.StatusInternalServerError: "Erreur lors de l'enregistrement",
+	http.StatusBadGateway:          "Erreur de Spotify",
+}
+
+// apiErrorCodes sont les codes symboliques des erreurs de /api/v1
+var apiErrorCodes = map[int]string{
+	http.StatusBadRequest:   apiErrBadRequest,
+	http.StatusUnauthorized: apiErrUnauthorized,
+	http.StatusForbidden:    apiErrForbidden,
+	http.StatusNotFound:     apiErrNotFound,
+	http.StatusBadGateway:   apiErrUpstream,
+}
+
+// pathVariable reconnaît une variable de chemin gorilla/mux, avec son
+// expression régulière éventuelle
+var pathVariable = regexp.MustCompile(`\{([^}:]+)(?::[^}]*)?\}`)
+
+// favoriteTypeSchema énumère les types de favoris connus
+func favoriteTypeSchema() *openapi.Schema {
+	schema := &openapi.Schema{Type: "string"}
+	for _, info := range models.FavoriteTypes() {
+		schema.Enum = append(schema.Enum, string(info.Type))
+	}
+	return schema
+}
+
+// apiSchemaName nomme les composants : les types du paquet api perdent leur
+// préfixe (apiPagination devient Pagination) et ceux du paquet jsonrpc
+// prennent le préfixe RPC (Request devient RPCRequest)
+func apiSchemaName(t reflect.Type) string {
+	name := t.Name()
+	switch t.PkgPath() {
+	case reflect.TypeOf(apiMeta{}).PkgPath():
+		name = strings.TrimPrefix(name, "api")
+	case reflect.TypeOf(jsonrpc.Request{}).PkgPath():
+		name = "RPC" + name
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// tokenChallengeHeader décrit l'en-tête WWW-Authenticate des erreurs de
+// jeton d'accès
+func tokenChallengeHeader() *openapi.Header {
+	return &openapi.Header{
+		Description: `Bearer error="invalid_token" ou Bearer error="insufficient_scope", pour les requêtes authentifiées par jeton`,
+		Schema:      &openapi.Schema{Type: "string"},
+	}
+}
+
+// lowerFirst met en minuscule la première lettre d'une description
+func lowerFirst(text string) string {
+	r, size := utf8.DecodeRuneInString(text)
+	return string(unicode.ToLower(r)) + text[size:]
+}
+
+// paginationParams sont les paramètres des listes paginées
+func paginationParams() []openapi.Parameter {
+	return []openapi.Parameter{
+		{Name: "limit", In: "query", Description: "Nombre d'éléments par page", Schema: &openapi.Schema{Type: "integer", Minimum: openapi.Int(1), Maximum: openapi.Int(maxAPILimit)}},
+		{Name: "page", In: "query", Description: "Numéro de page, à partir de 1", Schema: &openapi.Schema{Type: "integer", Minimum: openapi.Int(1)}},
+	}
+}
+
+// openAPIDocument construit le document OpenAPI à partir des routes
+// enregistrées sur le routeur
+func (s *Server) openAPIDocument() (*openapi.Document, error) {
+	gen := openapi.NewGenerator()
+	gen.TypeName = apiSchemaName
+	apiErrorSchema := gen.SchemaOf(apiErrorResponse{})
+	jsonErrorSchema := gen.SchemaOf(failureResponse{})
+
+	doc := &openapi.Document{
+		OpenAPI: openapi.Version,
+		Info: openapi.Info{
+			Title:       "MelodyExplorer API",
+			Description: "API JSON du catalogue Spotify et des favoris de MelodyExplorer",
+			Version:     "1",
+		},
+		Servers: []openapi.Server{{URL: "/"}},
+		Paths:   make(map[string]*openapi.PathItem),
+	}
+
+	err := s.Router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		spec, ok := apiOperations[route.GetName()]
+		if !ok {
+			return nil
+		}
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return err
+		}
+
+		op := &openapi.Operation{
+			OperationID: route.GetName(),
+			Summary:     spec.Summary,
+			Responses:   make(map[string]*openapi.Response),
+		}
+		if spec.Tag != "" {
+			op.Tags = []string{spec.Tag}
+		}
+
+		// Les paramètres de chemin sont lus dans le modèle de la route
+		for _, match := range pathVariable.FindAllStringSubmatch(template, -1) {
+			description, ok := spec.PathParams[match[1]]
+			if !ok {
+				description = apiPathParams[match[1]]
+			}
+			op.Parameters = append(op.Parameters, openapi.Parameter{
+				Name:        match[1],
+				In:          "path",
+				Required:    true,
+				Description: description,
+				Schema:      &openapi.Schema{Type: "string"},
+			})
+		}
+		op.Parameters = append(op.Parameters, spec.Query...)
+		if spec.Paginated {
+			op.Parameters = append(op.Parameters, paginationParams()...)
+		}
+		if spec.Body != nil {
+			op.RequestBody = &openapi.RequestBody{Required: true, Content: openapi.JSON(gen.SchemaOf(spec.Body))}
+		}
+
+		// Les routes de /api/v1 enveloppent leurs réponses dans data et meta
+		// et renvoient des erreurs avec un code symbolique
+		versioned := strings.HasPrefix(template, "/api/v1/")
+		status := spec.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		success := &openapi.Response{Description: "Réponse réussie"}
+		if versioned {
+			success.Content = openapi.JSON(&openapi.Schema{
+				Type: "object",
+				Properties: map[string]*openapi.Schema{
+					"data": gen.SchemaOf(spec.Data),
+					"meta": gen.SchemaOf(apiMeta{}),
+				},
+				Required: []string{"data"},
+			})
+		} else {
+			success.Content = openapi.JSON(gen.SchemaOf(spec.Response))
+		}
+		op.Responses[strconv.Itoa(status)] = success
+
+		errorResponse := func(status int, description string) *openapi.Response {
+			response := &openapi.Response{Description: description}
+			switch {
+			case versioned:
+				response.Description += " (" + apiErrorCodes[status] + ")"
+				response.Content = openapi.JSON(apiErrorSchema)
+			case spec.TextErrors && status != http.StatusUnauthorized && status != http.StatusForbidden:
+				response.Content = openapi.Text()
+			default:
+				response.Content = openapi.JSON(jsonErrorSchema)
+			}
+			return response
+		}
+		for _, status := range spec.Errors {
+			op.Responses[strconv.Itoa(status)] = errorResponse(status, apiErrorDescriptions[status])
+		}
+
+		// Erreurs d'authentification : session Spotify absente, jeton d'accès
+		// refusé par TokenMiddleware ou jeton CSRF refusé par CSRFMiddleware
+		unauthorized := errorResponse(http.StatusUnauthorized, "Utilisateur non connecté à Spotify")
+		forbidden := errorResponse(http.StatusForbidden, "Route inaccessible avec un jeton d'accès")
+		forbidden.Headers = map[string]*openapi.Header{"WWW-Authenticate": tokenChallengeHeader()}
+		if scope, ok := tokenRequiredScope(&http.Request{Method: methods[0], URL: &url.URL{Path: template}}); ok {
+			op.Description = "Accessible avec un jeton d'accès de portée " + string(scope) + "."
+			unauthorized = errorResponse(http.StatusUnauthorized, "Utilisateur non connecté à Spotify, ou jeton d'accès invalide, expiré, révoqué ou appartenant à un autre compte")
+			unauthorized.Headers = map[string]*openapi.Header{"WWW-Authenticate": tokenChallengeHeader()}
+			forbidden = errorResponse(http.StatusForbidden, "Portée du jeton d'accès insuffisante")
+			forbidden.Headers = map[string]*openapi.Header{"WWW-Authenticate": tokenChallengeHeader()}
+		} else {
+			op.Description = "Réservée à la session du navigateur : inaccessible avec un jeton d'accès."
+		}
+		if !isSafeMethod(methods[0]) {
+			op.Description += " Sans jeton d'accès, la requête doit porter l'en-tête " + csrfHeaderName + "."
+			forbidden.Description = "Jeton CSRF manquant ou invalide, ou " + lowerFirst(forbidden.Description)
+		}
+		op.Responses[strconv.Itoa(http.StatusUnauthorized)] = unauthorized
+		op.Responses[strconv.Itoa(http.StatusForbidden)] = forbidden
+
+		path := pathVariable.ReplaceAllString(template, "{$1}")
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = &openapi.PathItem{}
+			doc.Paths[path] = item
+		}
+		for _, method := range methods {
+			item.SetOperation(method, op)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	doc.Components.Schemas = gen.Schemas()
+	// Un jeton d'accès personnel, ou aucun lorsque l'application est
+	// connectée à Spotify depuis le navigateur
+	doc.Components.SecuritySchemes = map[string]*openapi.SecurityScheme{
+		"bearerToken": {Type: "http", Scheme: "bearer", Description: "Jeton d'accès personnel créé sur la page /account"},
+	}
+	doc.Security = []map[string][]string{{"bearerToken": {}}, {}}
+	return doc, nil
+}
+
+// OpenAPIHandler gère GET /api/openapi.json
+func (s *Server) OpenAPIHandler(w http.ResponseWriter, r *http.Request) {
+	doc, err := s.openAPIDocument()
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Erreur lors de la génération du document OpenAPI: "+err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, doc)
+}
+
+// apiDocsOperation est une opération présentée sur la page de documentation
+type apiDocsOperation struct {
+	ID          string
+	Method      string
+	Path        string
+	Summary     string
+	Description string
+	Parameters  []apiDocsField
+	Responses   []apiDocsField
+}
+
+// apiDocsSchema est un schéma présenté sur la page de documentation
+type apiDocsSchema struct {
+	Name   string
+	Fields []apiDocsField
+}
+
+// apiDocsField est une ligne des tableaux de la page de documentation
+type apiDocsField struct {
+	Name        string
+	In          string
+	Type        string
+	Required    bool
+	Description string
+}
+
+// APIDocsHandler affiche la documentation de l'API générée à partir du
+// document OpenAPI
+func (s *Server) APIDocsHandler(w http.ResponseWriter, r *http.Request) {
+	doc, err := s.openAPIDocument()
+	if err != nil {
+		http.Error(w, "Erreur lors de la génération de la documentation: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Opérations regroupées par tag, triées par chemin puis par méthode
+	sections := make(map[string][]apiDocsOperation)
+	for path, item := range doc.Paths {
+		for method, op := range item.Operations() {
+			entry := apiDocsOperation{ID: op.OperationID, Method: method, Path: path, Summary: op.Summary, Description: op.Description}
+			for _, param := range op.Parameters {
+				entry.Parameters = append(entry.Parameters, apiDocsField{
+					Name:        param.Name,
+					In:          param.In,
+					Type:        schemaLabel(param.Schema),
+					Required:    param.Required,
+					Description: param.Description,
+				})
+			}
+			if op.RequestBody != nil {
+				if media, ok := op.RequestBody.Content["application/json"]; ok {
+					entry.Parameters = append(entry.Parameters, apiDocsField{
+						Name:        "corps",
+						In:          "body",
+						Type:        schemaLabel(media.Schema),
+						Required:    op.RequestBody.Required,
+						Description: "Corps JSON de la requête",
+					})
+				}
+			}
+			for status, response := range op.Responses {
+				field := apiDocsField{Name: status, Description: response.Description}
+				if media, ok := response.Content["application/json"]; ok {
+					field.Type = schemaLabel(media.Schema)
+					if data, ok := media.Schema.Properties["data"]; ok {
+						field.Type = "{data: " + schemaLabel(data) + ", meta: Meta}"
+					}
+				} else if _, ok := response.Content["text/plain"]; ok {
+					field.Type = "text"
+				}
+				entry.Responses = append(entry.Responses, field)
+			}
+			sort.Slice(entry.Responses, func(i, j int) bool { return entry.Responses[i].Name < entry.Responses[j].Name })
+
+			tag := ""
+			if len(op.Tags) > 0 {
+				tag = op.Tags[0]
+			}
+			sections[tag] = append(sections[tag], entry)
+		}
+	}
+	for _, ops := range sections {
+		sort.Slice(ops, func(i, j int) bool {
+			if ops[i].Path != ops[j].Path {
+				return ops[i].Path < ops[j].Path
+			}
+			return ops[i].Method < ops[j].Method
+		})
+	}
+
+	schemas := make([]apiDocsSchema, 0, len(doc.Components.Schemas))
+	for name, schema := range doc.Components.Schemas {
+		entry := apiDocsSchema{Name: name}
+		required := make(map[string]bool, len(schema.Required))
+		for _, field := range schema.Required {
+			required[field] = true
+		}
+		for field, fieldSchema := range schema.Properties {
+			entry.Fields = append(entry.Fields, apiDocsField{Name: field, Type: schemaLabel(fieldSchema), Required: required[field]})
+		}
+		sort.Slice(entry.Fields, func(i, j int) bool { return entry.Fields[i].Name < entry.Fields[j].Name })
+		schemas = append(schemas, entry)
+	}
+	sort.Slice(schemas, func(i, j int) bool { return schemas[i].Name < schemas[j].Name })
+
+	data := PageData{
+		Title:       "Documentation de l'API - MelodyExplorer",
+		IsLoggedIn:  s.SpotifyAuth.IsTokenValid(),
+		CurrentPage: "api-docs",
+		Data: map[string]interface{}{
+			"Info":     doc.Info,
+			"Sections": sections,
+			"Schemas":  schemas,
+		},
+	}
+	s.renderTemplate(w, r, "api_docs.html", data)
+}
+
+// schemaLabel résume un schéma en une courte description de type
+func schemaLabel(schema *openapi.Schema) string {
+	if schema == nil {
+		return ""
+	}
+	if name := schema.RefName(); name != "" {
+		return name
+	}
+	switch schema.Type {
+	case "":
+		return "any"
+	case "array":
+		return schemaLabel(schema.Items) + "[]"
+	case "object":
+		if schema.AdditionalProperties != nil {
+			return "map<string, " + schemaLabel(schema.AdditionalProperties) + ">"
+		}
+		return "object"
+	}
+	label := schema.Type
+	if schema.Format != "" {
+		label += " (" + schema.Format + ")"
+	}
+	if len(schema.Enum) > 0 {
+		label += " : " + strings.Join(schema.Enum, ", ")
+	}
+	return label
+}
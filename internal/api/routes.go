@@ -6,7 +6,10 @@ import (
 
 // initializeRoutes configure toutes les routes pour le serveur
 func (s *Server) initializeRoutes() {
-	// Appliquer le middleware d'authentification
+	// Appliquer les middlewares d'authentification : jetons d'accès
+	// personnels, protection CSRF des sessions du navigateur, puis session Spotify
+	s.Router.Use(s.TokenMiddleware)
+	s.Router.Use(s.CSRFMiddleware)
 	s.Router.Use(s.SpotifyAuth.AuthMiddleware)
 
 	// Fichiers statiques
@@ -16,7 +19,7 @@ func (s *Server) initializeRoutes() {
 	// Routes d'authentification
 	s.Router.HandleFunc("/login", s.LoginHandler).Methods("GET")
 	s.Router.HandleFunc("/callback", s.CallbackHandler).Methods("GET")
-	s.Router.HandleFunc("/logout", s.LogoutHandler).Methods("GET")
+	s.Router.HandleFunc("/logout", s.LogoutHandler).Methods("POST")
 
 	// Routes des pages
 	s.Router.HandleFunc("/", s.HomeHandler).Methods("GET")
@@ -26,13 +29,81 @@ func (s *Server) initializeRoutes() {
 	s.Router.HandleFunc("/album/{id}", s.AlbumHandler).Methods("GET")
 	s.Router.HandleFunc("/track/{id}", s.TrackHandler).Methods("GET")
 	s.Router.HandleFunc("/favorites", s.FavoritesHandler).Methods("GET")
+	s.Router.HandleFunc("/favorites/import", s.ImportHandler).Methods("GET")
+	s.Router.HandleFunc("/favorites/history", s.HistoryHandler).Methods("GET")
 	s.Router.HandleFunc("/category/{genre}", s.CategoryHandler).Methods("GET")
 	s.Router.HandleFunc("/about", s.AboutHandler).Methods("GET")
 	s.Router.HandleFunc("/recommandation", s.RecommendationHandler).Methods("GET")
+	s.Router.HandleFunc("/lists", s.ListsHandler).Methods("GET")
+	s.Router.HandleFunc("/lists/{id}", s.ListHandler).Methods("GET")
+	s.Router.HandleFunc("/account", s.AccountHandler).Methods("GET")
 
 	// Routes API
-	s.Router.HandleFunc("/api/favorites/add", s.AddFavoriteHandler).Methods("POST")
-	s.Router.HandleFunc("/api/favorites/remove", s.RemoveFavoriteHandler).Methods("POST")
+	// (les routes nommées sont décrites dans le document OpenAPI, voir openapi.go)
+	s.Router.HandleFunc("/api/favorites/add", s.AddFavoriteHandler).Methods("POST").Name("addFavorite")
+	s.Router.HandleFunc("/api/favorites/remove", s.RemoveFavoriteHandler).Methods("POST").Name("removeFavorite")
+	s.Router.HandleFunc("/api/favorites/batch", s.BatchFavoritesHandler).Methods("POST").Name("batchFavorites")
+	s.Router.HandleFunc("/api/favorites/tags", s.FavoriteTagsHandler).Methods("GET")
+	s.Router.HandleFunc("/api/favorites/history", s.HistoryAPIHandler).Methods("GET").Name("getFavoritesHistory")
+	s.Router.HandleFunc("/api/favorites/undo", s.UndoFavoritesHandler).Methods("POST").Name("undoFavorites")
+	s.Router.HandleFunc("/api/favorites/restore", s.RestoreFavoritesHandler).Methods("POST").Name("restoreFavorites")
+	s.Router.HandleFunc("/api/favorites/export", s.ExportFavoritesHandler).Methods("GET")
+	s.Router.HandleFunc("/api/favorites/import", s.StartImportHandler).Methods("POST")
+	s.Router.HandleFunc("/api/favorites/import/{id}", s.ImportReportHandler).Methods("GET")
+	s.Router.HandleFunc("/api/favorites/import/{id}", s.DiscardImportHandler).Methods("DELETE")
+	s.Router.HandleFunc("/api/favorites/import/{id}/commit", s.CommitImportHandler).Methods("POST")
+	// L'identifiant des labels et des recherches peut contenir des barres obliques
+	s.Router.HandleFunc("/api/favorites/{type}/{id:.+}", s.UpdateFavoriteHandler).Methods("PATCH")
+
+	// Flux des modifications des favoris et des listes (Server-Sent Events)
+	s.Router.HandleFunc("/api/events", s.EventsHandler).Methods("GET")
+	s.Router.HandleFunc("/api/sync", s.SyncHandler).Methods("POST").Name("syncFavorites")
+
+	// Routes API des listes personnalisées
+	s.Router.HandleFunc("/api/lists", s.ListsAPIHandler).Methods("GET").Name("listLists")
+	s.Router.HandleFunc("/api/lists", s.CreateListHandler).Methods("POST").Name("createList")
+	s.Router.HandleFunc("/api/lists/{id}", s.GetListHandler).Methods("GET").Name("getList")
+	s.Router.HandleFunc("/api/lists/{id}", s.UpdateListHandler).Methods("PATCH").Name("updateList")
+	s.Router.HandleFunc("/api/lists/{id}", s.DeleteListHandler).Methods("DELETE").Name("deleteList")
+	s.Router.HandleFunc("/api/lists/{id}/refresh", s.RefreshListHandler).Methods("POST").Name("refreshList")
+	s.Router.HandleFunc("/api/lists/{id}/items", s.AddListItemHandler).Methods("POST").Name("addListItem")
+	s.Router.HandleFunc("/api/lists/{id}/items/{type}/{itemID}", s.UpdateListItemHandler).Methods("PATCH").Name("moveListItem")
+	s.Router.HandleFunc("/api/lists/{id}/items/{type}/{itemID}", s.RemoveListItemHandler).Methods("DELETE").Name("removeListItem")
+
+	// Routes API des jetons d'accès personnels (session uniquement)
+	s.Router.HandleFunc("/api/tokens", s.ListTokensHandler).Methods("GET").Name("listTokens")
+	s.Router.HandleFunc("/api/tokens", s.CreateTokenHandler).Methods("POST").Name("createToken")
+	s.Router.HandleFunc("/api/tokens/{id}", s.RevokeTokenHandler).Methods("DELETE").Name("revokeToken")
+
+	// Routes API des webhooks (session uniquement)
+	s.Router.HandleFunc("/api/webhooks", s.ListWebhooksHandler).Methods("GET").Name("listWebhooks")
+	s.Router.HandleFunc("/api/webhooks", s.CreateWebhookHandler).Methods("POST").Name("createWebhook")
+	s.Router.HandleFunc("/api/webhooks/{id}", s.UpdateWebhookHandler).Methods("PATCH").Name("updateWebhook")
+	s.Router.HandleFunc("/api/webhooks/{id}", s.DeleteWebhookHandler).Methods("DELETE").Name("deleteWebhook")
+	s.Router.HandleFunc("/api/webhooks/{id}/ping", s.PingWebhookHandler).Methods("POST").Name("pingWebhook")
+	s.Router.HandleFunc("/api/webhooks/{id}/deliveries", s.WebhookDeliveriesHandler).Methods("GET").Name("listWebhookDeliveries")
+	s.Router.HandleFunc("/api/webhooks/{id}/deliveries/{delivery}/redeliver", s.RedeliverWebhookHandler).Methods("POST").Name("redeliverWebhook")
+
+	// Point d'entrée JSON-RPC 2.0 (voir rpc.go)
+	s.Router.Handle("/rpc", s.newRPCServer()).Methods("POST").Name("rpc")
+
+	// API JSON versionnée
+	s.Router.HandleFunc("/api/openapi.json", s.OpenAPIHandler).Methods("GET")
+	s.Router.HandleFunc("/api/docs", s.APIDocsHandler).Methods("GET")
+	s.Router.HandleFunc("/api/v1/search", s.APISearchHandler).Methods("GET").Name("searchCatalog")
+	s.Router.HandleFunc("/api/v1/artists/{id}", s.APIArtistHandler).Methods("GET").Name("getArtist")
+	s.Router.HandleFunc("/api/v1/artists/{id}/albums", s.APIArtistAlbumsHandler).Methods("GET").Name("listArtistAlbums")
+	s.Router.HandleFunc("/api/v1/albums/{id}", s.APIAlbumHandler).Methods("GET").Name("getAlbum")
+	s.Router.HandleFunc("/api/v1/albums/{id}/tracks", s.APIAlbumTracksHandler).Methods("GET").Name("listAlbumTracks")
+	s.Router.HandleFunc("/api/v1/tracks/{id}", s.APITrackHandler).Methods("GET").Name("getTrack")
+	s.Router.HandleFunc("/api/v1/categories/{genre}", s.APICategoryHandler).Methods("GET").Name("listCategoryTracks")
+	s.Router.HandleFunc("/api/v1/favorites", s.APIFavoritesHandler).Methods("GET").Name("listFavorites")
+	s.Router.PathPrefix("/api/v1/").HandlerFunc(s.APINotFoundHandler)
+
+	// Routes d'administration
+	s.Router.HandleFunc("/api/admin/favorites/refresh", s.RefreshFavoritesHandler).Methods("POST")
+	s.Router.HandleFunc("/api/admin/favorites/refresh", s.RefreshStatusHandler).Methods("GET")
+	s.Router.HandleFunc("/api/admin/releases/check", s.CheckReleasesHandler).Methods("POST")
 
 	// Gestionnaire d'erreur (404)
 	s.Router.NotFoundHandler = http.HandlerFunc(s.ErrorHandler)
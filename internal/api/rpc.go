@@ -0,0 +1,245 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/yourusername/melody-explorer/internal/enrich"
+	"github.com/yourusername/melody-explorer/internal/jsonrpc"
+	"github.com/yourusername/melody-explorer/internal/models"
+	"github.com/yourusername/melody-explorer/internal/spotify"
+)
+
+// Point d'entrée JSON-RPC 2.0 (POST /rpc). Les méthodes reprennent les
+// traitements des pages HTML et de l'API JSON versionnée ; un client peut
+// en enchaîner plusieurs dans une seule requête HTTP.
+
+// Codes d'erreur propres à l'application (plage -32000 à -32099)
+const (
+	rpcErrUnauthorized = -32001
+	rpcErrNotFound     = -32002
+	rpcErrUpstream     = -32003
+	rpcErrForbidden    = -32004
+)
+
+// rpcSearchParams sont les paramètres de catalog.search
+type rpcSearchParams struct {
+	Query string   `json:"query"`
+	Types []string `json:"types"`
+	Limit int      `json:"limit"`
+	Page  int      `json:"page"`
+}
+
+// rpcSearchResult est le résultat de catalog.search
+type rpcSearchResult struct {
+	apiSearchData
+	// Favorites liste les clés (type:id) des résultats qui sont en favoris
+	Favorites  []string       `json:"favorites"`
+	Pagination *apiPagination `json:"pagination"`
+}
+
+// rpcArtistParams sont les paramètres de catalog.getArtist
+type rpcArtistParams struct {
+	ID string `json:"id"`
+}
+
+// rpcArtistResult est le résultat de catalog.getArtist
+type rpcArtistResult struct {
+	Artist     *spotify.Artist `json:"artist"`
+	IsFavorite bool            `json:"is_favorite"`
+}
+
+// rpcFavoritesParams sont les paramètres de favorites.list : les filtres de
+// la page des favoris, un filtre type et la pagination
+type rpcFavoritesParams struct {
+	Type   string `json:"type"`
+	Genre  string `json:"genre"`
+	Year   string `json:"year"`
+	Artist string `json:"artist"`
+	Tag    string `json:"tag"`
+	Limit  int    `json:"limit"`
+	Page   int    `json:"page"`
+}
+
+// rpcFavoritesResult est le résultat de favorites.list
+type rpcFavoritesResult struct {
+	Items      []models.FavoriteItem `json:"items"`
+	Pagination *apiPagination        `json:"pagination"`
+}
+
+// newRPCServer crée le serveur JSON-RPC et enregistre ses méthodes
+func (s *Server) newRPCServer() *jsonrpc.Server {
+	rpc := jsonrpc.NewServer()
+	rpc.Register("catalog.search", s.rpcMethod(models.TokenScopeRead, s.rpcSearch))
+	rpc.Register("catalog.getArtist", s.rpcMethod(models.TokenScopeRead, s.rpcGetArtist))
+	rpc.Register("favorites.list", s.rpcMethod(models.TokenScopeRead, s.rpcListFavorites))
+	rpc.Register("favorites.add", s.rpcMethod(models.TokenScopeFavoritesWrite, s.rpcAddFavorite))
+	// Comme l'API des listes, lists.create est réservée à la session
+	rpc.Register("lists.create", s.rpcMethod("", s.rpcCreateList))
+	return rpc
+}
+
+// rpcMethod vérifie l'authentification avant d'exécuter une méthode. Un
+// appel authentifié par jeton doit en avoir la portée ; une portée vide
+// réserve la méthode à la session du navigateur.
+func (s *Server) rpcMethod(scope models.TokenScope, handler jsonrpc.Handler) jsonrpc.Handler {
+	return func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		if token, ok := apiTokenFrom(ctx); ok {
+			if scope == "" {
+				return nil, jsonrpc.NewError(rpcErrForbidden, "Cette méthode n'est pas accessible avec un jeton d'accès")
+			}
+			if !token.Allows(scope) {
+				return nil, jsonrpc.NewError(rpcErrForbidden, "La portée du jeton ne permet pas cette opération")
+			}
+		} else if !s.isAuthenticatedContext(ctx) {
+			return nil, jsonrpc.NewError(rpcErrUnauthorized, "Non autorisé")
+		}
+		return handler(ctx, params)
+	}
+}
+
+// rpcSpotifyError traduit une erreur de Spotify, comme writeSpotifyAPIError
+func rpcSpotifyError(err error) error {
+	if enrich.UnavailableReason(err) != "" {
+		return jsonrpc.NewError(rpcErrNotFound, "Élément introuvable sur Spotify")
+	}
+	return jsonrpc.NewError(rpcErrUpstream, "Erreur de Spotify : "+err.Error())
+}
+
+// rpcInputError traduit une erreur de validation en paramètres invalides
+func rpcInputError(err error) error {
+	var invalid inputError
+	if errors.As(err, &invalid) {
+		return jsonrpc.InvalidParams(invalid.Error())
+	}
+	return err
+}
+
+// rpcPage applique les valeurs par défaut de la pagination et la vérifie
+func rpcPage(limit, page int) (int, int, error) {
+	if limit == 0 {
+		limit = defaultPageLimit
+	}
+	if page == 0 {
+		page = 1
+	}
+	if err := checkAPIPage(limit, page); err != nil {
+		return 0, 0, jsonrpc.InvalidParams(err.Error())
+	}
+	return limit, page, nil
+}
+
+// rpcSearch implémente catalog.search
+func (s *Server) rpcSearch(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p rpcSearchParams
+	if err := jsonrpc.DecodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	query := strings.TrimSpace(p.Query)
+	if query == "" {
+		return nil, jsonrpc.InvalidParams("Le paramètre query est obligatoire")
+	}
+	limit, page, err := rpcPage(p.Limit, p.Page)
+	if err != nil {
+		return nil, err
+	}
+	types, err := parseSearchTypes(p.Types)
+	if err != nil {
+		return nil, jsonrpc.InvalidParams(err.Error())
+	}
+
+	results, err := s.searchCatalog(query, types, limit, page)
+	if err != nil {
+		return nil, rpcSpotifyError(err)
+	}
+
+	data, total := newAPISearchData(query, types, results)
+	result := rpcSearchResult{
+		apiSearchData: data,
+		Favorites:     s.favoriteMeta(favoriteKeys(results)).Favorites,
+		Pagination:    apiPaginationFrom(newPagination(page, limit, total)),
+	}
+	if result.Favorites == nil {
+		result.Favorites = []string{}
+	}
+	return result, nil
+}
+
+// rpcGetArtist implémente catalog.getArtist
+func (s *Server) rpcGetArtist(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p rpcArtistParams
+	if err := jsonrpc.DecodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	if p.ID == "" {
+		return nil, jsonrpc.InvalidParams("Le paramètre id est obligatoire")
+	}
+
+	artist, err := s.fetchArtist(p.ID)
+	if err != nil {
+		return nil, rpcSpotifyError(err)
+	}
+	return rpcArtistResult{
+		Artist:     artist,
+		IsFavorite: s.FavoritesStorage.Contains(artist.ID, models.FavoriteTypeArtist),
+	}, nil
+}
+
+// rpcListFavorites implémente favorites.list
+func (s *Server) rpcListFavorites(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p rpcFavoritesParams
+	if err := jsonrpc.DecodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	limit, page, err := rpcPage(p.Limit, p.Page)
+	if err != nil {
+		return nil, err
+	}
+
+	var itemType models.FavoriteType
+	if p.Type != "" {
+		var ok bool
+		if itemType, ok = models.ParseFavoriteType(p.Type); !ok {
+			return nil, jsonrpc.InvalidParams("Type de favori invalide : " + p.Type)
+		}
+	}
+
+	filter := favoritesFilter{
+		Genre:  strings.TrimSpace(p.Genre),
+		Year:   strings.TrimSpace(p.Year),
+		Artist: strings.TrimSpace(p.Artist),
+		Tag:    strings.TrimSpace(p.Tag),
+	}
+	items, pagination := s.listFavorites(filter, itemType, limit, page)
+	return rpcFavoritesResult{Items: items, Pagination: apiPaginationFrom(pagination)}, nil
+}
+
+// rpcAddFavorite implémente favorites.add et renvoie le favori enregistré
+func (s *Server) rpcAddFavorite(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req favoriteRequest
+	if err := jsonrpc.DecodeParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	item, err := s.addFavorite(s.favoritesForContext(ctx), req)
+	if err != nil {
+		return nil, rpcInputError(err)
+	}
+	return item, nil
+}
+
+// rpcCreateList implémente lists.create et renvoie la liste créée
+func (s *Server) rpcCreateList(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req listRequest
+	if err := jsonrpc.DecodeParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	list, err := s.createList(req)
+	if err != nil {
+		return nil, rpcInputError(err)
+	}
+	return list, nil
+}
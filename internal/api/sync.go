@@ -0,0 +1,293 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yourusername/melody-explorer/internal/history"
+	"github.com/yourusername/melody-explorer/internal/hlc"
+	"github.com/yourusername/melody-explorer/internal/models"
+	"github.com/yourusername/melody-explorer/internal/storage"
+)
+
+// Synchronisation hors ligne (POST /api/sync) : un appareil envoie les
+// modifications faites hors connexion et reçoit celles du serveur depuis
+// sa dernière synchronisation. Chaque favori porte l'horodatage logique
+// hybride (voir le paquet hlc) de sa dernière modification ; entre deux
+// modifications concurrentes, la plus récente l'emporte, suppressions
+// comprises.
+
+const (
+	// maxSyncChanges est le nombre maximal de modifications par requête
+	maxSyncChanges = 500
+	// maxSyncBodySize est la taille maximale du corps d'une requête
+	maxSyncBodySize = 4 << 20
+	// syncServerNode identifie le serveur dans les horodatages qu'il émet
+	syncServerNode = "server"
+)
+
+// Statuts des modifications reçues
+const (
+	syncStatusApplied = "applied"
+	// syncStatusStale marque une modification plus ancienne que la version
+	// du serveur, renvoyée dans la réponse
+	syncStatusStale = "stale"
+	syncStatusError = "error"
+)
+
+// syncChange est une modification faite par un appareil. Un favori modifié
+// est envoyé en entier : ses champs remplacent ceux du serveur, à
+// l'exception des métadonnées de Spotify et du nom et de l'image s'ils sont
+// omis.
+type syncChange struct {
+	Type    string `json:"type"`
+	ID      string `json:"id"`
+	Version string `json:"version"`
+	Deleted bool   `json:"deleted,omitempty"`
+
+	Name     string     `json:"name,omitempty"`
+	ImageURL string     `json:"image_url,omitempty"`
+	AddedAt  *time.Time `json:"added_at,omitempty"`
+	Note     string     `json:"note,omitempty"`
+	Rating   int        `json:"rating,omitempty"`
+	Tags     []string   `json:"tags,omitempty"`
+
+	itemType models.FavoriteType
+	version  hlc.Timestamp
+}
+
+// syncRequest est le corps de POST /api/sync
+type syncRequest struct {
+	// Cursor est le curseur de la synchronisation précédente, vide la première fois
+	Cursor  string       `json:"cursor"`
+	Changes []syncChange `json:"changes"`
+}
+
+// syncResult est le résultat d'une modification reçue
+type syncResult struct {
+	Index  int                 `json:"index"`
+	Type   models.FavoriteType `json:"type"`
+	ID     string              `json:"id"`
+	Status string              `json:"status"`
+	Error  string              `json:"error,omitempty"`
+}
+
+// syncResponse est la réponse de POST /api/sync
+type syncResponse struct {
+	Success bool `json:"success"`
+	// Cursor est à renvoyer lors de la prochaine synchronisation
+	Cursor hlc.Timestamp `json:"cursor"`
+	// Reset indique que Items contient tous les favoris : l'appareil doit
+	// remplacer son état, le curseur étant absent ou trop ancien
+	Reset   bool                  `json:"reset"`
+	Results []syncResult          `json:"results"`
+	Items   []models.FavoriteItem `json:"items"`
+	Deleted []models.Tombstone    `json:"deleted"`
+}
+
+// validate vérifie une modification et avance l'horloge du serveur après
+// son horodatage
+func (c *syncChange) validate(clock *hlc.Clock) error {
+	info, ok := models.LookupFavoriteType(models.FavoriteType(c.Type))
+	if !ok {
+		return fmt.Errorf("type invalide : %q", c.Type)
+	}
+	c.itemType = info.Type
+
+	item := models.FavoriteItem{ID: c.ID, Type: info.Type, Name: c.Name}
+	if err := info.Normalize(&item); err != nil {
+		return err
+	}
+	c.ID, c.Name = item.ID, item.Name
+
+	version, err := hlc.Parse(c.Version)
+	if err != nil {
+		return err
+	}
+	if err := clock.Observe(version); err != nil {
+		return err
+	}
+	c.version = version
+
+	if c.Deleted {
+		return nil
+	}
+	if len([]rune(c.Note)) > maxNoteLength {
+		return errors.New("note trop longue")
+	}
+	if !models.ValidRating(c.Rating) {
+		return errors.New("la note doit être comprise entre 1 et 5 (0 pour l'effacer)")
+	}
+	return nil
+}
+
+// apply applique la modification dans la transaction si elle est plus
+// récente que la version connue du serveur
+func (c *syncChange) apply(tx *storage.Tx, known hlc.Timestamp) string {
+	if !c.version.After(known) {
+		return syncStatusStale
+	}
+	if c.Deleted {
+		tx.Remove(c.ID, c.itemType)
+		return syncStatusApplied
+	}
+
+	item, ok := tx.Get(c.ID, c.itemType)
+	if !ok {
+		item = models.FavoriteItem{ID: c.ID, Type: c.itemType, AddedAt: time.Now()}
+	}
+	if c.Name != "" {
+		item.Name = c.Name
+	}
+	if c.ImageURL != "" {
+		item.ImageURL = c.ImageURL
+	}
+	if c.AddedAt != nil && !c.AddedAt.IsZero() {
+		item.AddedAt = *c.AddedAt
+	}
+	item.Note = strings.TrimSpace(c.Note)
+	item.Rating = c.Rating
+	item.Tags = models.NormalizeTags(c.Tags)
+	tx.Add(item)
+	return syncStatusApplied
+}
+
+// SyncHandler gère POST /api/sync : applique les modifications de
+// l'appareil, puis renvoie les favoris modifiés et supprimés depuis son
+// curseur. Les modifications invalides sont ignorées et signalées, sans
+// empêcher l'application des autres. Les favoris ajoutés sont enregistrés
+// sans métadonnées, complétées au prochain rafraîchissement.
+func (s *Server) SyncHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.isAuthenticated(r) {
+		writeJSONError(w, http.StatusUnauthorized, "Non autorisé")
+		return
+	}
+
+	var req syncRequest
+	r.Body = http.MaxBytesReader(w, r.Body, maxSyncBodySize)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Corps de requête invalide")
+		return
+	}
+	if len(req.Changes) > maxSyncChanges {
+		writeJSONError(w, http.StatusBadRequest, "Trop de modifications ("+strconv.Itoa(maxSyncChanges)+" au maximum)")
+		return
+	}
+
+	var cursor hlc.Timestamp
+	if req.Cursor != "" {
+		var err error
+		if cursor, err = hlc.Parse(req.Cursor); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "Curseur invalide")
+			return
+		}
+	}
+
+	results := make([]syncResult, len(req.Changes))
+	versions := make(map[models.FavoriteKey]hlc.Timestamp, len(req.Changes))
+	for i := range req.Changes {
+		change := &req.Changes[i]
+		results[i] = syncResult{Index: i, Type: models.FavoriteType(change.Type), ID: change.ID}
+		if err := change.validate(s.Clock); err != nil {
+			results[i].Status = syncStatusError
+			results[i].Error = err.Error()
+			continue
+		}
+		results[i].Type, results[i].ID = change.itemType, change.ID
+	}
+
+	store := s.History.As(history.ActorSync)
+	if token, ok := apiTokenFrom(r.Context()); ok {
+		store = s.History.As(history.ActorToken(token.Name))
+	}
+	next, err := store.UpdateVersioned(versions, func(tx *storage.Tx) error {
+		for i := range req.Changes {
+			change := &req.Changes[i]
+			if results[i].Status == syncStatusError {
+				continue
+			}
+			key := models.FavoriteKey{Type: change.itemType, ID: change.ID}
+
+			// Version connue du serveur, y compris celle d'une modification
+			// précédente de la même requête
+			known, ok := versions[key]
+			if !ok {
+				if item, found := tx.Get(change.ID, change.itemType); found {
+					known = item.Version
+				} else if tombstone, found := s.Tombstones.Get(key); found {
+					known = tombstone.Version
+				}
+			}
+
+			results[i].Status = change.apply(tx, known)
+			if results[i].Status == syncStatusApplied {
+				versions[key] = change.version
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Erreur lors de la synchronisation des favoris: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "Échec lors de l'enregistrement des favoris: "+err.Error())
+		return
+	}
+
+	// Les versions du serveur qui l'ont emporté sont renvoyées même si elles
+	// précèdent le curseur, pour que l'appareil remplace les siennes
+	stale := make(map[models.FavoriteKey]bool)
+	for i, result := range results {
+		if result.Status == syncStatusStale {
+			stale[models.FavoriteKey{Type: req.Changes[i].itemType, ID: req.Changes[i].ID}] = true
+		}
+	}
+
+	// La première synchronisation reçoit l'état complet, y compris les
+	// favoris enregistrés avant leur versionnage, qui n'ont pas de révision.
+	// Un curseur antérieur aux suppressions purgées ne permet plus de les
+	// transmettre : renvoyer aussi l'état complet.
+	reset := cursor.IsZero() || s.Tombstones.PurgedBefore().After(cursor)
+	response := syncResponse{
+		Success: true,
+		Cursor:  next,
+		Reset:   reset,
+		Results: results,
+		Items:   []models.FavoriteItem{},
+		Deleted: []models.Tombstone{},
+	}
+	present := make(map[models.FavoriteKey]bool)
+	for _, item := range s.FavoritesStorage.GetAll() {
+		present[item.Key()] = true
+		if reset || stale[item.Key()] || item.Revision.After(cursor) {
+			response.Items = append(response.Items, item)
+		}
+	}
+	if !reset {
+		for _, tombstone := range s.Tombstones.Since(cursor) {
+			if !present[tombstone.Key()] {
+				response.Deleted = append(response.Deleted, tombstone)
+				delete(stale, tombstone.Key())
+			}
+		}
+		for key := range stale {
+			if tombstone, ok := s.Tombstones.Get(key); ok && !present[key] {
+				response.Deleted = append(response.Deleted, tombstone)
+			}
+		}
+	}
+
+	applied := 0
+	for _, result := range results {
+		if result.Status == syncStatusApplied {
+			applied++
+		}
+	}
+	log.Printf("Synchronisation : %d modifications reçues, %d appliquées, %d favoris et %d suppressions renvoyés",
+		len(req.Changes), applied, len(response.Items), len(response.Deleted))
+	writeJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,240 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/yourusername/melody-explorer/internal/history"
+	"github.com/yourusername/melody-explorer/internal/hlc"
+	"github.com/yourusername/melody-explorer/internal/models"
+	"github.com/yourusername/melody-explorer/internal/storage"
+)
+
+// syncTrackID est l'identifiant du morceau synchronisé par les tests
+const syncTrackID = "4uLU6hMCjMI75M1A2tKUQC"
+
+// newSyncServer crée un serveur limité aux favoris versionnés, stockés dans
+// un dossier temporaire. store est le stockage sous-jacent, dont les
+// modifications ne sont pas horodatées.
+func newSyncServer(t *testing.T) (s *Server, store storage.FavoritesStore) {
+	t.Helper()
+	dir := t.TempDir()
+
+	store, err := storage.NewFavoritesStorage(dir, storage.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	historyLog, err := history.OpenLog(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tombstones, err := storage.NewTombstonesStorage(dir, storage.DefaultTombstoneRetention)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clock := hlc.NewClock(syncServerNode)
+	recorder := history.NewRecorder(store, historyLog, history.ActorWeb)
+	recorder.EnableVersioning(clock, tombstones)
+	return &Server{FavoritesStorage: recorder, History: recorder, Clock: clock, Tombstones: tombstones}, store
+}
+
+// postSync envoie une synchronisation authentifiée par un jeton d'accès
+func postSync(t *testing.T, s *Server, req syncRequest) syncResponse {
+	t.Helper()
+	body, err := json.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest(http.MethodPost, "/api/sync", bytes.NewReader(body))
+	r = r.WithContext(context.WithValue(r.Context(), tokenContextKey{}, models.APIToken{Name: "test"}))
+	w := httptest.NewRecorder()
+	s.SyncHandler(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("statut %d : %s", w.Code, w.Body)
+	}
+
+	var response syncResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	return response
+}
+
+// version renvoie un horodatage d'appareil décalé de l'heure courante
+func version(offset time.Duration, node string) string {
+	return string(hlc.New(time.Now().Add(offset).UnixMilli(), 0, node))
+}
+
+// noted renvoie la modification du morceau de test avec une note
+func noted(offset time.Duration, note string) syncChange {
+	return syncChange{Type: "track", ID: syncTrackID, Name: "Morceau", Version: version(offset, "phone"), Note: note}
+}
+
+// deleted renvoie la suppression du morceau de test
+func deleted(offset time.Duration) syncChange {
+	return syncChange{Type: "track", ID: syncTrackID, Version: version(offset, "laptop"), Deleted: true}
+}
+
+func TestSyncHandlerLastWriterWins(t *testing.T) {
+	tests := []struct {
+		name string
+		// setup est envoyé par un premier appareil, dont le curseur sert à
+		// la synchronisation testée
+		setup   []syncChange
+		changes []syncChange
+		status  []string
+		// items associe la note renvoyée à chaque favori renvoyé
+		items   map[string]string
+		deleted []string
+	}{
+		{
+			name:    "nouveau favori",
+			changes: []syncChange{noted(-time.Minute, "ajouté")},
+			status:  []string{syncStatusApplied},
+			items:   map[string]string{syncTrackID: "ajouté"},
+		},
+		{
+			name:    "modification plus récente",
+			setup:   []syncChange{noted(-10*time.Minute, "serveur")},
+			changes: []syncChange{noted(-5*time.Minute, "appareil")},
+			status:  []string{syncStatusApplied},
+			items:   map[string]string{syncTrackID: "appareil"},
+		},
+		{
+			name:    "modification plus ancienne renvoyée avec la version du serveur",
+			setup:   []syncChange{noted(-5*time.Minute, "serveur")},
+			changes: []syncChange{noted(-10*time.Minute, "appareil")},
+			status:  []string{syncStatusStale},
+			items:   map[string]string{syncTrackID: "serveur"},
+		},
+		{
+			name:    "suppression plus récente",
+			setup:   []syncChange{noted(-10*time.Minute, "serveur")},
+			changes: []syncChange{deleted(-5 * time.Minute)},
+			status:  []string{syncStatusApplied},
+			deleted: []string{syncTrackID},
+		},
+		{
+			name:    "suppression plus ancienne",
+			setup:   []syncChange{noted(-5*time.Minute, "serveur")},
+			changes: []syncChange{deleted(-10 * time.Minute)},
+			status:  []string{syncStatusStale},
+			items:   map[string]string{syncTrackID: "serveur"},
+		},
+		{
+			name:    "modification antérieure à une suppression",
+			setup:   []syncChange{noted(-10*time.Minute, "serveur"), deleted(-5 * time.Minute)},
+			changes: []syncChange{noted(-7*time.Minute, "appareil")},
+			status:  []string{syncStatusStale},
+			deleted: []string{syncTrackID},
+		},
+		{
+			name:    "suppression d'un favori absent du serveur",
+			setup:   []syncChange{deleted(-5 * time.Minute)},
+			changes: []syncChange{noted(-7*time.Minute, "appareil")},
+			status:  []string{syncStatusStale},
+			deleted: []string{syncTrackID},
+		},
+		{
+			name:    "favori recréé après une suppression",
+			setup:   []syncChange{noted(-10*time.Minute, "serveur"), deleted(-5 * time.Minute)},
+			changes: []syncChange{noted(-time.Minute, "recréé")},
+			status:  []string{syncStatusApplied},
+			items:   map[string]string{syncTrackID: "recréé"},
+		},
+		{
+			name:    "modifications successives dans la même requête",
+			changes: []syncChange{noted(-5*time.Minute, "récente"), noted(-7*time.Minute, "ancienne")},
+			status:  []string{syncStatusApplied, syncStatusStale},
+			items:   map[string]string{syncTrackID: "récente"},
+		},
+		{
+			name: "modification invalide ignorée",
+			changes: []syncChange{
+				{Type: "track", ID: syncTrackID, Version: "hier"},
+				{Type: "inconnu", ID: syncTrackID, Version: version(-time.Minute, "phone")},
+				noted(-time.Minute, "valide"),
+			},
+			status: []string{syncStatusError, syncStatusError, syncStatusApplied},
+			items:  map[string]string{syncTrackID: "valide"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newSyncServer(t)
+			cursor := postSync(t, s, syncRequest{Changes: tt.setup}).Cursor
+
+			response := postSync(t, s, syncRequest{Cursor: string(cursor), Changes: tt.changes})
+			if response.Reset {
+				t.Error("réinitialisation inattendue avec un curseur valide")
+			}
+
+			var status []string
+			for _, result := range response.Results {
+				status = append(status, result.Status)
+			}
+			if !reflect.DeepEqual(status, tt.status) {
+				t.Errorf("statuts = %v, attendu %v", status, tt.status)
+			}
+
+			items := map[string]string{}
+			for _, item := range response.Items {
+				items[item.ID] = item.Note
+			}
+			if tt.items == nil {
+				tt.items = map[string]string{}
+			}
+			if !reflect.DeepEqual(items, tt.items) {
+				t.Errorf("favoris renvoyés = %v, attendu %v", items, tt.items)
+			}
+
+			var deleted []string
+			for _, tombstone := range response.Deleted {
+				deleted = append(deleted, tombstone.ID)
+			}
+			if !reflect.DeepEqual(deleted, tt.deleted) {
+				t.Errorf("suppressions renvoyées = %v, attendu %v", deleted, tt.deleted)
+			}
+			if !response.Cursor.After(cursor) {
+				t.Errorf("curseur %q non postérieur à %q", response.Cursor, cursor)
+			}
+		})
+	}
+}
+
+func TestSyncHandlerCursor(t *testing.T) {
+	s, store := newSyncServer(t)
+
+	// Un favori enregistré avant le versionnage n'a pas de révision
+	if err := store.Add(models.FavoriteItem{ID: syncTrackID, Type: models.FavoriteTypeTrack, Name: "Ancien"}); err != nil {
+		t.Fatal(err)
+	}
+
+	first := postSync(t, s, syncRequest{})
+	if !first.Reset || len(first.Items) != 1 {
+		t.Fatalf("première synchronisation : reset = %v, %d favoris ; attendu l'état complet", first.Reset, len(first.Items))
+	}
+
+	second := postSync(t, s, syncRequest{Cursor: string(first.Cursor)})
+	if second.Reset || len(second.Items) != 0 || len(second.Deleted) != 0 {
+		t.Errorf("synchronisation sans modification : reset = %v, %d favoris, %d suppressions",
+			second.Reset, len(second.Items), len(second.Deleted))
+	}
+
+	if err := s.FavoritesStorage.Remove(syncTrackID, models.FavoriteTypeTrack); err != nil {
+		t.Fatal(err)
+	}
+	third := postSync(t, s, syncRequest{Cursor: string(second.Cursor)})
+	if len(third.Deleted) != 1 || third.Deleted[0].ID != syncTrackID {
+		t.Errorf("suppression non transmise : %+v", third.Deleted)
+	}
+}
@@ -0,0 +1,343 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+
+	"github.com/yourusername/melody-explorer/internal/history"
+	"github.com/yourusername/melody-explorer/internal/models"
+	"github.com/yourusername/melody-explorer/internal/storage"
+)
+
+// Jetons d'accès personnels : les scripts appellent l'API avec l'en-tête
+// Authorization: Bearer <jeton> au lieu d'une session du navigateur.
+// Le serveur ne conserve que les données du compte Spotify connecté : un
+// jeton n'est accepté que si ce compte est celui qui l'a créé.
+
+// maxTokenLifetimeDays est la durée de validité maximale d'un jeton
+const maxTokenLifetimeDays = 365
+
+// tokenContextKey est la clé du jeton authentifié dans le contexte de la requête
+type tokenContextKey struct{}
+
+// apiTokenFrom renvoie le jeton avec lequel la requête a été authentifiée
+func apiTokenFrom(ctx context.Context) (models.APIToken, bool) {
+	token, ok := ctx.Value(tokenContextKey{}).(models.APIToken)
+	return token, ok
+}
+
+// bearerToken extrait le jeton de l'en-tête Authorization
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+// tokenRequiredScope renvoie la portée nécessaire à une requête authentifiée
+// par jeton, ou false si la route n'est jamais accessible par jeton : pages
+// HTML, administration, webhooks et gestion des jetons elle-même. Le point
+// d'entrée JSON-RPC demande la lecture ; chaque méthode vérifie ensuite sa
+// propre portée (voir rpc.go). La synchronisation modifie les favoris.
+func tokenRequiredScope(r *http.Request) (models.TokenScope, bool) {
+	path := r.URL.Path
+	if path == "/rpc" {
+		return models.TokenScopeRead, true
+	}
+	if !strings.HasPrefix(path, "/api/") || strings.HasPrefix(path, "/api/admin/") || path == "/api/tokens" || strings.HasPrefix(path, "/api/tokens/") ||
+		path == "/api/webhooks" || strings.HasPrefix(path, "/api/webhooks/") {
+		return "", false
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return models.TokenScopeRead, true
+	}
+	if strings.HasPrefix(path, "/api/favorites/") || path == "/api/sync" {
+		return models.TokenScopeFavoritesWrite, true
+	}
+	return "", false
+}
+
+// writeTokenError écrit une erreur d'authentification par jeton, au format
+// de l'API concernée
+func writeTokenError(w http.ResponseWriter, r *http.Request, status int, challenge, message string) {
+	w.Header().Set("WWW-Authenticate", `Bearer error="`+challenge+`"`)
+	if strings.HasPrefix(r.URL.Path, "/api/v1/") {
+		code := apiErrUnauthorized
+		if status == http.StatusForbidden {
+			code = apiErrForbidden
+		}
+		writeAPIError(w, status, code, message)
+		return
+	}
+	writeJSONError(w, status, message)
+}
+
+// TokenMiddleware authentifie les requêtes qui portent un jeton d'accès
+// personnel et vérifie sa portée et son propriétaire. Les requêtes sans
+// en-tête Authorization passent inchangées.
+func (s *Server) TokenMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		plain, ok := bearerToken(r)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		scope, allowed := tokenRequiredScope(r)
+		if !allowed {
+			writeTokenError(w, r, http.StatusForbidden, "insufficient_scope", "Cette route n'est pas accessible avec un jeton d'accès")
+			return
+		}
+
+		if s.Tokens == nil {
+			writeTokenError(w, r, http.StatusUnauthorized, "invalid_token", "Jeton d'accès invalide")
+			return
+		}
+		token, found := s.Tokens.Lookup(plain)
+		now := time.Now()
+		if !found || !token.Active(now) {
+			writeTokenError(w, r, http.StatusUnauthorized, "invalid_token", "Jeton d'accès invalide, expiré ou révoqué")
+			return
+		}
+		if !token.Allows(scope) {
+			writeTokenError(w, r, http.StatusForbidden, "insufficient_scope", "La portée du jeton ne permet pas cette opération")
+			return
+		}
+
+		// Les données servies sont celles du compte connecté
+		owner, err := s.sessionUser()
+		if err != nil {
+			log.Printf("Erreur lors de l'obtention de l'utilisateur Spotify: %v", err)
+			writeTokenError(w, r, http.StatusUnauthorized, "invalid_token", "Aucun compte Spotify connecté pour vérifier le propriétaire du jeton")
+			return
+		}
+		if owner != token.Owner {
+			writeTokenError(w, r, http.StatusUnauthorized, "invalid_token", "Le jeton d'accès appartient à un autre compte Spotify")
+			return
+		}
+
+		s.Tokens.Touch(token.ID, now)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), tokenContextKey{}, token)))
+	})
+}
+
+// isAuthenticated indique si la requête vient de la session Spotify ou d'un
+// jeton d'accès valide
+func (s *Server) isAuthenticated(r *http.Request) bool {
+	return s.isAuthenticatedContext(r.Context())
+}
+
+// isAuthenticatedContext est la variante d'isAuthenticated pour les
+// traitements qui ne reçoivent que le contexte de la requête
+func (s *Server) isAuthenticatedContext(ctx context.Context) bool {
+	if _, ok := apiTokenFrom(ctx); ok {
+		return true
+	}
+	return s.SpotifyAuth.IsTokenValid()
+}
+
+// favoritesFor renvoie le stockage des favoris dont les modifications sont
+// attribuées à l'auteur de la requête : le jeton d'accès, ou l'utilisateur
+func (s *Server) favoritesFor(r *http.Request) storage.FavoritesStore {
+	return s.favoritesForContext(r.Context())
+}
+
+// favoritesForContext est la variante de favoritesFor pour les traitements
+// qui ne reçoivent que le contexte de la requête
+func (s *Server) favoritesForContext(ctx context.Context) storage.FavoritesStore {
+	if token, ok := apiTokenFrom(ctx); ok && s.History != nil {
+		return s.History.As(history.ActorToken(token.Name))
+	}
+	return s.FavoritesStorage
+}
+
+// tokenRequest est le corps de la création d'un jeton
+type tokenRequest struct {
+	Name  string `json:"name"`
+	Scope string `json:"scope"`
+	// ExpiresInDays est la durée de validité en jours, 0 pour un jeton sans expiration
+	ExpiresInDays int `json:"expires_in_days"`
+}
+
+// tokensResponse est la réponse de GET /api/tokens
+type tokensResponse struct {
+	Success bool              `json:"success"`
+	Tokens  []models.APIToken `json:"tokens"`
+}
+
+// tokenResponse renvoie un jeton ; le secret n'est présent qu'à la création
+type tokenResponse struct {
+	Success bool            `json:"success"`
+	Token   models.APIToken `json:"token"`
+	Secret  string          `json:"secret,omitempty"`
+}
+
+// ownTokens renvoie les jetons d'accès de l'utilisateur connecté, dans
+// l'ordre de création : ceux d'un autre compte Spotify ne sont ni affichés
+// ni révocables
+func (s *Server) ownTokens() ([]models.APIToken, error) {
+	owner, err := s.sessionUser()
+	if err != nil {
+		return nil, err
+	}
+	var tokens []models.APIToken
+	for _, token := range s.Tokens.All() {
+		if token.Owner == owner {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens, nil
+}
+
+// ListTokensHandler renvoie les jetons d'accès de l'utilisateur connecté,
+// sans leur secret
+func (s *Server) ListTokensHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.SpotifyAuth.IsTokenValid() {
+		writeJSONError(w, http.StatusUnauthorized, "Non autorisé")
+		return
+	}
+
+	tokens, err := s.ownTokens()
+	if err != nil {
+		writeJSONError(w, http.StatusBadGateway, "Impossible d'identifier l'utilisateur Spotify: "+err.Error())
+		log.Printf("Erreur lors de l'obtention de l'utilisateur Spotify: %v", err)
+		return
+	}
+	for i := range tokens {
+		tokens[i] = tokens[i].Public()
+	}
+	if tokens == nil {
+		tokens = []models.APIToken{}
+	}
+	writeJSON(w, http.StatusOK, tokensResponse{Success: true, Tokens: tokens})
+}
+
+// CreateTokenHandler crée un jeton d'accès pour l'utilisateur connecté. Le
+// secret figure uniquement dans cette réponse.
+func (s *Server) CreateTokenHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.SpotifyAuth.IsTokenValid() {
+		writeJSONError(w, http.StatusUnauthorized, "Non autorisé")
+		return
+	}
+
+	var req tokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Corps de requête invalide")
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || utf8.RuneCountInString(req.Name) > 100 {
+		writeJSONError(w, http.StatusBadRequest, "Le nom du jeton doit contenir de 1 à 100 caractères")
+		return
+	}
+	scope, ok := models.ParseTokenScope(req.Scope)
+	if !ok {
+		writeJSONError(w, http.StatusBadRequest, "Portée invalide : read ou favorites-write attendu")
+		return
+	}
+	if req.ExpiresInDays < 0 || req.ExpiresInDays > maxTokenLifetimeDays {
+		writeJSONError(w, http.StatusBadRequest, "La durée de validité doit être comprise entre 0 et 365 jours")
+		return
+	}
+
+	// Le jeton est rattaché au compte Spotify connecté
+	user, err := s.SpotifyClient.GetCurrentUser()
+	if err != nil {
+		writeJSONError(w, http.StatusBadGateway, "Impossible d'identifier l'utilisateur Spotify: "+err.Error())
+		log.Printf("Erreur lors de l'obtention de l'utilisateur Spotify: %v", err)
+		return
+	}
+	s.account.set(user.ID)
+
+	token, plain, err := models.NewAPIToken(req.Name, scope, user.ID, user.DisplayName, time.Duration(req.ExpiresInDays)*24*time.Hour)
+	if err == nil {
+		err = s.Tokens.Create(token)
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Échec lors de la création du jeton: "+err.Error())
+		log.Printf("Erreur lors de la création du jeton: %v", err)
+		return
+	}
+
+	log.Printf("Jeton d'accès créé: %s (%s, %s)", token.Name, token.ID, token.Scope)
+
+	writeJSON(w, http.StatusCreated, tokenResponse{Success: true, Token: token.Public(), Secret: plain})
+}
+
+// RevokeTokenHandler révoque un jeton d'accès de l'utilisateur connecté
+func (s *Server) RevokeTokenHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.SpotifyAuth.IsTokenValid() {
+		writeJSONError(w, http.StatusUnauthorized, "Non autorisé")
+		return
+	}
+
+	tokens, err := s.ownTokens()
+	if err != nil {
+		writeJSONError(w, http.StatusBadGateway, "Impossible d'identifier l'utilisateur Spotify: "+err.Error())
+		log.Printf("Erreur lors de l'obtention de l'utilisateur Spotify: %v", err)
+		return
+	}
+	id := mux.Vars(r)["id"]
+	owned := false
+	for _, token := range tokens {
+		owned = owned || token.ID == id
+	}
+	if !owned {
+		writeJSONError(w, http.StatusNotFound, "Jeton introuvable")
+		return
+	}
+
+	token, err := s.Tokens.Revoke(id)
+	if errors.Is(err, storage.ErrTokenNotFound) {
+		writeJSONError(w, http.StatusNotFound, "Jeton introuvable")
+		return
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Échec lors de la révocation du jeton: "+err.Error())
+		log.Printf("Erreur lors de la révocation du jeton: %v", err)
+		return
+	}
+
+	log.Printf("Jeton d'accès révoqué: %s (%s)", token.Name, token.ID)
+
+	writeJSON(w, http.StatusOK, tokenResponse{Success: true, Token: token.Public()})
+}
+
+// AccountHandler affiche la page du compte et la gestion des jetons d'accès
+func (s *Server) AccountHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.SpotifyAuth.IsTokenValid() {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	// Les jetons de l'utilisateur, les plus récents en premier
+	tokens, err := s.ownTokens()
+	if err != nil {
+		log.Printf("Erreur lors de l'obtention de l'utilisateur Spotify: %v", err)
+	}
+	for i, j := 0, len(tokens)-1; i < j; i, j = i+1, j-1 {
+		tokens[i], tokens[j] = tokens[j], tokens[i]
+	}
+
+	data := PageData{
+		Title:       "Mon compte - MelodyExplorer",
+		IsLoggedIn:  true,
+		CurrentPage: "account",
+		Data: map[string]interface{}{
+			"Tokens": tokens,
+			"Scopes": []models.TokenScope{models.TokenScopeRead, models.TokenScopeFavoritesWrite},
+		},
+	}
+	s.renderTemplate(w, r, "account.html", data)
+}
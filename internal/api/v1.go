@@ -0,0 +1,419 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/yourusername/melody-explorer/internal/enrich"
+	"github.com/yourusername/melody-explorer/internal/models"
+	"github.com/yourusername/melody-explorer/internal/spotify"
+)
+
+// API JSON versionnée (/api/v1). Chaque réponse est une enveloppe
+// {"data": …, "meta": …} ; les erreurs ont la forme {"error": {…}}.
+
+// maxAPILimit est le nombre maximal d'éléments par page, celui de Spotify
+const maxAPILimit = 50
+
+// Codes d'erreur de l'API
+const (
+	apiErrBadRequest   = "bad_request"
+	apiErrUnauthorized = "unauthorized"
+	apiErrForbidden    = "forbidden"
+	apiErrNotFound     = "not_found"
+	apiErrUpstream     = "upstream_error"
+)
+
+// apiResponse est l'enveloppe des réponses de l'API
+type apiResponse struct {
+	Data interface{} `json:"data"`
+	Meta *apiMeta    `json:"meta,omitempty"`
+}
+
+// apiMeta contient les métadonnées d'une réponse
+type apiMeta struct {
+	Pagination *apiPagination `json:"pagination,omitempty"`
+	// Favorites liste les clés (type:id) des éléments renvoyés qui sont en favoris
+	Favorites []string `json:"favorites,omitempty"`
+}
+
+// apiPagination décrit la page renvoyée d'une liste
+type apiPagination struct {
+	Page       int  `json:"page"`
+	Limit      int  `json:"limit"`
+	Total      int  `json:"total"`
+	TotalPages int  `json:"total_pages"`
+	HasPrev    bool `json:"has_prev"`
+	HasNext    bool `json:"has_next"`
+}
+
+// apiErrorResponse est l'enveloppe des erreurs de l'API
+type apiErrorResponse struct {
+	Error apiError `json:"error"`
+}
+
+// apiError décrit une erreur : code HTTP, code symbolique et message
+type apiError struct {
+	Status  int    `json:"status"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// apiSearchData contient les résultats d'une recherche par type
+type apiSearchData struct {
+	Query   string           `json:"query"`
+	Artists []spotify.Artist `json:"artists"`
+	Albums  []spotify.Album  `json:"albums"`
+	Tracks  []spotify.Track  `json:"tracks"`
+	// Totals donne le nombre total de résultats de chaque type recherché
+	Totals map[string]int `json:"totals"`
+}
+
+// apiPaginationFrom convertit la pagination des pages HTML
+func apiPaginationFrom(p *PaginationData) *apiPagination {
+	return &apiPagination{
+		Page:       p.CurrentPage,
+		Limit:      p.Limit,
+		Total:      p.TotalItems,
+		TotalPages: p.TotalPages,
+		HasPrev:    p.HasPrev,
+		HasNext:    p.HasNext,
+	}
+}
+
+// writeAPI écrit une réponse réussie de l'API
+func writeAPI(w http.ResponseWriter, data interface{}, meta *apiMeta) {
+	writeJSON(w, http.StatusOK, apiResponse{Data: data, Meta: meta})
+}
+
+// writeAPIError écrit une erreur structurée de l'API
+func writeAPIError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, apiErrorResponse{Error: apiError{Status: status, Code: code, Message: message}})
+}
+
+// writeSpotifyAPIError traduit une erreur de Spotify : 404 si l'élément
+// n'existe pas, 502 pour les autres erreurs
+func writeSpotifyAPIError(w http.ResponseWriter, err error) {
+	if enrich.UnavailableReason(err) != "" {
+		writeAPIError(w, http.StatusNotFound, apiErrNotFound, "Élément introuvable sur Spotify")
+		return
+	}
+	writeAPIError(w, http.StatusBadGateway, apiErrUpstream, "Erreur de Spotify : "+err.Error())
+}
+
+// errInvalidPage est renvoyée pour des paramètres de pagination invalides
+var errInvalidPage = errors.New("paramètres de pagination invalides")
+
+// parseAPIPage lit les paramètres limit (1 à maxAPILimit) et page (à partir
+// de 1). Contrairement aux pages HTML, les valeurs invalides sont refusées.
+func parseAPIPage(query url.Values) (limit, page int, err error) {
+	limit, page = defaultPageLimit, 1
+	if value := query.Get("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil {
+			limit = 0
+		}
+	}
+	if value := query.Get("page"); value != "" {
+		if page, err = strconv.Atoi(value); err != nil {
+			page = 0
+		}
+	}
+	if err := checkAPIPage(limit, page); err != nil {
+		return 0, 0, err
+	}
+	return limit, page, nil
+}
+
+// checkAPIPage vérifie des paramètres de pagination déjà lus
+func checkAPIPage(limit, page int) error {
+	if limit < 1 || limit > maxAPILimit {
+		return fmt.Errorf("%w : limit doit être compris entre 1 et %d", errInvalidPage, maxAPILimit)
+	}
+	if page < 1 {
+		return fmt.Errorf("%w : page doit être un entier positif", errInvalidPage)
+	}
+	return nil
+}
+
+// parseSearchTypes valide les types de recherche ; chaque valeur peut
+// contenir plusieurs types séparés par des virgules
+func parseSearchTypes(values []string) ([]string, error) {
+	var types []string
+	for _, value := range values {
+		for _, t := range strings.Split(value, ",") {
+			switch t = strings.TrimSpace(t); t {
+			case "artist", "album", "track":
+				types = append(types, t)
+			default:
+				return nil, fmt.Errorf("Type de recherche invalide : %q", t)
+			}
+		}
+	}
+	if len(types) == 0 {
+		types = defaultSearchTypes
+	}
+	return types, nil
+}
+
+// newAPISearchData regroupe les résultats d'une recherche et renvoie le
+// total sur lequel porte la pagination : celui du type qui compte le plus
+// de résultats
+func newAPISearchData(query string, types []string, results *spotify.SearchResults) (apiSearchData, int) {
+	data := apiSearchData{
+		Query:   query,
+		Artists: results.Artists.Items,
+		Albums:  results.Albums.Items,
+		Tracks:  results.Tracks.Items,
+		Totals:  make(map[string]int, len(types)),
+	}
+	total := 0
+	for _, t := range types {
+		switch t {
+		case "artist":
+			data.Totals[t] = results.Artists.Total
+		case "album":
+			data.Totals[t] = results.Albums.Total
+		case "track":
+			data.Totals[t] = results.Tracks.Total
+		}
+		total = max(total, data.Totals[t])
+	}
+	return data, total
+}
+
+// listFavorites renvoie la page demandée des favoris du type donné (tous
+// les types s'il est vide) qui correspondent aux filtres
+func (s *Server) listFavorites(filter favoritesFilter, itemType models.FavoriteType, limit, page int) ([]models.FavoriteItem, *PaginationData) {
+	favorites := filter.apply(s.FavoritesStorage.GetAll())
+	items := make([]models.FavoriteItem, 0, len(favorites))
+	for _, item := range favorites {
+		if itemType == "" || item.Type == itemType {
+			items = append(items, item)
+		}
+	}
+
+	start := min((page-1)*limit, len(items))
+	end := min(start+limit, len(items))
+	return items[start:end], newPagination(page, limit, len(items))
+}
+
+// apiAuth vérifie que l'utilisateur est connecté ou authentifié par un
+// jeton d'accès, et écrit l'erreur sinon
+func (s *Server) apiAuth(w http.ResponseWriter, r *http.Request) bool {
+	if !s.isAuthenticated(r) {
+		writeAPIError(w, http.StatusUnauthorized, apiErrUnauthorized, "Non autorisé")
+		return false
+	}
+	return true
+}
+
+// favoriteMeta renvoie les métadonnées listant les clés en favoris
+func (s *Server) favoriteMeta(keys []models.FavoriteKey) *apiMeta {
+	meta := &apiMeta{}
+	for key, isFavorite := range s.FavoritesStorage.ContainsMany(keys) {
+		if isFavorite {
+			meta.Favorites = append(meta.Favorites, key)
+		}
+	}
+	sort.Strings(meta.Favorites)
+	return meta
+}
+
+// APISearchHandler gère GET /api/v1/search?q=&type=&limit=&page=. Le
+// paramètre type peut être répété ou contenir plusieurs types séparés par
+// des virgules.
+func (s *Server) APISearchHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.apiAuth(w, r) {
+		return
+	}
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		writeAPIError(w, http.StatusBadRequest, apiErrBadRequest, "Le paramètre q est obligatoire")
+		return
+	}
+	limit, page, err := parseAPIPage(r.URL.Query())
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, apiErrBadRequest, err.Error())
+		return
+	}
+
+	types, err := parseSearchTypes(r.URL.Query()["type"])
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, apiErrBadRequest, err.Error())
+		return
+	}
+
+	results, err := s.searchCatalog(query, types, limit, page)
+	if err != nil {
+		writeSpotifyAPIError(w, err)
+		return
+	}
+
+	data, total := newAPISearchData(query, types, results)
+	meta := s.favoriteMeta(favoriteKeys(results))
+	meta.Pagination = apiPaginationFrom(newPagination(page, limit, total))
+	writeAPI(w, data, meta)
+}
+
+// APIArtistHandler gère GET /api/v1/artists/{id}
+func (s *Server) APIArtistHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.apiAuth(w, r) {
+		return
+	}
+
+	artist, err := s.fetchArtist(mux.Vars(r)["id"])
+	if err != nil {
+		writeSpotifyAPIError(w, err)
+		return
+	}
+	writeAPI(w, artist, s.favoriteMeta([]models.FavoriteKey{{Type: models.FavoriteTypeArtist, ID: artist.ID}}))
+}
+
+// APIArtistAlbumsHandler gère GET /api/v1/artists/{id}/albums
+func (s *Server) APIArtistAlbumsHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.apiAuth(w, r) {
+		return
+	}
+	limit, page, err := parseAPIPage(r.URL.Query())
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, apiErrBadRequest, err.Error())
+		return
+	}
+
+	albums, err := s.SpotifyClient.GetArtistAlbums(mux.Vars(r)["id"], limit, (page-1)*limit)
+	if err != nil {
+		writeSpotifyAPIError(w, err)
+		return
+	}
+
+	keys := make([]models.FavoriteKey, 0, len(albums.Items))
+	for _, album := range albums.Items {
+		keys = append(keys, models.FavoriteKey{Type: models.FavoriteTypeAlbum, ID: album.ID})
+	}
+	meta := s.favoriteMeta(keys)
+	meta.Pagination = apiPaginationFrom(newPagination(page, limit, albums.Total))
+	writeAPI(w, albums.Items, meta)
+}
+
+// APIAlbumHandler gère GET /api/v1/albums/{id}. Le label de l'album figure
+// dans les favoris de la réponse s'il est suivi.
+func (s *Server) APIAlbumHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.apiAuth(w, r) {
+		return
+	}
+
+	album, err := s.fetchAlbum(mux.Vars(r)["id"])
+	if err != nil {
+		writeSpotifyAPIError(w, err)
+		return
+	}
+
+	keys := []models.FavoriteKey{{Type: models.FavoriteTypeAlbum, ID: album.ID}}
+	if album.Label != "" {
+		keys = append(keys, models.FavoriteKey{Type: models.FavoriteTypeLabel, ID: models.LabelFavoriteID(album.Label)})
+	}
+	writeAPI(w, album, s.favoriteMeta(keys))
+}
+
+// APIAlbumTracksHandler gère GET /api/v1/albums/{id}/tracks
+func (s *Server) APIAlbumTracksHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.apiAuth(w, r) {
+		return
+	}
+	limit, page, err := parseAPIPage(r.URL.Query())
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, apiErrBadRequest, err.Error())
+		return
+	}
+
+	tracks, err := s.SpotifyClient.GetAlbumTracks(mux.Vars(r)["id"], limit, (page-1)*limit)
+	if err != nil {
+		writeSpotifyAPIError(w, err)
+		return
+	}
+
+	meta := s.favoriteMeta(favoriteKeys(&spotify.SearchResults{Tracks: tracks}))
+	meta.Pagination = apiPaginationFrom(newPagination(page, limit, tracks.Total))
+	writeAPI(w, tracks.Items, meta)
+}
+
+// APITrackHandler gère GET /api/v1/tracks/{id}
+func (s *Server) APITrackHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.apiAuth(w, r) {
+		return
+	}
+
+	track, err := s.fetchTrack(mux.Vars(r)["id"])
+	if err != nil {
+		writeSpotifyAPIError(w, err)
+		return
+	}
+	writeAPI(w, track, s.favoriteMeta([]models.FavoriteKey{{Type: models.FavoriteTypeTrack, ID: track.ID}}))
+}
+
+// APICategoryHandler gère GET /api/v1/categories/{genre} : les pistes du
+// genre, avec la même pagination que la page /category
+func (s *Server) APICategoryHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.apiAuth(w, r) {
+		return
+	}
+	limit, page, err := parseAPIPage(r.URL.Query())
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, apiErrBadRequest, err.Error())
+		return
+	}
+
+	genre := mux.Vars(r)["genre"]
+	results, pagination, err := s.searchCategory(genre, limit, page)
+	if err != nil {
+		writeSpotifyAPIError(w, err)
+		return
+	}
+
+	tracks := []spotify.Track{}
+	if results.Tracks != nil {
+		tracks = results.Tracks.Items
+	}
+	keys := append(favoriteKeys(results), models.FavoriteKey{Type: models.FavoriteTypeCategory, ID: genre})
+	meta := s.favoriteMeta(keys)
+	meta.Pagination = apiPaginationFrom(pagination)
+	writeAPI(w, tracks, meta)
+}
+
+// APIFavoritesHandler gère GET /api/v1/favorites, avec les filtres de la
+// page des favoris (genre, year, artist, tag) et un filtre type
+func (s *Server) APIFavoritesHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.apiAuth(w, r) {
+		return
+	}
+	limit, page, err := parseAPIPage(r.URL.Query())
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, apiErrBadRequest, err.Error())
+		return
+	}
+
+	var itemType models.FavoriteType
+	if value := r.URL.Query().Get("type"); value != "" {
+		var ok bool
+		if itemType, ok = models.ParseFavoriteType(value); !ok {
+			writeAPIError(w, http.StatusBadRequest, apiErrBadRequest, fmt.Sprintf("Type de favori invalide : %q", value))
+			return
+		}
+	}
+
+	items, pagination := s.listFavorites(parseFavoritesFilter(r.URL.Query()), itemType, limit, page)
+	writeAPI(w, items, &apiMeta{Pagination: apiPaginationFrom(pagination)})
+}
+
+// APINotFoundHandler répond aux routes inconnues de l'API par une erreur structurée
+func (s *Server) APINotFoundHandler(w http.ResponseWriter, r *http.Request) {
+	writeAPIError(w, http.StatusNotFound, apiErrNotFound, "Route inconnue : "+r.Method+" "+r.URL.Path)
+}
@@ -0,0 +1,393 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+	"github.com/yourusername/melody-explorer/internal/history"
+	"github.com/yourusername/melody-explorer/internal/models"
+	"github.com/yourusername/melody-explorer/internal/releases"
+	"github.com/yourusername/melody-explorer/internal/storage"
+	"github.com/yourusername/melody-explorer/internal/webhook"
+)
+
+// Webhooks sortants : les outils externes abonnés reçoivent les ajouts et
+// suppressions de favoris, les modifications des listes et les nouvelles
+// sorties des artistes favoris (voir le paquet webhook).
+
+// webhookRequest est le corps de la création et de la modification d'un
+// webhook ; en modification, seuls les champs présents sont modifiés
+type webhookRequest struct {
+	URL         *string   `json:"url"`
+	Description *string   `json:"description"`
+	Events      *[]string `json:"events"`
+	Active      *bool     `json:"active"`
+}
+
+// webhooksResponse est la réponse de GET /api/webhooks
+type webhooksResponse struct {
+	Success  bool                  `json:"success"`
+	Webhooks []models.Webhook      `json:"webhooks"`
+	Events   []models.WebhookEvent `json:"events"`
+}
+
+// webhookResponse renvoie un webhook ; le secret de signature n'est présent
+// qu'à la création
+type webhookResponse struct {
+	Success bool           `json:"success"`
+	Webhook models.Webhook `json:"webhook"`
+	Secret  string         `json:"secret,omitempty"`
+}
+
+// deliveryResponse renvoie une notification mise en file
+type deliveryResponse struct {
+	Success  bool                   `json:"success"`
+	Delivery models.WebhookDelivery `json:"delivery"`
+}
+
+// deliveriesResponse est la réponse de GET /api/webhooks/{id}/deliveries
+type deliveriesResponse struct {
+	Success    bool                     `json:"success"`
+	Deliveries []models.WebhookDelivery `json:"deliveries"`
+}
+
+// favoriteWebhookData est la donnée des événements favorite.added et favorite.removed
+type favoriteWebhookData struct {
+	Type  models.FavoriteType  `json:"type"`
+	ID    string               `json:"id"`
+	Name  string               `json:"name"`
+	Actor string               `json:"actor"`
+	Item  *models.FavoriteItem `json:"item"`
+}
+
+// publishWebhook met en file un événement pour les webhooks abonnés
+func (s *Server) publishWebhook(event models.WebhookEvent, data interface{}) {
+	s.publishWebhooks([]webhook.Message{{Event: event, Data: data}})
+}
+
+// publishWebhooks met en file plusieurs événements en une seule écriture
+func (s *Server) publishWebhooks(messages []webhook.Message) {
+	if s.Webhooks == nil || len(messages) == 0 {
+		return
+	}
+	if _, err := s.Webhooks.PublishAll(messages); err != nil {
+		log.Printf("Erreur lors de la mise en file de %d événements pour les webhooks: %v", len(messages), err)
+	}
+}
+
+// webhookFavoriteChanges transmet les ajouts et suppressions de favoris
+// enregistrés dans l'historique ; les modifications ne sont pas transmises.
+// Elle est appelée sous le verrou d'écriture des favoris : les événements
+// d'une transaction sont mis en file en une seule écriture.
+func (s *Server) webhookFavoriteChanges(entries []history.Entry) {
+	var messages []webhook.Message
+	for _, entry := range entries {
+		data := favoriteWebhookData{Type: entry.Type, ID: entry.ID, Name: entry.Name, Actor: entry.Actor}
+		switch entry.Action {
+		case history.ActionAdd:
+			data.Item = entry.New
+			messages = append(messages, webhook.Message{Event: models.WebhookFavoriteAdded, Data: data})
+		case history.ActionRemove:
+			data.Item = entry.Old
+			messages = append(messages, webhook.Message{Event: models.WebhookFavoriteRemoved, Data: data})
+		}
+	}
+	s.publishWebhooks(messages)
+}
+
+// webhookListChanges transmet les modifications des listes
+func (s *Server) webhookListChanges(changes []storage.ListChange) {
+	messages := make([]webhook.Message, 0, len(changes))
+	for _, change := range changes {
+		messages = append(messages, webhook.Message{Event: models.WebhookListChanged, Data: change})
+	}
+	s.publishWebhooks(messages)
+}
+
+// webhookRelease transmet une nouvelle sortie d'un artiste favori
+func (s *Server) webhookRelease(release releases.Release) {
+	s.publishWebhook(models.WebhookReleaseNew, release)
+}
+
+// validateWebhookURL vérifie l'adresse de destination d'un webhook
+func validateWebhookURL(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	parsed, err := url.Parse(value)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", inputError("Adresse invalide : une URL http ou https absolue est attendue")
+	}
+	if len(value) > 2000 {
+		return "", inputError("Adresse trop longue")
+	}
+	return value, nil
+}
+
+// parseWebhookEvents valide les événements d'un abonnement
+func parseWebhookEvents(values []string) ([]models.WebhookEvent, error) {
+	events := make([]models.WebhookEvent, 0, len(values))
+	seen := make(map[models.WebhookEvent]bool, len(values))
+	for _, value := range values {
+		event, ok := models.ParseWebhookEvent(strings.TrimSpace(value))
+		if !ok {
+			return nil, inputError("Événement inconnu : " + value)
+		}
+		if !seen[event] {
+			seen[event] = true
+			events = append(events, event)
+		}
+	}
+	if len(events) == 0 {
+		return nil, inputError("Au moins un événement est requis")
+	}
+	return events, nil
+}
+
+// validateWebhookDescription vérifie la description d'un webhook
+func validateWebhookDescription(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if utf8.RuneCountInString(value) > 200 {
+		return "", inputError("La description ne doit pas dépasser 200 caractères")
+	}
+	return value, nil
+}
+
+// writeWebhookInputError écrit une erreur de validation, ou une erreur
+// interne pour les autres erreurs
+func writeWebhookInputError(w http.ResponseWriter, err error) {
+	var invalid inputError
+	if errors.As(err, &invalid) {
+		writeJSONError(w, http.StatusBadRequest, invalid.Error())
+		return
+	}
+	if errors.Is(err, storage.ErrWebhookNotFound) {
+		writeJSONError(w, http.StatusNotFound, "Webhook introuvable")
+		return
+	}
+	writeJSONError(w, http.StatusInternalServerError, "Échec lors de l'enregistrement du webhook: "+err.Error())
+	log.Printf("Erreur lors de l'enregistrement d'un webhook: %v", err)
+}
+
+// ListWebhooksHandler renvoie les webhooks, sans leur secret
+func (s *Server) ListWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.SpotifyAuth.IsTokenValid() {
+		writeJSONError(w, http.StatusUnauthorized, "Non autorisé")
+		return
+	}
+
+	webhooks := s.WebhooksStorage.All()
+	for i := range webhooks {
+		webhooks[i] = webhooks[i].Public()
+	}
+	writeJSON(w, http.StatusOK, webhooksResponse{Success: true, Webhooks: webhooks, Events: models.WebhookEvents})
+}
+
+// CreateWebhookHandler crée un webhook. Le secret de signature figure
+// uniquement dans cette réponse.
+func (s *Server) CreateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.SpotifyAuth.IsTokenValid() {
+		writeJSONError(w, http.StatusUnauthorized, "Non autorisé")
+		return
+	}
+
+	var req webhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Corps de requête invalide")
+		return
+	}
+	if req.URL == nil {
+		writeJSONError(w, http.StatusBadRequest, "L'adresse du webhook est obligatoire")
+		return
+	}
+	if req.Events == nil {
+		writeJSONError(w, http.StatusBadRequest, "Au moins un événement est requis")
+		return
+	}
+
+	target, err := validateWebhookURL(*req.URL)
+	if err != nil {
+		writeWebhookInputError(w, err)
+		return
+	}
+	events, err := parseWebhookEvents(*req.Events)
+	if err != nil {
+		writeWebhookInputError(w, err)
+		return
+	}
+	var description string
+	if req.Description != nil {
+		if description, err = validateWebhookDescription(*req.Description); err != nil {
+			writeWebhookInputError(w, err)
+			return
+		}
+	}
+
+	webhook, err := models.NewWebhook(target, description, events)
+	if err == nil {
+		if req.Active != nil {
+			webhook.Active = *req.Active
+		}
+		err = s.WebhooksStorage.Create(webhook)
+	}
+	if err != nil {
+		writeWebhookInputError(w, err)
+		return
+	}
+
+	log.Printf("Webhook créé: %s (%s)", webhook.URL, webhook.ID)
+
+	writeJSON(w, http.StatusCreated, webhookResponse{Success: true, Webhook: webhook.Public(), Secret: webhook.Secret})
+}
+
+// UpdateWebhookHandler modifie l'adresse, la description, les événements
+// ou l'activation d'un webhook
+func (s *Server) UpdateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.SpotifyAuth.IsTokenValid() {
+		writeJSONError(w, http.StatusUnauthorized, "Non autorisé")
+		return
+	}
+
+	var req webhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Corps de requête invalide")
+		return
+	}
+
+	webhook, err := s.WebhooksStorage.UpdateWebhook(mux.Vars(r)["id"], func(webhook *models.Webhook) error {
+		if req.URL != nil {
+			target, err := validateWebhookURL(*req.URL)
+			if err != nil {
+				return err
+			}
+			webhook.URL = target
+		}
+		if req.Description != nil {
+			description, err := validateWebhookDescription(*req.Description)
+			if err != nil {
+				return err
+			}
+			webhook.Description = description
+		}
+		if req.Events != nil {
+			events, err := parseWebhookEvents(*req.Events)
+			if err != nil {
+				return err
+			}
+			webhook.Events = events
+		}
+		if req.Active != nil {
+			webhook.Active = *req.Active
+		}
+		return nil
+	})
+	if err != nil {
+		writeWebhookInputError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, webhookResponse{Success: true, Webhook: webhook.Public()})
+}
+
+// DeleteWebhookHandler supprime un webhook et ses notifications
+func (s *Server) DeleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.SpotifyAuth.IsTokenValid() {
+		writeJSONError(w, http.StatusUnauthorized, "Non autorisé")
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	if err := s.WebhooksStorage.Delete(id); err != nil {
+		writeWebhookInputError(w, err)
+		return
+	}
+
+	log.Printf("Webhook supprimé: %s", id)
+	writeJSON(w, http.StatusOK, successResponse{Success: true})
+}
+
+// PingWebhookHandler envoie un événement ping pour tester un webhook
+func (s *Server) PingWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.SpotifyAuth.IsTokenValid() {
+		writeJSONError(w, http.StatusUnauthorized, "Non autorisé")
+		return
+	}
+
+	delivery, err := s.Webhooks.Ping(mux.Vars(r)["id"])
+	if err != nil {
+		writeWebhookInputError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, deliveryResponse{Success: true, Delivery: delivery})
+}
+
+// WebhookDeliveriesHandler renvoie le journal des notifications d'un
+// webhook, les plus récentes d'abord, avec les codes de réponse
+func (s *Server) WebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.SpotifyAuth.IsTokenValid() {
+		writeJSONError(w, http.StatusUnauthorized, "Non autorisé")
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	if _, ok := s.WebhooksStorage.Get(id); !ok {
+		writeJSONError(w, http.StatusNotFound, "Webhook introuvable")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, deliveriesResponse{Success: true, Deliveries: s.WebhooksStorage.Deliveries(id)})
+}
+
+// RedeliverWebhookHandler renvoie une notification : une nouvelle
+// notification, avec le même contenu, est mise en file
+func (s *Server) RedeliverWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.SpotifyAuth.IsTokenValid() {
+		writeJSONError(w, http.StatusUnauthorized, "Non autorisé")
+		return
+	}
+
+	vars := mux.Vars(r)
+	original, ok := s.WebhooksStorage.Delivery(vars["delivery"])
+	if !ok || original.WebhookID != vars["id"] {
+		writeJSONError(w, http.StatusNotFound, "Notification introuvable")
+		return
+	}
+
+	delivery, err := s.Webhooks.Redeliver(original.ID)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Échec lors du renvoi de la notification: "+err.Error())
+		log.Printf("Erreur lors du renvoi de la notification %s: %v", original.ID, err)
+		return
+	}
+
+	log.Printf("Notification %s renvoyée (%s)", original.ID, delivery.ID)
+	writeJSON(w, http.StatusAccepted, deliveryResponse{Success: true, Delivery: delivery})
+}
+
+// CheckReleasesHandler recherche immédiatement les nouvelles sorties des
+// artistes favoris et renvoie celles qui ont été détectées
+func (s *Server) CheckReleasesHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+
+	found, err := s.Releases.CheckAll()
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Échec de la recherche de nouvelles sorties: "+err.Error())
+		log.Printf("Erreur lors de la recherche de nouvelles sorties: %v", err)
+		return
+	}
+	if found == nil {
+		found = []releases.Release{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success":  true,
+		"releases": found,
+	})
+}
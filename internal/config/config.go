@@ -0,0 +1,164 @@
+package config
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config regroupe les paramètres de l'application lus depuis l'environnement
+type Config struct {
+	Port         string
+	TemplatesDir string
+	StaticDir    string
+	DataDir      string
+
+	// FavoritesBackend choisit le stockage des favoris : "json" ou "log"
+	FavoritesBackend string
+	// FavoritesBackups est le nombre de sauvegardes tournantes conservées pour favorites.json
+	FavoritesBackups int
+	// FavoritesCompactEvery et FavoritesCompactInterval règlent la compaction du journal
+	FavoritesCompactEvery    int
+	FavoritesCompactInterval time.Duration
+	// FavoritesWatchInterval est la période de détection des modifications externes
+	FavoritesWatchInterval time.Duration
+
+	// SpotifyRateLimit est le nombre maximal de requêtes par seconde vers Spotify
+	SpotifyRateLimit float64
+	// RefreshInterval est la période du rafraîchissement des favoris (0 pour désactiver)
+	RefreshInterval time.Duration
+	// RefreshStaleAfter est l'âge à partir duquel un favori est rafraîchi
+	RefreshStaleAfter time.Duration
+	// SmartListsInterval est la période de réévaluation des listes automatiques (0 pour désactiver)
+	SmartListsInterval time.Duration
+	// ReleasesInterval est la période de la recherche de nouvelles sorties des artistes favoris (0 pour désactiver)
+	ReleasesInterval time.Duration
+	// WebhookTimeout est le délai de réponse accordé aux destinataires des webhooks
+	WebhookTimeout time.Duration
+	// AdminToken protège les points de terminaison d'administration s'il est défini
+	AdminToken string
+
+	// Middlewares est la chaîne des middlewares HTTP, du plus extérieur au plus intérieur
+	Middlewares []string
+	// CORSAllowedOrigins liste les origines autorisées à appeler le serveur depuis un navigateur
+	CORSAllowedOrigins []string
+	// CORSMaxAge est la durée de mise en cache des requêtes préliminaires CORS
+	CORSMaxAge time.Duration
+	// StaticCacheMaxAge est la durée de mise en cache des fichiers statiques (0 pour désactiver)
+	StaticCacheMaxAge time.Duration
+}
+
+// Load construit la configuration à partir des variables d'environnement,
+// les chemins relatifs étant résolus depuis le répertoire racine fourni
+func Load(rootDir string) *Config {
+	return &Config{
+		Port:             getEnv("PORT", "8080"),
+		TemplatesDir:     getPath(rootDir, "TEMPLATES_DIR", "templates"),
+		StaticDir:        getPath(rootDir, "STATIC_DIR", "static"),
+		DataDir:          getPath(rootDir, "DATA_DIR", "data"),
+		FavoritesBackend: getEnv("FAVORITES_BACKEND", "json"),
+		FavoritesBackups: getEnvInt("FAVORITES_BACKUPS", 5),
+
+		FavoritesCompactEvery:    getEnvInt("FAVORITES_COMPACT_EVERY", 500),
+		FavoritesCompactInterval: getEnvDuration("FAVORITES_COMPACT_INTERVAL", 10*time.Minute),
+		FavoritesWatchInterval:   getEnvDuration("FAVORITES_WATCH_INTERVAL", 2*time.Second),
+
+		SpotifyRateLimit:   getEnvFloat("SPOTIFY_RATE_LIMIT", 5),
+		RefreshInterval:    getEnvDuration("REFRESH_INTERVAL", 6*time.Hour),
+		RefreshStaleAfter:  getEnvDuration("REFRESH_STALE_AFTER", 24*time.Hour),
+		SmartListsInterval: getEnvDuration("SMART_LISTS_INTERVAL", time.Hour),
+		ReleasesInterval:   getEnvDuration("RELEASES_INTERVAL", 12*time.Hour),
+		WebhookTimeout:     getEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+		AdminToken:         getEnv("ADMIN_TOKEN", ""),
+
+		Middlewares:        getEnvList("MIDDLEWARES", []string{"logging", "recover", "cors", "cache"}),
+		CORSAllowedOrigins: getEnvList("CORS_ALLOWED_ORIGINS", nil),
+		CORSMaxAge:         getEnvDuration("CORS_MAX_AGE", 10*time.Minute),
+		StaticCacheMaxAge:  getEnvDuration("STATIC_CACHE_MAX_AGE", 24*time.Hour),
+	}
+}
+
+// getEnv renvoie la valeur d'une variable d'environnement ou la valeur par défaut
+func getEnv(key, fallback string) string {
+	if value := strings.TrimSpace(os.Getenv(key)); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// getPath renvoie un chemin absolu depuis une variable d'environnement ou la valeur par défaut
+func getPath(rootDir, key, fallback string) string {
+	path := getEnv(key, fallback)
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(rootDir, path)
+}
+
+// getEnvList renvoie les valeurs séparées par des virgules d'une variable
+// d'environnement ou la valeur par défaut ; "none" donne une liste vide
+func getEnvList(key string, fallback []string) []string {
+	value := getEnv(key, "")
+	if value == "" {
+		return fallback
+	}
+	if value == "none" {
+		return nil
+	}
+
+	var values []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
+
+// getEnvInt renvoie la valeur entière d'une variable d'environnement ou la valeur par défaut
+func getEnvInt(key string, fallback int) int {
+	value := getEnv(key, "")
+	if value == "" {
+		return fallback
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Valeur invalide pour %s (%q), utilisation de %d", key, value, fallback)
+		return fallback
+	}
+	return parsed
+}
+
+// getEnvFloat renvoie la valeur décimale d'une variable d'environnement ou la valeur par défaut
+func getEnvFloat(key string, fallback float64) float64 {
+	value := getEnv(key, "")
+	if value == "" {
+		return fallback
+	}
+
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("Valeur invalide pour %s (%q), utilisation de %g", key, value, fallback)
+		return fallback
+	}
+	return parsed
+}
+
+// getEnvDuration renvoie la durée d'une variable d'environnement ou la valeur par défaut
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value := getEnv(key, "")
+	if value == "" {
+		return fallback
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Durée invalide pour %s (%q), utilisation de %s", key, value, fallback)
+		return fallback
+	}
+	return parsed
+}
@@ -0,0 +1,58 @@
+package enrich
+
+import (
+	"errors"
+	"time"
+
+	"github.com/yourusername/melody-explorer/internal/models"
+	"github.com/yourusername/melody-explorer/internal/spotify"
+	"github.com/yourusername/melody-explorer/internal/storage"
+)
+
+// ErrEmptyObject est renvoyée lorsque Spotify répond avec un objet vide
+var ErrEmptyObject = errors.New("objet vide renvoyé par Spotify")
+
+// UnavailableReason indique si une erreur de récupération signifie que
+// l'élément n'existe plus sur Spotify, et pour quelle raison. Les erreurs
+// transitoires (réseau, limite de débit, authentification) renvoient "".
+func UnavailableReason(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case spotify.IsNotFound(err):
+		return models.UnavailableNotFound
+	case errors.Is(err, ErrEmptyObject):
+		return models.UnavailableEmpty
+	default:
+		return ""
+	}
+}
+
+// MarkUnavailable signale un favori comme indisponible. La date du premier
+// signalement est conservée ; les éléments absents des favoris sont ignorés.
+func MarkUnavailable(store storage.FavoritesStore, itemType models.FavoriteType, id, reason string) error {
+	return store.Update(func(tx *storage.Tx) error {
+		markUnavailable(tx, itemType, id, reason, time.Now())
+		return nil
+	})
+}
+
+// markUnavailable signale un favori comme indisponible dans une transaction
+// et indique si l'élément a été modifié
+func markUnavailable(tx *storage.Tx, itemType models.FavoriteType, id, reason string, now time.Time) bool {
+	item, ok := tx.Get(id, itemType)
+	if !ok {
+		return false
+	}
+	if item.Unavailable != nil && item.Unavailable.Reason == reason {
+		return false
+	}
+
+	since := now
+	if item.Unavailable != nil {
+		since = item.Unavailable.Since
+	}
+	item.Unavailable = &models.Unavailability{Reason: reason, Since: since}
+	tx.Add(item)
+	return true
+}
@@ -0,0 +1,153 @@
+package enrich
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/yourusername/melody-explorer/internal/models"
+	"github.com/yourusername/melody-explorer/internal/spotify"
+)
+
+// Snapshot regroupe les informations actuelles d'un élément sur Spotify
+type Snapshot struct {
+	Name     string
+	ImageURL string
+	Metadata *models.FavoriteMetadata
+}
+
+// ApplyTo met à jour le nom, l'image et les métadonnées d'un favori, et lève
+// son éventuel signalement d'indisponibilité.
+// Le nom et l'image ne sont remplacés que si Spotify en fournit.
+func (s Snapshot) ApplyTo(item *models.FavoriteItem) {
+	if s.Name != "" {
+		item.Name = s.Name
+	}
+	if s.ImageURL != "" {
+		item.ImageURL = s.ImageURL
+	}
+	item.Metadata = s.Metadata
+	item.Unavailable = nil
+}
+
+// Fetch récupère depuis Spotify l'instantané d'un élément
+func Fetch(client *spotify.Client, itemType models.FavoriteType, id string) (Snapshot, error) {
+	switch itemType {
+	case models.FavoriteTypeArtist:
+		artist, err := client.GetArtist(id)
+		if err != nil {
+			return Snapshot{}, err
+		}
+		if artist.ID == "" {
+			return Snapshot{}, ErrEmptyObject
+		}
+		return ArtistSnapshot(artist), nil
+	case models.FavoriteTypeAlbum:
+		album, err := client.GetAlbum(id)
+		if err != nil {
+			return Snapshot{}, err
+		}
+		if album.ID == "" {
+			return Snapshot{}, ErrEmptyObject
+		}
+		return AlbumSnapshot(album), nil
+	case models.FavoriteTypeTrack:
+		track, err := client.GetTrack(id)
+		if err != nil {
+			return Snapshot{}, err
+		}
+		if track.ID == "" {
+			return Snapshot{}, ErrEmptyObject
+		}
+		return TrackSnapshot(track), nil
+	case models.FavoriteTypePlaylist:
+		playlist, err := client.GetPlaylist(id)
+		if err != nil {
+			return Snapshot{}, err
+		}
+		if playlist.ID == "" {
+			return Snapshot{}, ErrEmptyObject
+		}
+		return PlaylistSnapshot(playlist), nil
+	default:
+		return Snapshot{}, fmt.Errorf("type de favori sans métadonnées : %s", itemType)
+	}
+}
+
+// ArtistSnapshot construit l'instantané d'un artiste
+func ArtistSnapshot(artist *spotify.Artist) Snapshot {
+	return Snapshot{
+		Name:     artist.Name,
+		ImageURL: primaryImage(artist.Images),
+		Metadata: &models.FavoriteMetadata{
+			Genres:     artist.Genres,
+			Popularity: artist.Popularity,
+			Followers:  artist.Followers.Total,
+			FetchedAt:  time.Now(),
+		},
+	}
+}
+
+// AlbumSnapshot construit l'instantané d'un album
+func AlbumSnapshot(album *spotify.Album) Snapshot {
+	return Snapshot{
+		Name:     album.Name,
+		ImageURL: primaryImage(album.Images),
+		Metadata: &models.FavoriteMetadata{
+			Genres:      album.Genres,
+			Popularity:  album.Popularity,
+			Artists:     artistRefs(album.Artists),
+			ReleaseDate: album.ReleaseDate,
+			Label:       album.Label,
+			TrackCount:  album.TotalTracks,
+			FetchedAt:   time.Now(),
+		},
+	}
+}
+
+// TrackSnapshot construit l'instantané d'une piste
+func TrackSnapshot(track *spotify.Track) Snapshot {
+	return Snapshot{
+		Name:     track.Name,
+		ImageURL: primaryImage(track.Album.Images),
+		Metadata: &models.FavoriteMetadata{
+			Popularity:  track.Popularity,
+			Artists:     artistRefs(track.Artists),
+			ReleaseDate: track.Album.ReleaseDate,
+			Album:       &models.FavoriteRef{ID: track.Album.ID, Name: track.Album.Name},
+			DurationMs:  track.Duration,
+			Explicit:    track.Explicit,
+			FetchedAt:   time.Now(),
+		},
+	}
+}
+
+// PlaylistSnapshot construit l'instantané d'une playlist
+func PlaylistSnapshot(playlist *spotify.Playlist) Snapshot {
+	return Snapshot{
+		Name:     playlist.Name,
+		ImageURL: primaryImage(playlist.Images),
+		Metadata: &models.FavoriteMetadata{
+			Followers:  playlist.Followers.Total,
+			TrackCount: playlist.Tracks.Total,
+			Owner:      playlist.Owner.DisplayName,
+			FetchedAt:  time.Now(),
+		},
+	}
+}
+
+// artistRefs convertit des artistes Spotify en références
+func artistRefs(artists []spotify.Artist) []models.FavoriteRef {
+	refs := make([]models.FavoriteRef, 0, len(artists))
+	for _, artist := range artists {
+		refs = append(refs, models.FavoriteRef{ID: artist.ID, Name: artist.Name})
+	}
+	return refs
+}
+
+// primaryImage renvoie l'URL de la première image ou une chaîne vide
+func primaryImage(images []spotify.Image) string {
+	if len(images) == 0 {
+		return ""
+	}
+	return images[0].URL
+}
@@ -0,0 +1,302 @@
+package enrich
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/yourusername/melody-explorer/internal/models"
+	"github.com/yourusername/melody-explorer/internal/spotify"
+	"github.com/yourusername/melody-explorer/internal/storage"
+)
+
+// ErrAlreadyRunning est renvoyée lorsqu'un rafraîchissement est déjà en cours
+var ErrAlreadyRunning = errors.New("rafraîchissement des favoris déjà en cours")
+
+// ErrNotAuthenticated est renvoyée lorsqu'aucun jeton Spotify n'est disponible
+var ErrNotAuthenticated = errors.New("aucune session Spotify active")
+
+// RunReport résume une exécution du rafraîchissement
+type RunReport struct {
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Forced     bool      `json:"forced"`
+	Checked    int       `json:"checked"`
+	Updated    int       `json:"updated"`
+	// Unavailable est le nombre de favoris signalés comme indisponibles
+	Unavailable int    `json:"unavailable"`
+	Failed      int    `json:"failed"`
+	Error       string `json:"error,omitempty"`
+}
+
+// Refresher met à jour périodiquement les noms, images et métadonnées des
+// favoris depuis Spotify, par lots, en passant par le limiteur de débit du client.
+// Il vérifie aussi leur disponibilité : les éléments que Spotify ne renvoie plus
+// sont conservés et signalés comme indisponibles.
+type Refresher struct {
+	client     *spotify.Client
+	store      storage.FavoritesStore
+	interval   time.Duration
+	staleAfter time.Duration
+
+	stop chan struct{}
+	done chan struct{}
+
+	running bool
+	last    *RunReport
+	mu      sync.Mutex
+}
+
+// NewRefresher crée un job de rafraîchissement exécuté toutes les interval,
+// qui ne traite que les favoris non rafraîchis depuis staleAfter
+func NewRefresher(client *spotify.Client, store storage.FavoritesStore, interval, staleAfter time.Duration) *Refresher {
+	return &Refresher{
+		client:     client,
+		store:      store,
+		interval:   interval,
+		staleAfter: staleAfter,
+	}
+}
+
+// Start lance l'exécution périodique en arrière-plan (sans effet si l'intervalle est nul)
+func (r *Refresher) Start() {
+	if r.interval <= 0 {
+		return
+	}
+
+	r.stop = make(chan struct{})
+	r.done = make(chan struct{})
+
+	go func() {
+		defer close(r.done)
+
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				report, err := r.Run(false)
+				if err != nil && err != ErrAlreadyRunning && err != ErrNotAuthenticated {
+					log.Printf("Erreur lors du rafraîchissement des favoris : %v", err)
+				} else if err == nil {
+					log.Printf("Rafraîchissement des favoris : %d vérifiés, %d mis à jour, %d indisponibles, %d échecs",
+						report.Checked, report.Updated, report.Unavailable, report.Failed)
+				}
+			case <-r.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop arrête l'exécution périodique
+func (r *Refresher) Stop() {
+	if r.stop == nil {
+		return
+	}
+	close(r.stop)
+	<-r.done
+}
+
+// Running indique si un rafraîchissement est en cours
+func (r *Refresher) Running() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.running
+}
+
+// LastReport renvoie le rapport de la dernière exécution terminée
+func (r *Refresher) LastReport() *RunReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.last
+}
+
+// Run rafraîchit les favoris obsolètes ou indisponibles, ou tous les favoris si force est vrai
+func (r *Refresher) Run(force bool) (RunReport, error) {
+	r.mu.Lock()
+	if r.running {
+		r.mu.Unlock()
+		return RunReport{}, ErrAlreadyRunning
+	}
+	r.running = true
+	r.mu.Unlock()
+
+	report := RunReport{StartedAt: time.Now(), Forced: force}
+	err := r.run(force, &report)
+	report.FinishedAt = time.Now()
+	if err != nil {
+		report.Error = err.Error()
+	}
+
+	r.mu.Lock()
+	r.running = false
+	r.last = &report
+	r.mu.Unlock()
+
+	return report, err
+}
+
+// run effectue le rafraîchissement type par type
+func (r *Refresher) run(force bool, report *RunReport) error {
+	if err := r.client.Auth.EnsureValidToken(); err != nil || !r.client.Auth.IsTokenValid() {
+		return ErrNotAuthenticated
+	}
+
+	// Regrouper les favoris à rafraîchir par type
+	stale := make(map[models.FavoriteType][]string)
+	cutoff := time.Now().Add(-r.staleAfter)
+	for _, item := range r.store.GetAll() {
+		if force || item.IsUnavailable() || item.RefreshedAt().Before(cutoff) {
+			stale[item.Type] = append(stale[item.Type], item.ID)
+		}
+	}
+
+	for itemType, ids := range stale {
+		size := BatchSize(itemType)
+		if size == 0 {
+			continue
+		}
+
+		for start := 0; start < len(ids); start += size {
+			end := start + size
+			if end > len(ids) {
+				end = len(ids)
+			}
+			batch := ids[start:end]
+			report.Checked += len(batch)
+
+			snapshots, missing, err := FetchBatch(r.client, itemType, batch)
+			if err != nil {
+				log.Printf("Erreur lors de la récupération d'un lot de %d %s : %v", len(batch), itemType, err)
+				report.Failed += len(batch)
+				continue
+			}
+
+			updated, unavailable, err := r.apply(itemType, snapshots, missing)
+			if err != nil {
+				return err
+			}
+			report.Updated += updated
+			report.Unavailable += unavailable
+		}
+	}
+
+	return nil
+}
+
+// BatchSize renvoie la taille de lot acceptée par Spotify pour un type, ou 0
+// pour les types de favoris qui ne sont pas des ressources Spotify
+func BatchSize(itemType models.FavoriteType) int {
+	switch itemType {
+	case models.FavoriteTypeArtist:
+		return spotify.MaxArtistsPerRequest
+	case models.FavoriteTypeAlbum:
+		return spotify.MaxAlbumsPerRequest
+	case models.FavoriteTypeTrack:
+		return spotify.MaxTracksPerRequest
+	case models.FavoriteTypePlaylist:
+		// Spotify ne propose pas de requête groupée pour les playlists
+		return 1
+	default:
+		return 0
+	}
+}
+
+// FetchBatch récupère un lot d'éléments (au plus BatchSize) et renvoie leurs
+// instantanés par ID, ainsi que la raison d'indisponibilité des IDs que Spotify
+// ne renvoie plus. Spotify répond dans l'ordre des IDs demandés, avec null pour
+// les IDs inconnus.
+func FetchBatch(client *spotify.Client, itemType models.FavoriteType, ids []string) (map[string]Snapshot, map[string]string, error) {
+	snapshots := make(map[string]Snapshot, len(ids))
+	missing := make(map[string]string)
+
+	switch itemType {
+	case models.FavoriteTypeArtist:
+		artists, err := client.GetArtists(ids)
+		if err != nil {
+			return nil, nil, err
+		}
+		for i, id := range ids {
+			if i >= len(artists) || artists[i] == nil {
+				missing[id] = models.UnavailableNotFound
+			} else if artists[i].ID == "" {
+				missing[id] = models.UnavailableEmpty
+			} else {
+				snapshots[id] = ArtistSnapshot(artists[i])
+			}
+		}
+	case models.FavoriteTypeAlbum:
+		albums, err := client.GetAlbums(ids)
+		if err != nil {
+			return nil, nil, err
+		}
+		for i, id := range ids {
+			if i >= len(albums) || albums[i] == nil {
+				missing[id] = models.UnavailableNotFound
+			} else if albums[i].ID == "" {
+				missing[id] = models.UnavailableEmpty
+			} else {
+				snapshots[id] = AlbumSnapshot(albums[i])
+			}
+		}
+	case models.FavoriteTypeTrack:
+		tracks, err := client.GetTracks(ids)
+		if err != nil {
+			return nil, nil, err
+		}
+		for i, id := range ids {
+			if i >= len(tracks) || tracks[i] == nil {
+				missing[id] = models.UnavailableNotFound
+			} else if tracks[i].ID == "" {
+				missing[id] = models.UnavailableEmpty
+			} else {
+				snapshots[id] = TrackSnapshot(tracks[i])
+			}
+		}
+	case models.FavoriteTypePlaylist:
+		for _, id := range ids {
+			snapshot, err := Fetch(client, itemType, id)
+			if reason := UnavailableReason(err); reason != "" {
+				missing[id] = reason
+			} else if err != nil {
+				return nil, nil, err
+			} else {
+				snapshots[id] = snapshot
+			}
+		}
+	}
+
+	return snapshots, missing, nil
+}
+
+// apply enregistre les instantanés et les indisponibilités d'un lot dans une
+// seule transaction. Les favoris supprimés pendant la récupération sont ignorés.
+func (r *Refresher) apply(itemType models.FavoriteType, snapshots map[string]Snapshot, missing map[string]string) (int, int, error) {
+	updated, unavailable := 0, 0
+	now := time.Now()
+
+	err := r.store.Update(func(tx *storage.Tx) error {
+		for id, snapshot := range snapshots {
+			item, ok := tx.Get(id, itemType)
+			if !ok {
+				continue
+			}
+			snapshot.ApplyTo(&item)
+			refreshedAt := now
+			item.LastRefreshedAt = &refreshedAt
+			tx.Add(item)
+			updated++
+		}
+		for id, reason := range missing {
+			if markUnavailable(tx, itemType, id, reason, now) {
+				unavailable++
+			}
+		}
+		return nil
+	})
+
+	return updated, unavailable, err
+}
@@ -0,0 +1,222 @@
+// Package events diffuse les modifications des données aux clients connectés
+// (flux Server-Sent Events). Le hub garde les derniers événements publiés
+// pour qu'un client qui se reconnecte reçoive ceux qu'il a manqués.
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TypeResync est le type de l'événement envoyé à un client qui ne peut pas
+// reprendre là où il s'était arrêté : il doit recharger son état
+const TypeResync = "resync"
+
+// Tailles par défaut
+const (
+	// DefaultHistorySize est le nombre d'événements conservés pour la reprise
+	DefaultHistorySize = 256
+	// subscriptionBuffer est le nombre d'événements en attente par abonné ;
+	// un abonné plus lent est déconnecté et reprendra avec Last-Event-ID
+	subscriptionBuffer = 64
+)
+
+// Event est un événement publié. Son identifiant est croissant au sein d'une
+// exécution du serveur.
+type Event struct {
+	ID   string          `json:"id"`
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+
+	// user est le destinataire de l'événement, vide pour tous les utilisateurs
+	user string
+	seq  uint64
+}
+
+// isFor indique si l'événement est destiné à user
+func (e Event) isFor(user string) bool {
+	return e.user == "" || e.user == user
+}
+
+// Hub répartit les événements entre les abonnés de chaque utilisateur
+type Hub struct {
+	// epoch distingue les identifiants d'une exécution du serveur à l'autre
+	epoch string
+
+	mu          sync.Mutex
+	seq         uint64
+	history     []Event
+	size        int
+	subscribers map[string]map[*Subscription]struct{}
+	closed      bool
+}
+
+// NewHub crée un hub qui conserve les historySize derniers événements
+func NewHub(historySize int) *Hub {
+	if historySize <= 0 {
+		historySize = DefaultHistorySize
+	}
+	return &Hub{
+		epoch:       strconv.FormatInt(time.Now().UnixNano(), 36),
+		size:        historySize,
+		subscribers: make(map[string]map[*Subscription]struct{}),
+	}
+}
+
+// Publish publie un événement pour user, ou pour tous les utilisateurs si
+// user est vide. data est encodé en JSON.
+func (h *Hub) Publish(user, eventType string, data interface{}) (Event, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, fmt.Errorf("encodage de l'événement %s : %w", eventType, err)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.seq++
+	event := Event{ID: h.id(h.seq), Type: eventType, Data: payload, user: user, seq: h.seq}
+	if len(h.history) == h.size {
+		h.history = append(h.history[:0], h.history[1:]...)
+	}
+	h.history = append(h.history, event)
+
+	for target, subs := range h.subscribers {
+		if !event.isFor(target) {
+			continue
+		}
+		for sub := range subs {
+			select {
+			case sub.ch <- event:
+			default:
+				// Abonné trop lent : le déconnecter plutôt que de bloquer la publication
+				h.removeLocked(sub)
+			}
+		}
+	}
+	return event, nil
+}
+
+// Subscribe abonne un client aux événements de user. lastEventID est le
+// dernier événement reçu par le client (en-tête Last-Event-ID), vide pour
+// une première connexion. Les événements manqués sont renvoyés ; s'ils ne
+// sont plus tous disponibles, un unique événement TypeResync les remplace.
+func (h *Hub) Subscribe(user, lastEventID string) (*Subscription, []Event) {
+	sub := &Subscription{
+		hub:  h,
+		user: user,
+		ch:   make(chan Event, subscriptionBuffer),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		close(sub.ch)
+		return sub, nil
+	}
+	if h.subscribers[user] == nil {
+		h.subscribers[user] = make(map[*Subscription]struct{})
+	}
+	h.subscribers[user][sub] = struct{}{}
+
+	return sub, h.missedLocked(user, lastEventID)
+}
+
+// missedLocked renvoie les événements de user publiés après lastEventID
+func (h *Hub) missedLocked(user, lastEventID string) []Event {
+	if lastEventID == "" {
+		return nil
+	}
+
+	last, ok := h.parseID(lastEventID)
+	// Un identifiant d'une exécution précédente, inconnu ou trop ancien ne
+	// permet pas la reprise
+	oldest := h.seq + 1
+	if len(h.history) > 0 {
+		oldest = h.history[0].seq
+	}
+	if !ok || last > h.seq || last+1 < oldest {
+		return []Event{{ID: h.id(h.seq), Type: TypeResync, Data: json.RawMessage("{}")}}
+	}
+
+	var missed []Event
+	for _, event := range h.history {
+		if event.seq > last && event.isFor(user) {
+			missed = append(missed, event)
+		}
+	}
+	return missed
+}
+
+// Close déconnecte tous les abonnés ; les abonnements suivants sont fermés
+// immédiatement
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for _, subs := range h.subscribers {
+		for sub := range subs {
+			h.removeLocked(sub)
+		}
+	}
+}
+
+// removeLocked retire un abonné et ferme son canal
+func (h *Hub) removeLocked(sub *Subscription) {
+	subs, ok := h.subscribers[sub.user]
+	if !ok {
+		return
+	}
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(h.subscribers, sub.user)
+	}
+	close(sub.ch)
+}
+
+// id renvoie l'identifiant de l'événement seq
+func (h *Hub) id(seq uint64) string {
+	return h.epoch + "-" + strconv.FormatUint(seq, 10)
+}
+
+// parseID lit un identifiant émis par ce hub
+func (h *Hub) parseID(id string) (uint64, bool) {
+	epoch, seq, ok := strings.Cut(id, "-")
+	if !ok || epoch != h.epoch {
+		return 0, false
+	}
+	parsed, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return parsed, true
+}
+
+// Subscription est l'abonnement d'un client
+type Subscription struct {
+	hub  *Hub
+	user string
+	ch   chan Event
+}
+
+// Events renvoie le canal des événements, fermé lorsque l'abonnement prend
+// fin (fermeture du hub ou client trop lent)
+func (s *Subscription) Events() <-chan Event {
+	return s.ch
+}
+
+// Close met fin à l'abonnement
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.removeLocked(s)
+}
@@ -0,0 +1,84 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yourusername/melody-explorer/internal/models"
+)
+
+// csvHeader liste les colonnes de l'export CSV
+var csvHeader = []string{
+	"type", "id", "name", "artists", "album", "release_date", "duration_ms",
+	"popularity", "genres", "rating", "tags", "note", "added_at", "spotify_uri", "unavailable",
+}
+
+// csvEncoder écrit une ligne par élément
+type csvEncoder struct {
+	w *csv.Writer
+}
+
+func newCSVEncoder(w io.Writer) Encoder {
+	return &csvEncoder{w: csv.NewWriter(w)}
+}
+
+// Begin écrit la ligne d'en-tête
+func (e *csvEncoder) Begin(Metadata) error {
+	return e.write(csvHeader)
+}
+
+// Encode écrit la ligne d'un élément
+func (e *csvEncoder) Encode(item models.FavoriteItem) error {
+	var releaseDate, popularity string
+	if item.Metadata != nil {
+		releaseDate = item.Metadata.ReleaseDate
+		popularity = strconv.Itoa(item.Metadata.Popularity)
+	}
+
+	var durationMs, rating, unavailable string
+	if d := item.DurationMs(); d > 0 {
+		durationMs = strconv.Itoa(d)
+	}
+	if item.Rating > 0 {
+		rating = strconv.Itoa(item.Rating)
+	}
+	if item.IsUnavailable() {
+		unavailable = item.Unavailable.Reason
+	}
+
+	return e.write([]string{
+		string(item.Type),
+		item.ID,
+		item.Name,
+		item.ArtistNames(),
+		item.AlbumName(),
+		releaseDate,
+		durationMs,
+		popularity,
+		strings.Join(item.Genres(), "; "),
+		rating,
+		strings.Join(item.Tags, "; "),
+		item.Note,
+		item.AddedAt.Format(time.RFC3339),
+		item.URI(),
+		unavailable,
+	})
+}
+
+// End vide le tampon
+func (e *csvEncoder) End() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// write écrit une ligne et la transmet immédiatement
+func (e *csvEncoder) write(record []string) error {
+	if err := e.w.Write(record); err != nil {
+		return err
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
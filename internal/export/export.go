@@ -0,0 +1,87 @@
+// Package export écrit les favoris dans des formats de fichiers courants
+// (JSON, CSV et listes de lecture), élément par élément pour permettre une
+// réponse HTTP en flux continu.
+package export
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/yourusername/melody-explorer/internal/models"
+)
+
+// Encoder écrit une suite d'éléments dans un format donné
+type Encoder interface {
+	// Begin écrit l'en-tête du document
+	Begin(meta Metadata) error
+	// Encode écrit un élément
+	Encode(item models.FavoriteItem) error
+	// End termine le document
+	End() error
+}
+
+// Metadata décrit le document exporté
+type Metadata struct {
+	Title   string
+	Creator string
+	Date    time.Time
+}
+
+// Format décrit un format d'export
+type Format struct {
+	Name        string
+	ContentType string
+	Extension   string
+	// Playlist indique une liste de lecture, qui ne peut contenir que des
+	// ressources Spotify
+	Playlist   bool
+	newEncoder func(w io.Writer) Encoder
+}
+
+// NewEncoder crée un encodeur écrivant dans w
+func (f Format) NewEncoder(w io.Writer) Encoder {
+	return f.newEncoder(w)
+}
+
+// formats regroupe les formats disponibles par nom
+var formats = map[string]Format{
+	"json": {Name: "json", ContentType: "application/json", Extension: "json", newEncoder: newJSONEncoder},
+	"csv":  {Name: "csv", ContentType: "text/csv; charset=utf-8", Extension: "csv", newEncoder: newCSVEncoder},
+	"m3u8": {Name: "m3u8", ContentType: "audio/x-mpegurl; charset=utf-8", Extension: "m3u8", Playlist: true, newEncoder: newM3UEncoder},
+	"xspf": {Name: "xspf", ContentType: "application/xspf+xml", Extension: "xspf", Playlist: true, newEncoder: newXSPFEncoder},
+	"jspf": {Name: "jspf", ContentType: "application/jspf+json", Extension: "jspf", Playlist: true, newEncoder: newJSPFEncoder},
+}
+
+// Lookup renvoie le format correspondant au nom
+func Lookup(name string) (Format, error) {
+	format, ok := formats[name]
+	if !ok {
+		return Format{}, fmt.Errorf("format d'export inconnu : %q", name)
+	}
+	return format, nil
+}
+
+// Names renvoie les noms des formats disponibles, triés
+func Names() []string {
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Write écrit un document complet contenant les éléments
+func Write(enc Encoder, meta Metadata, items []models.FavoriteItem) error {
+	if err := enc.Begin(meta); err != nil {
+		return err
+	}
+	for _, item := range items {
+		if err := enc.Encode(item); err != nil {
+			return err
+		}
+	}
+	return enc.End()
+}
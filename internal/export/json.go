@@ -0,0 +1,59 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+
+	"github.com/yourusername/melody-explorer/internal/models"
+)
+
+// jsonEncoder écrit un tableau JSON au format de favorites.json
+type jsonEncoder struct {
+	w     *bufio.Writer
+	count int
+}
+
+func newJSONEncoder(w io.Writer) Encoder {
+	return &jsonEncoder{w: bufio.NewWriter(w)}
+}
+
+// Begin ouvre le tableau
+func (e *jsonEncoder) Begin(Metadata) error {
+	_, err := e.w.WriteString("[")
+	return err
+}
+
+// Encode écrit un élément, précédé d'une virgule s'il n'est pas le premier
+func (e *jsonEncoder) Encode(item models.FavoriteItem) error {
+	data, err := json.MarshalIndent(item, "  ", "  ")
+	if err != nil {
+		return err
+	}
+
+	sep := ",\n  "
+	if e.count == 0 {
+		sep = "\n  "
+	}
+	e.count++
+
+	if _, err := e.w.WriteString(sep); err != nil {
+		return err
+	}
+	if _, err := e.w.Write(data); err != nil {
+		return err
+	}
+	return e.w.Flush()
+}
+
+// End ferme le tableau
+func (e *jsonEncoder) End() error {
+	end := "\n]\n"
+	if e.count == 0 {
+		end = "]\n"
+	}
+	if _, err := e.w.WriteString(end); err != nil {
+		return err
+	}
+	return e.w.Flush()
+}
@@ -0,0 +1,99 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/yourusername/melody-explorer/internal/models"
+)
+
+// jspfTrack est une piste d'une liste de lecture JSPF
+type jspfTrack struct {
+	Location   []string `json:"location"`
+	Identifier []string `json:"identifier"`
+	Title      string   `json:"title"`
+	Creator    string   `json:"creator,omitempty"`
+	Album      string   `json:"album,omitempty"`
+	Annotation string   `json:"annotation,omitempty"`
+	Image      string   `json:"image,omitempty"`
+	Duration   int      `json:"duration,omitempty"`
+}
+
+// jspfEncoder écrit une liste de lecture JSPF, l'équivalent JSON de XSPF
+type jspfEncoder struct {
+	w     *bufio.Writer
+	count int
+}
+
+func newJSPFEncoder(w io.Writer) Encoder {
+	return &jspfEncoder{w: bufio.NewWriter(w)}
+}
+
+// Begin écrit l'en-tête de la liste et ouvre le tableau des pistes
+func (e *jspfEncoder) Begin(meta Metadata) error {
+	header := struct {
+		Title   string `json:"title"`
+		Creator string `json:"creator,omitempty"`
+		Date    string `json:"date"`
+	}{meta.Title, meta.Creator, meta.Date.Format(time.RFC3339)}
+
+	data, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+
+	// Retirer l'accolade fermante pour poursuivre l'objet avec les pistes
+	if _, err := e.w.WriteString(`{"playlist":`); err != nil {
+		return err
+	}
+	if _, err := e.w.Write(data[:len(data)-1]); err != nil {
+		return err
+	}
+	if _, err := e.w.WriteString(`,"track":[`); err != nil {
+		return err
+	}
+	return e.w.Flush()
+}
+
+// Encode écrit une piste
+func (e *jspfEncoder) Encode(item models.FavoriteItem) error {
+	track := jspfTrack{
+		Location:   []string{item.URI()},
+		Identifier: []string{item.SpotifyURL()},
+		Title:      item.Name,
+		Creator:    item.ArtistNames(),
+		Album:      item.AlbumName(),
+		Annotation: item.Note,
+		Image:      item.ImageURL,
+		Duration:   item.DurationMs(),
+	}
+
+	data, err := json.Marshal(track)
+	if err != nil {
+		return err
+	}
+	if e.count > 0 {
+		if err := e.w.WriteByte(','); err != nil {
+			return err
+		}
+	}
+	e.count++
+
+	if _, err := e.w.WriteString("\n"); err != nil {
+		return err
+	}
+	if _, err := e.w.Write(data); err != nil {
+		return err
+	}
+	return e.w.Flush()
+}
+
+// End ferme le tableau des pistes et les objets englobants
+func (e *jspfEncoder) End() error {
+	if _, err := e.w.WriteString("\n]}}\n"); err != nil {
+		return err
+	}
+	return e.w.Flush()
+}
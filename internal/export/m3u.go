@@ -0,0 +1,51 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/yourusername/melody-explorer/internal/models"
+)
+
+// m3uEncoder écrit une liste de lecture M3U8 étendue dont les entrées sont
+// des URI Spotify
+type m3uEncoder struct {
+	w io.Writer
+}
+
+func newM3UEncoder(w io.Writer) Encoder {
+	return &m3uEncoder{w: w}
+}
+
+// Begin écrit l'en-tête étendu et le titre de la liste
+func (e *m3uEncoder) Begin(meta Metadata) error {
+	_, err := fmt.Fprintf(e.w, "#EXTM3U\n#PLAYLIST:%s\n", m3uEscape(meta.Title))
+	return err
+}
+
+// Encode écrit la ligne #EXTINF (durée en secondes, -1 si inconnue) suivie de l'URI
+func (e *m3uEncoder) Encode(item models.FavoriteItem) error {
+	duration := -1
+	if d := item.DurationMs(); d > 0 {
+		duration = (d + 500) / 1000
+	}
+
+	title := item.Name
+	if artists := item.ArtistNames(); artists != "" {
+		title = artists + " - " + item.Name
+	}
+
+	_, err := fmt.Fprintf(e.w, "#EXTINF:%d,%s\n%s\n", duration, m3uEscape(title), item.URI())
+	return err
+}
+
+// End ne fait rien : le format M3U n'a pas de pied de document
+func (e *m3uEncoder) End() error {
+	return nil
+}
+
+// m3uEscape supprime les retours à la ligne qui casseraient le format
+func m3uEscape(value string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(value)
+}
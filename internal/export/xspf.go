@@ -0,0 +1,100 @@
+package export
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+
+	"github.com/yourusername/melody-explorer/internal/models"
+)
+
+// xspfTrack est une piste d'une liste de lecture XSPF
+type xspfTrack struct {
+	XMLName    xml.Name `xml:"track"`
+	Location   string   `xml:"location"`
+	Identifier string   `xml:"identifier"`
+	Title      string   `xml:"title"`
+	Creator    string   `xml:"creator,omitempty"`
+	Album      string   `xml:"album,omitempty"`
+	Annotation string   `xml:"annotation,omitempty"`
+	Image      string   `xml:"image,omitempty"`
+	Duration   int      `xml:"duration,omitempty"`
+}
+
+// xspfEncoder écrit une liste de lecture XSPF (http://xspf.org/ns/0/)
+type xspfEncoder struct {
+	w   io.Writer
+	enc *xml.Encoder
+}
+
+func newXSPFEncoder(w io.Writer) Encoder {
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return &xspfEncoder{w: w, enc: enc}
+}
+
+// Begin écrit la déclaration XML, l'en-tête de la liste et ouvre trackList
+func (e *xspfEncoder) Begin(meta Metadata) error {
+	if _, err := io.WriteString(e.w, xml.Header); err != nil {
+		return err
+	}
+
+	playlist := xml.StartElement{
+		Name: xml.Name{Local: "playlist"},
+		Attr: []xml.Attr{
+			{Name: xml.Name{Local: "version"}, Value: "1"},
+			{Name: xml.Name{Local: "xmlns"}, Value: "http://xspf.org/ns/0/"},
+		},
+	}
+	if err := e.enc.EncodeToken(playlist); err != nil {
+		return err
+	}
+	if err := e.enc.EncodeElement(meta.Title, xml.StartElement{Name: xml.Name{Local: "title"}}); err != nil {
+		return err
+	}
+	if meta.Creator != "" {
+		if err := e.enc.EncodeElement(meta.Creator, xml.StartElement{Name: xml.Name{Local: "creator"}}); err != nil {
+			return err
+		}
+	}
+	if err := e.enc.EncodeElement(meta.Date.Format(time.RFC3339), xml.StartElement{Name: xml.Name{Local: "date"}}); err != nil {
+		return err
+	}
+	if err := e.enc.EncodeToken(xml.StartElement{Name: xml.Name{Local: "trackList"}}); err != nil {
+		return err
+	}
+	return e.enc.Flush()
+}
+
+// Encode écrit une piste
+func (e *xspfEncoder) Encode(item models.FavoriteItem) error {
+	track := xspfTrack{
+		Location:   item.URI(),
+		Identifier: item.SpotifyURL(),
+		Title:      item.Name,
+		Creator:    item.ArtistNames(),
+		Album:      item.AlbumName(),
+		Annotation: item.Note,
+		Image:      item.ImageURL,
+		Duration:   item.DurationMs(),
+	}
+	if err := e.enc.Encode(track); err != nil {
+		return err
+	}
+	return e.enc.Flush()
+}
+
+// End ferme trackList et playlist
+func (e *xspfEncoder) End() error {
+	if err := e.enc.EncodeToken(xml.EndElement{Name: xml.Name{Local: "trackList"}}); err != nil {
+		return err
+	}
+	if err := e.enc.EncodeToken(xml.EndElement{Name: xml.Name{Local: "playlist"}}); err != nil {
+		return err
+	}
+	if err := e.enc.Flush(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(e.w, "\n")
+	return err
+}
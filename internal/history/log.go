@@ -0,0 +1,394 @@
+// Package history conserve un historique des modifications des favoris,
+// permettant d'annuler la dernière opération ou de revenir à l'état des
+// favoris à une date passée.
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourusername/melody-explorer/internal/models"
+	"github.com/yourusername/melody-explorer/internal/storage"
+)
+
+// Actions enregistrées dans l'historique
+const (
+	ActionAdd    = "add"
+	ActionUpdate = "update"
+	ActionRemove = "remove"
+	// ActionRevert marque un lot annulé sans modifier de favori, ses
+	// modifications ayant déjà été défaites par ailleurs
+	ActionRevert = "revert"
+)
+
+// Auteurs des modifications
+const (
+	// ActorWeb désigne les modifications faites depuis l'interface ou l'API
+	ActorWeb = "web"
+	// ActorImport désigne les favoris ajoutés par un import validé
+	ActorImport = "import"
+	// ActorSystem désigne les modifications automatiques (rafraîchissement des
+	// métadonnées, indisponibilité)
+	ActorSystem = "system"
+	// ActorUndo désigne l'annulation d'une opération
+	ActorUndo = "undo"
+	// ActorRestore désigne le retour à un état passé
+	ActorRestore = "restore"
+	// ActorSync désigne les modifications reçues d'un appareil synchronisé
+	ActorSync = "sync"
+	// ActorTokenPrefix préfixe les modifications faites avec un jeton d'accès
+	// personnel, suivi du nom du jeton
+	ActorTokenPrefix = "token:"
+)
+
+// ActorToken renvoie l'auteur des modifications faites avec le jeton nommé
+func ActorToken(name string) string {
+	return ActorTokenPrefix + name
+}
+
+// Entry est la modification d'un favori. Les entrées d'une même transaction
+// partagent le même numéro de lot.
+type Entry struct {
+	Seq    int64                `json:"seq"`
+	Batch  int64                `json:"batch"`
+	Time   time.Time            `json:"time"`
+	Actor  string               `json:"actor"`
+	Action string               `json:"action"`
+	Type   models.FavoriteType  `json:"type"`
+	ID     string               `json:"id"`
+	Name   string               `json:"name"`
+	Old    *models.FavoriteItem `json:"old,omitempty"`
+	New    *models.FavoriteItem `json:"new,omitempty"`
+	// Reverts est le lot annulé par cette entrée, le cas échéant
+	Reverts int64 `json:"reverts,omitempty"`
+}
+
+// Key renvoie la clé du favori modifié
+func (e Entry) Key() models.FavoriteKey {
+	return models.FavoriteKey{Type: e.Type, ID: e.ID}
+}
+
+// ActionLabel renvoie le libellé de l'action
+func (e Entry) ActionLabel() string {
+	switch e.Action {
+	case ActionAdd:
+		return "Ajout"
+	case ActionRemove:
+		return "Suppression"
+	default:
+		return "Modification"
+	}
+}
+
+// ActorLabel renvoie le libellé de l'auteur de la modification
+func (e Entry) ActorLabel() string {
+	switch e.Actor {
+	case ActorWeb:
+		return "Utilisateur"
+	case ActorImport:
+		return "Import"
+	case ActorSystem:
+		return "Système"
+	case ActorUndo:
+		return "Annulation"
+	case ActorRestore:
+		return "Restauration"
+	case ActorSync:
+		return "Synchronisation"
+	default:
+		if name, ok := strings.CutPrefix(e.Actor, ActorTokenPrefix); ok {
+			return "Jeton « " + name + " »"
+		}
+		return e.Actor
+	}
+}
+
+// Changes renvoie les champs modifiés par une mise à jour
+func (e Entry) Changes() []string {
+	if e.Old == nil || e.New == nil {
+		return nil
+	}
+	old, cur := e.Old, e.New
+
+	var changes []string
+	if old.Name != cur.Name {
+		changes = append(changes, "nom")
+	}
+	if old.ImageURL != cur.ImageURL {
+		changes = append(changes, "image")
+	}
+	if old.Note != cur.Note {
+		changes = append(changes, "commentaire")
+	}
+	if old.Rating != cur.Rating {
+		changes = append(changes, "note")
+	}
+	if !reflect.DeepEqual(old.Tags, cur.Tags) {
+		changes = append(changes, "étiquettes")
+	}
+	if !reflect.DeepEqual(old.Unavailable, cur.Unavailable) {
+		changes = append(changes, "disponibilité")
+	}
+	if !reflect.DeepEqual(old.Metadata, cur.Metadata) {
+		changes = append(changes, "métadonnées")
+	}
+	if !old.AddedAt.Equal(cur.AddedAt) {
+		changes = append(changes, "date d'ajout")
+	}
+	return changes
+}
+
+// refreshOnly indique qu'une modification ne porte que sur l'instantané des
+// métadonnées de Spotify et la date de rafraîchissement
+func refreshOnly(change storage.FavoriteChange) bool {
+	if change.Old == nil || change.New == nil {
+		return false
+	}
+	old, cur := *change.Old, *change.New
+	old.Metadata, cur.Metadata = nil, nil
+	old.LastRefreshedAt, cur.LastRefreshedAt = nil, nil
+	return reflect.DeepEqual(old, cur)
+}
+
+// changeEntries renvoie les entrées correspondant aux modifications d'une
+// transaction, sans numéro de séquence ni de lot. Les simples
+// rafraîchissements de métadonnées sont ignorés : ils sont enregistrés dans
+// le stockage sans entrer dans l'historique ni changer la révision du favori.
+func changeEntries(changes []storage.FavoriteChange) []Entry {
+	entries := make([]Entry, 0, len(changes))
+	for _, change := range changes {
+		switch {
+		case refreshOnly(change):
+			continue
+		case change.Old == nil:
+			item := change.New
+			entries = append(entries, Entry{Action: ActionAdd, Type: item.Type, ID: item.ID, Name: item.Name, New: item})
+		case change.New == nil:
+			item := change.Old
+			entries = append(entries, Entry{Action: ActionRemove, Type: item.Type, ID: item.ID, Name: item.Name, Old: item})
+		default:
+			item := change.New
+			entries = append(entries, Entry{Action: ActionUpdate, Type: item.Type, ID: item.ID, Name: item.Name, Old: change.Old, New: item})
+		}
+	}
+	return entries
+}
+
+// Log est un journal des modifications en ajout seul (data/history.jsonl),
+// une entrée JSON par ligne, entièrement chargé en mémoire
+type Log struct {
+	path    string
+	entries []Entry
+	// undone contient les lots déjà annulés
+	undone map[int64]bool
+	mu     sync.RWMutex
+}
+
+// OpenLog ouvre ou crée le journal dans dataDir
+func OpenLog(dataDir string) (*Log, error) {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, fmt.Errorf("création du dossier de données : %w", err)
+	}
+
+	l := &Log{
+		path:   filepath.Join(dataDir, "history.jsonl"),
+		undone: make(map[int64]bool),
+	}
+
+	data, err := os.ReadFile(l.path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("lecture de l'historique : %w", err)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// Une ligne tronquée par un arrêt brutal est ignorée
+			log.Printf("Entrée d'historique illisible ignorée (ligne %d) : %v", line, err)
+			continue
+		}
+		l.index(entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("lecture de l'historique : %w", err)
+	}
+
+	log.Printf("Chargement de %d entrées d'historique depuis %s", len(l.entries), l.path)
+	return l, nil
+}
+
+// index ajoute une entrée au journal en mémoire
+func (l *Log) index(entry Entry) {
+	l.entries = append(l.entries, entry)
+	if entry.Reverts != 0 {
+		l.undone[entry.Reverts] = true
+	}
+}
+
+// append numérote les entrées d'un lot, les écrit à la fin du fichier et
+// renvoie les entrées enregistrées
+func (l *Log) append(actor string, reverts int64, entries []Entry) ([]Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var seq, batch int64 = 1, 1
+	if n := len(l.entries); n > 0 {
+		seq = l.entries[n-1].Seq + 1
+		batch = l.entries[n-1].Batch + 1
+	}
+
+	now := time.Now()
+	var buf bytes.Buffer
+	for i := range entries {
+		entries[i].Seq = seq + int64(i)
+		entries[i].Batch = batch
+		entries[i].Time = now
+		entries[i].Actor = actor
+		entries[i].Reverts = reverts
+
+		data, err := json.Marshal(entries[i])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("ouverture de l'historique : %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(buf.Bytes()); err != nil {
+		return nil, fmt.Errorf("écriture de l'historique : %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		return nil, fmt.Errorf("écriture de l'historique : %w", err)
+	}
+
+	for _, entry := range entries {
+		l.index(entry)
+	}
+	return entries, nil
+}
+
+// markUndone enregistre l'annulation d'un lot qui n'a modifié aucun favori,
+// pour que lastUndoable passe au lot précédent
+func (l *Log) markUndone(actor string, batch int64) error {
+	_, err := l.append(actor, batch, []Entry{{Action: ActionRevert}})
+	return err
+}
+
+// Query filtre la consultation de l'historique
+type Query struct {
+	// Before limite aux entrées dont le numéro est inférieur (0 pour aucune limite)
+	Before int64
+	// Offset est le nombre d'entrées correspondantes à sauter
+	Offset int
+	Limit  int
+	Type   models.FavoriteType
+	ID     string
+}
+
+// matches indique si une entrée correspond aux filtres de la requête.
+// Les marques d'annulation ne sont jamais renvoyées.
+func (q Query) matches(entry Entry) bool {
+	if entry.Action == ActionRevert {
+		return false
+	}
+	if q.Before > 0 && entry.Seq >= q.Before {
+		return false
+	}
+	if q.Type != "" && entry.Type != q.Type {
+		return false
+	}
+	return q.ID == "" || entry.ID == q.ID
+}
+
+// Entries renvoie les entrées correspondant à la requête, les plus récentes d'abord
+func (l *Log) Entries(q Query) []Entry {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	entries := []Entry{}
+	skipped := 0
+	for i := len(l.entries) - 1; i >= 0; i-- {
+		entry := l.entries[i]
+		if !q.matches(entry) {
+			continue
+		}
+		if skipped < q.Offset {
+			skipped++
+			continue
+		}
+		entries = append(entries, entry)
+		if q.Limit > 0 && len(entries) >= q.Limit {
+			break
+		}
+	}
+	return entries
+}
+
+// Count renvoie le nombre d'entrées correspondant aux filtres de la requête
+func (l *Log) Count(q Query) int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	count := 0
+	for _, entry := range l.entries {
+		if q.matches(entry) {
+			count++
+		}
+	}
+	return count
+}
+
+// lastUndoable renvoie les entrées du dernier lot pouvant être annulé : ni
+// une annulation, ni un signalement automatique, ni déjà annulé
+func (l *Log) lastUndoable() []Entry {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	for i := len(l.entries) - 1; i >= 0; i-- {
+		entry := l.entries[i]
+		if entry.Reverts != 0 || entry.Actor == ActorSystem || l.undone[entry.Batch] {
+			continue
+		}
+
+		// Remonter jusqu'au début du lot
+		start := i
+		for start > 0 && l.entries[start-1].Batch == entry.Batch {
+			start--
+		}
+		batch := make([]Entry, i-start+1)
+		copy(batch, l.entries[start:i+1])
+		return batch
+	}
+	return nil
+}
+
+// since renvoie les entrées postérieures à la date donnée, dans l'ordre chronologique
+func (l *Log) since(t time.Time) []Entry {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	var entries []Entry
+	for _, entry := range l.entries {
+		if entry.Time.After(t) && entry.Action != ActionRevert {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
@@ -0,0 +1,304 @@
+package history
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/yourusername/melody-explorer/internal/hlc"
+	"github.com/yourusername/melody-explorer/internal/models"
+	"github.com/yourusername/melody-explorer/internal/storage"
+)
+
+// ErrNothingToUndo est renvoyée lorsqu'aucune opération ne peut être annulée
+var ErrNothingToUndo = errors.New("aucune modification à annuler")
+
+var _ storage.FavoritesStore = (*Recorder)(nil)
+
+// Recorder enveloppe le stockage des favoris et enregistre dans l'historique
+// chaque transaction qui modifie des favoris, attribuée à un auteur.
+// Les modifications du rafraîchissement des métadonnées sont attribuées à
+// ActorSystem et ne peuvent pas être annulées ; celles qui ne portent que
+// sur l'instantané des métadonnées ne sont ni historisées ni horodatées.
+type Recorder struct {
+	storage.FavoritesStore
+	log   *Log
+	actor string
+	// mu sérialise les écritures de toutes les vues pour que l'historique
+	// suive l'ordre des transactions
+	mu *sync.Mutex
+	// observers est partagé par toutes les vues
+	observers *[]func([]Entry)
+	// versioning est partagé par toutes les vues
+	versioning *versioning
+}
+
+// versioning horodate les modifications pour la synchronisation entre
+// appareils et conserve la trace des suppressions
+type versioning struct {
+	clock      *hlc.Clock
+	tombstones *storage.TombstonesStorage
+}
+
+// NewRecorder crée un enregistreur dont les modifications sont attribuées à actor
+func NewRecorder(store storage.FavoritesStore, history *Log, actor string) *Recorder {
+	return &Recorder{
+		FavoritesStore: store,
+		log:            history,
+		actor:          actor,
+		mu:             &sync.Mutex{},
+		observers:      &[]func([]Entry){},
+		versioning:     &versioning{},
+	}
+}
+
+// EnableVersioning horodate désormais chaque favori modifié (Version et
+// Revision) avec clock et enregistre les suppressions dans tombstones,
+// quelle que soit la vue utilisée
+func (r *Recorder) EnableVersioning(clock *hlc.Clock, tombstones *storage.TombstonesStorage) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.versioning.clock = clock
+	r.versioning.tombstones = tombstones
+}
+
+// OnChange enregistre fn, appelée après chaque transaction qui modifie des
+// favoris avec les entrées ajoutées à l'historique, quelle que soit la vue
+// utilisée. fn est appelée dans l'ordre des transactions et ne doit pas
+// bloquer. Les observateurs doivent être enregistrés avant la première
+// modification.
+func (r *Recorder) OnChange(fn func(entries []Entry)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	*r.observers = append(*r.observers, fn)
+}
+
+// As renvoie une vue du même stockage dont les modifications sont attribuées à actor
+func (r *Recorder) As(actor string) *Recorder {
+	view := *r
+	view.actor = actor
+	return &view
+}
+
+// Log renvoie le journal des modifications
+func (r *Recorder) Log() *Log {
+	return r.log
+}
+
+// Add ajoute ou met à jour un élément favori
+func (r *Recorder) Add(item models.FavoriteItem) error {
+	return r.Update(func(tx *storage.Tx) error {
+		tx.Add(item)
+		return nil
+	})
+}
+
+// Remove supprime un élément favori
+func (r *Recorder) Remove(id string, itemType models.FavoriteType) error {
+	return r.Update(func(tx *storage.Tx) error {
+		tx.Remove(id, itemType)
+		return nil
+	})
+}
+
+// Update exécute fn dans une transaction et enregistre ses modifications
+func (r *Recorder) Update(fn func(tx *storage.Tx) error) error {
+	_, err := r.update(r.actor, 0, nil, fn)
+	return err
+}
+
+// UpdateVersioned exécute fn comme Update, pour des modifications reçues
+// d'un appareil synchronisé : les favoris listés dans versions gardent
+// l'horodatage donné par leur auteur. Renvoie un curseur : toute
+// modification ultérieure aura une révision postérieure.
+func (r *Recorder) UpdateVersioned(versions map[models.FavoriteKey]hlc.Timestamp, fn func(tx *storage.Tx) error) (hlc.Timestamp, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.versioning.clock == nil {
+		return "", errors.New("horodatage des favoris non activé")
+	}
+	if _, err := r.updateLocked(r.actor, 0, versions, fn); err != nil {
+		return "", err
+	}
+	return r.versioning.clock.Now(), nil
+}
+
+// update exécute fn dans une transaction du stockage et ajoute à l'historique
+// les favoris dont l'état final diffère de l'état initial
+func (r *Recorder) update(actor string, reverts int64, versions map[models.FavoriteKey]hlc.Timestamp, fn func(tx *storage.Tx) error) ([]Entry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.updateLocked(actor, reverts, versions, fn)
+}
+
+// updateLocked est update appelée avec le verrou d'écriture
+func (r *Recorder) updateLocked(actor string, reverts int64, versions map[models.FavoriteKey]hlc.Timestamp, fn func(tx *storage.Tx) error) ([]Entry, error) {
+	var changes []Entry
+	var put []models.Tombstone
+	var clear []models.FavoriteKey
+	err := r.FavoritesStore.Update(func(tx *storage.Tx) error {
+		if err := fn(tx); err != nil {
+			return err
+		}
+		changes = changeEntries(tx.Changes())
+		put, clear = r.stamp(tx, changes, versions)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if r.versioning.tombstones != nil {
+		if err := r.versioning.tombstones.Apply(put, clear); err != nil {
+			// Les favoris sont déjà enregistrés : ne pas faire échouer l'opération
+			log.Printf("Erreur lors de l'enregistrement des suppressions : %v", err)
+		}
+	}
+
+	if len(changes) == 0 {
+		if reverts != 0 {
+			// Les modifications du lot ont déjà été défaites : le marquer
+			// comme annulé pour ne pas le proposer indéfiniment
+			if err := r.log.markUndone(actor, reverts); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	}
+
+	entries, err := r.log.append(actor, reverts, changes)
+	if err != nil {
+		// Les favoris sont déjà enregistrés : ne pas faire échouer l'opération
+		log.Printf("Erreur lors de l'enregistrement de l'historique : %v", err)
+		entries = changes
+	}
+	for _, fn := range *r.observers {
+		fn(entries)
+	}
+	return entries, nil
+}
+
+// stamp horodate dans la transaction les favoris ajoutés ou modifiés et
+// renvoie les traces des favoris supprimés, ainsi que les favoris dont la
+// trace doit être effacée. Sans horodatage activé, ne fait rien.
+func (r *Recorder) stamp(tx *storage.Tx, changes []Entry, versions map[models.FavoriteKey]hlc.Timestamp) ([]models.Tombstone, []models.FavoriteKey) {
+	if r.versioning.clock == nil {
+		return nil, nil
+	}
+
+	var put []models.Tombstone
+	var clear []models.FavoriteKey
+	for i, change := range changes {
+		revision := r.versioning.clock.Now()
+		version, ok := versions[change.Key()]
+		if !ok {
+			version = revision
+		}
+
+		if change.New == nil {
+			put = append(put, models.Tombstone{
+				Type:      change.Type,
+				ID:        change.ID,
+				Version:   version,
+				Revision:  revision,
+				DeletedAt: time.Now(),
+			})
+			continue
+		}
+
+		item := *change.New
+		item.Version = version
+		item.Revision = revision
+		tx.Add(item)
+		changes[i].New = &item
+		clear = append(clear, change.Key())
+	}
+
+	// Une suppression reçue pour un favori absent, ou ajouté puis supprimé
+	// dans la même transaction, ne modifie aucun favori : conserver quand
+	// même sa trace pour écarter les modifications plus anciennes
+	changed := make(map[models.FavoriteKey]bool, len(changes))
+	for _, change := range changes {
+		changed[change.Key()] = true
+	}
+	for key, version := range versions {
+		if changed[key] || tx.Contains(key.ID, key.Type) {
+			continue
+		}
+		put = append(put, models.Tombstone{
+			Type:      key.Type,
+			ID:        key.ID,
+			Version:   version,
+			Revision:  r.versioning.clock.Now(),
+			DeletedAt: time.Now(),
+		})
+	}
+	return put, clear
+}
+
+// Undo annule la dernière opération et renvoie les modifications effectuées.
+// Les éléments supprimés retrouvent leur date d'ajout et leurs annotations.
+func (r *Recorder) Undo() ([]Entry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	batch := r.log.lastUndoable()
+	if len(batch) == 0 {
+		return nil, ErrNothingToUndo
+	}
+
+	entries, err := r.updateLocked(ActorUndo, batch[0].Batch, nil, func(tx *storage.Tx) error {
+		for i := len(batch) - 1; i >= 0; i-- {
+			revert(tx, batch[i])
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("annulation du lot %d : %w", batch[0].Batch, err)
+	}
+	log.Printf("Annulation du lot %d (%d modifications)", batch[0].Batch, len(entries))
+	if entries == nil {
+		// Lot déjà défait par ailleurs : il est marqué comme annulé
+		entries = []Entry{}
+	}
+	return entries, nil
+}
+
+// RestoreAt ramène les favoris à leur état à la date donnée en annulant,
+// du plus récent au plus ancien, toutes les modifications postérieures.
+// La restauration est elle-même enregistrée et peut être annulée.
+func (r *Recorder) RestoreAt(t time.Time) ([]Entry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	later := r.log.since(t)
+	entries, err := r.updateLocked(ActorRestore, 0, nil, func(tx *storage.Tx) error {
+		for i := len(later) - 1; i >= 0; i-- {
+			revert(tx, later[i])
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("restauration au %s : %w", t.Format(time.RFC3339), err)
+	}
+	log.Printf("Restauration des favoris au %s (%d modifications)", t.Format(time.RFC3339), len(entries))
+	return entries, nil
+}
+
+// revert rétablit dans la transaction l'état d'un favori avant une
+// modification. L'horodatage courant est conservé : un favori rétabli à
+// l'identique n'est pas considéré comme modifié.
+func revert(tx *storage.Tx, entry Entry) {
+	if entry.Old == nil {
+		tx.Remove(entry.ID, entry.Type)
+		return
+	}
+	item := *entry.Old
+	if current, ok := tx.Get(entry.ID, entry.Type); ok {
+		item.Version, item.Revision = current.Version, current.Revision
+	}
+	tx.Add(item)
+}
@@ -0,0 +1,153 @@
+// Package hlc implémente des horloges logiques hybrides (HLC) : des
+// horodatages proches de l'heure réelle, mais qui respectent l'ordre causal
+// des événements entre appareils dont les horloges ne sont pas synchronisées.
+package hlc
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultMaxDrift est l'avance maximale acceptée d'un horodatage reçu sur
+// l'horloge locale
+const DefaultMaxDrift = 5 * time.Minute
+
+// ErrDrift est renvoyée pour un horodatage trop en avance sur l'horloge locale
+var ErrDrift = errors.New("horodatage trop en avance sur l'horloge du serveur")
+
+// Timestamp est un horodatage sérialisé sous la forme
+// <millisecondes>-<compteur>-<nœud> : la partie physique en millisecondes
+// depuis l'époque Unix (12 chiffres hexadécimaux), le compteur logique
+// (6 chiffres hexadécimaux) et l'identifiant du nœud qui l'a émis. Les
+// parties numériques étant de largeur fixe, l'ordre des chaînes est l'ordre
+// des horodatages ; le nœud départage deux horodatages simultanés. La
+// valeur vide précède tous les horodatages.
+type Timestamp string
+
+// pattern valide la forme sérialisée d'un horodatage
+var pattern = regexp.MustCompile(`^([0-9a-f]{12})-([0-9a-f]{6})-([A-Za-z0-9_.]{1,64})$`)
+
+// maxLogical est la valeur maximale du compteur logique
+const maxLogical = 0xffffff
+
+// New construit un horodatage
+func New(wall int64, logical int, node string) Timestamp {
+	return Timestamp(fmt.Sprintf("%012x-%06x-%s", wall, logical, node))
+}
+
+// Parse valide un horodatage reçu
+func Parse(value string) (Timestamp, error) {
+	if !pattern.MatchString(value) {
+		return "", fmt.Errorf("horodatage invalide : %q", value)
+	}
+	return Timestamp(value), nil
+}
+
+// parts renvoie la partie physique et le compteur logique d'un horodatage
+// valide ; la valeur vide renvoie zéro
+func (t Timestamp) parts() (wall int64, logical int) {
+	match := pattern.FindStringSubmatch(string(t))
+	if match == nil {
+		return 0, 0
+	}
+	wall, _ = strconv.ParseInt(match[1], 16, 64)
+	parsed, _ := strconv.ParseInt(match[2], 16, 64)
+	return wall, int(parsed)
+}
+
+// Time renvoie la partie physique de l'horodatage
+func (t Timestamp) Time() time.Time {
+	wall, _ := t.parts()
+	return time.UnixMilli(wall)
+}
+
+// IsZero indique si l'horodatage est vide
+func (t Timestamp) IsZero() bool {
+	return t == ""
+}
+
+// After indique si t est postérieur à other
+func (t Timestamp) After(other Timestamp) bool {
+	return t > other
+}
+
+// Max renvoie le plus récent des horodatages
+func Max(a, b Timestamp) Timestamp {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// Clock est une horloge logique hybride, sûre pour un usage concurrent
+type Clock struct {
+	node string
+	// MaxDrift est l'avance maximale acceptée par Observe
+	MaxDrift time.Duration
+	// now renvoie l'heure physique
+	now func() time.Time
+
+	mu      sync.Mutex
+	wall    int64
+	logical int
+}
+
+// NewClock crée une horloge pour le nœud donné
+func NewClock(node string) *Clock {
+	return &Clock{node: node, MaxDrift: DefaultMaxDrift, now: time.Now}
+}
+
+// Now renvoie un horodatage pour un événement local, postérieur à tous ceux
+// déjà émis ou observés par l'horloge
+func (c *Clock) Now() Timestamp {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.advance(c.now().UnixMilli(), 0, 0)
+	return New(c.wall, c.logical, c.node)
+}
+
+// Observe avance l'horloge après un horodatage reçu d'un autre nœud, pour
+// que les événements locaux suivants lui soient postérieurs. Un horodatage
+// en avance de plus de MaxDrift est refusé.
+func (c *Clock) Observe(t Timestamp) error {
+	wall, logical := t.parts()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	physical := c.now().UnixMilli()
+	if c.MaxDrift > 0 && wall-physical > c.MaxDrift.Milliseconds() {
+		return fmt.Errorf("%w : %s", ErrDrift, t)
+	}
+	c.advance(physical, wall, logical)
+	return nil
+}
+
+// advance applique l'algorithme HLC avec l'heure physique et un horodatage
+// observé (zéro pour un événement local). Doit être appelée avec c.mu détenu.
+func (c *Clock) advance(physical, wall int64, logical int) {
+	latest := max(c.wall, wall, physical)
+	switch {
+	case latest == c.wall && latest == wall:
+		c.logical = max(c.logical, logical) + 1
+	case latest == c.wall:
+		c.logical++
+	case latest == wall:
+		c.logical = logical + 1
+	default:
+		c.logical = 0
+	}
+	c.wall = latest
+
+	// Le compteur déborde seulement si plus de 16 millions d'événements
+	// partagent la même milliseconde : avancer la partie physique
+	if c.logical > maxLogical {
+		c.wall++
+		c.logical = 0
+	}
+}
@@ -0,0 +1,157 @@
+package hlc
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// fixedClock crée une horloge dont l'heure physique est lue dans *now
+func fixedClock(node string, now *time.Time) *Clock {
+	c := NewClock(node)
+	c.now = func() time.Time { return *now }
+	return c
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		value string
+		valid bool
+	}{
+		{"0190a1b2c3d4-000000-server", true},
+		{"0190a1b2c3d4-00002a-phone_1.local", true},
+		{"", false},
+		{"0190a1b2c3d4-000000-", false},
+		{"190a1b2c3d4-000000-server", false},
+		{"0190A1B2C3D4-000000-server", false},
+		{"0190a1b2c3d4-0000000-server", false},
+		{"0190a1b2c3d4-000000-serveur distant", false},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.value)
+		if tt.valid && (err != nil || string(got) != tt.value) {
+			t.Errorf("Parse(%q) = %q, %v ; attendu valide", tt.value, got, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("Parse(%q) : erreur attendue", tt.value)
+		}
+	}
+}
+
+func TestTimestampOrder(t *testing.T) {
+	tests := []struct {
+		name  string
+		later Timestamp
+		early Timestamp
+	}{
+		{"partie physique", New(2, 0, "a"), New(1, 99, "z")},
+		{"partie physique sur plusieurs chiffres", New(0x100, 0, "a"), New(0xff, 0, "a")},
+		{"compteur logique", New(1, 2, "a"), New(1, 1, "z")},
+		{"compteur sur plusieurs chiffres", New(1, 0x10, "a"), New(1, 0xf, "a")},
+		{"nœud départageant deux horodatages simultanés", New(1, 1, "b"), New(1, 1, "a")},
+		{"horodatage vide", New(0, 0, "a"), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !tt.later.After(tt.early) || tt.early.After(tt.later) {
+				t.Errorf("%q devrait suivre %q", tt.later, tt.early)
+			}
+			if got := Max(tt.early, tt.later); got != tt.later {
+				t.Errorf("Max = %q, attendu %q", got, tt.later)
+			}
+		})
+	}
+}
+
+func TestClockNow(t *testing.T) {
+	now := time.UnixMilli(1000)
+	c := fixedClock("server", &now)
+
+	tests := []struct {
+		name string
+		at   int64
+		want Timestamp
+	}{
+		{"premier événement", 1000, New(1000, 0, "server")},
+		{"même milliseconde", 1000, New(1000, 1, "server")},
+		{"heure qui avance", 1005, New(1005, 0, "server")},
+		{"heure qui recule", 900, New(1005, 1, "server")},
+	}
+
+	var previous Timestamp
+	for _, tt := range tests {
+		now = time.UnixMilli(tt.at)
+		got := c.Now()
+		if got != tt.want {
+			t.Errorf("%s : Now() = %q, attendu %q", tt.name, got, tt.want)
+		}
+		if !got.After(previous) {
+			t.Errorf("%s : %q ne suit pas %q", tt.name, got, previous)
+		}
+		previous = got
+	}
+}
+
+func TestClockObserve(t *testing.T) {
+	tests := []struct {
+		name     string
+		physical int64
+		local    int
+		observed Timestamp
+		want     Timestamp
+		err      error
+	}{
+		{
+			name:     "horodatage reçu en retard",
+			physical: 2000,
+			observed: New(1000, 5, "phone"),
+			want:     New(2000, 1, "server"),
+		},
+		{
+			name:     "horodatage reçu en avance",
+			physical: 2000,
+			observed: New(3000, 5, "phone"),
+			want:     New(3000, 7, "server"),
+		},
+		{
+			name:     "même partie physique que l'horloge",
+			physical: 2000,
+			local:    3,
+			observed: New(2000, 1, "phone"),
+			want:     New(2000, 4, "server"),
+		},
+		{
+			name:     "avance au-delà de la dérive maximale",
+			physical: 2000,
+			observed: New(2000+DefaultMaxDrift.Milliseconds()+1, 0, "phone"),
+			want:     New(2000, 0, "server"),
+			err:      ErrDrift,
+		},
+		{
+			name:     "débordement du compteur",
+			physical: 2000,
+			observed: New(2000, maxLogical, "phone"),
+			want:     New(2001, 1, "server"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.UnixMilli(tt.physical)
+			c := fixedClock("server", &now)
+			for i := 0; i < tt.local; i++ {
+				c.Now()
+			}
+
+			err := c.Observe(tt.observed)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Observe = %v, attendu %v", err, tt.err)
+			}
+			if got := c.Now(); got != tt.want {
+				t.Errorf("Now() = %q, attendu %q", got, tt.want)
+			}
+		})
+	}
+}
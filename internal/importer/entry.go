@@ -0,0 +1,237 @@
+// Package importer lit des bibliothèques exportées par d'autres outils
+// (Last.fm, M3U, XSPF, iTunes, listes « Artiste - Titre »), associe chaque
+// entrée à un élément Spotify et produit un rapport à valider avant l'ajout
+// aux favoris.
+package importer
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/yourusername/melody-explorer/internal/models"
+)
+
+// Formats d'import reconnus
+const (
+	FormatLastFM = "lastfm"
+	FormatM3U    = "m3u"
+	FormatXSPF   = "xspf"
+	FormatITunes = "itunes"
+	FormatText   = "text"
+)
+
+// MaxEntries est le nombre maximal d'entrées distinctes par import
+const MaxEntries = 2000
+
+// Entry est une entrée lue dans un fichier importé
+type Entry struct {
+	Line       int    `json:"line"`
+	Artist     string `json:"artist,omitempty"`
+	Album      string `json:"album,omitempty"`
+	Title      string `json:"title,omitempty"`
+	DurationMs int    `json:"duration_ms,omitempty"`
+	// URI est l'URI ou le lien Spotify présent dans le fichier, le cas échéant
+	URI string `json:"uri,omitempty"`
+	// Count est le nombre d'occurrences de l'entrée (écoutes Last.fm, doublons)
+	Count int `json:"count"`
+}
+
+// Type renvoie le type d'élément recherché : une piste si un titre est connu,
+// sinon un album, sinon un artiste
+func (e Entry) Type() models.FavoriteType {
+	if itemType, _, ok := parseSpotifyURI(e.URI); ok {
+		return itemType
+	}
+	switch {
+	case e.Title != "":
+		return models.FavoriteTypeTrack
+	case e.Album != "":
+		return models.FavoriteTypeAlbum
+	default:
+		return models.FavoriteTypeArtist
+	}
+}
+
+// String renvoie une description lisible de l'entrée
+func (e Entry) String() string {
+	var parts []string
+	if e.Artist != "" {
+		parts = append(parts, e.Artist)
+	}
+	if e.Title != "" {
+		parts = append(parts, e.Title)
+	} else if e.Album != "" {
+		parts = append(parts, e.Album)
+	}
+	if len(parts) == 0 {
+		return e.URI
+	}
+	return strings.Join(parts, " - ")
+}
+
+// key renvoie la clé de dédoublonnage de l'entrée
+func (e Entry) key() string {
+	if e.URI != "" {
+		return e.URI
+	}
+	return normalize(e.Artist) + "\x00" + normalize(e.Album) + "\x00" + normalize(e.Title)
+}
+
+// valid indique si l'entrée contient de quoi lancer une recherche
+func (e Entry) valid() bool {
+	return e.URI != "" || e.Artist != "" || e.Title != "" || e.Album != ""
+}
+
+// Parse lit les entrées d'un fichier dans le format donné, ou dans le format
+// détecté à partir du nom et du contenu si format est vide. Les doublons sont
+// regroupés et le format effectivement utilisé est renvoyé.
+func Parse(filename, format string, data []byte) ([]Entry, string, error) {
+	if format == "" {
+		format = Detect(filename, data)
+	}
+
+	var entries []Entry
+	var err error
+	switch format {
+	case FormatLastFM:
+		entries, err = parseLastFM(data)
+	case FormatM3U:
+		entries, err = parseM3U(data)
+	case FormatXSPF:
+		entries, err = parseXSPF(data)
+	case FormatITunes:
+		entries, err = parseITunes(data)
+	case FormatText:
+		entries, err = parseText(data)
+	default:
+		return nil, format, fmt.Errorf("format d'import inconnu : %q", format)
+	}
+	if err != nil {
+		return nil, format, fmt.Errorf("lecture du fichier %s : %w", format, err)
+	}
+
+	entries = dedupe(entries)
+	if len(entries) == 0 {
+		return nil, format, fmt.Errorf("aucune entrée reconnue dans le fichier")
+	}
+	if len(entries) > MaxEntries {
+		return nil, format, fmt.Errorf("trop d'entrées (%d), %d au maximum par import", len(entries), MaxEntries)
+	}
+	return entries, format, nil
+}
+
+// Detect devine le format d'un fichier à partir de son extension, puis de son contenu
+func Detect(filename string, data []byte) string {
+	head := bytes.TrimSpace(data)
+	if len(head) > 4096 {
+		head = head[:4096]
+	}
+	isPlist := bytes.Contains(head, []byte("<plist"))
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return FormatLastFM
+	case ".m3u", ".m3u8":
+		return FormatM3U
+	case ".xspf":
+		return FormatXSPF
+	case ".xml":
+		if isPlist {
+			return FormatITunes
+		}
+		return FormatXSPF
+	case ".txt":
+		return FormatText
+	}
+
+	switch {
+	case bytes.HasPrefix(head, []byte("#EXTM3U")):
+		return FormatM3U
+	case isPlist:
+		return FormatITunes
+	case bytes.Contains(head, []byte("<playlist")):
+		return FormatXSPF
+	case looksLikeCSV(head):
+		return FormatLastFM
+	default:
+		return FormatText
+	}
+}
+
+// looksLikeCSV indique si la première ligne contient au moins trois champs
+// séparés par des virgules
+func looksLikeCSV(head []byte) bool {
+	line, _, _ := bytes.Cut(head, []byte("\n"))
+	return bytes.Count(line, []byte(",")) >= 2
+}
+
+// dedupe regroupe les entrées identiques en conservant la première occurrence
+func dedupe(entries []Entry) []Entry {
+	index := make(map[string]int, len(entries))
+	result := make([]Entry, 0, len(entries))
+	for _, entry := range entries {
+		entry.Artist = strings.TrimSpace(entry.Artist)
+		entry.Album = strings.TrimSpace(entry.Album)
+		entry.Title = strings.TrimSpace(entry.Title)
+		if !entry.valid() {
+			continue
+		}
+		if i, ok := index[entry.key()]; ok {
+			result[i].Count++
+			continue
+		}
+		entry.Count = 1
+		index[entry.key()] = len(result)
+		result = append(result, entry)
+	}
+	return result
+}
+
+// parseSpotifyURI extrait le type et l'identifiant d'une URI spotify:type:id
+// ou d'un lien https://open.spotify.com/type/id
+func parseSpotifyURI(value string) (models.FavoriteType, string, bool) {
+	value = strings.TrimSpace(value)
+	var kind, id string
+	switch {
+	case strings.HasPrefix(value, "spotify:"):
+		parts := strings.Split(value, ":")
+		if len(parts) != 3 {
+			return "", "", false
+		}
+		kind, id = parts[1], parts[2]
+	case strings.Contains(value, "open.spotify.com/"):
+		_, path, _ := strings.Cut(value, "open.spotify.com/")
+		path, _, _ = strings.Cut(path, "?")
+		parts := strings.Split(strings.Trim(path, "/"), "/")
+		// Les liens localisés sont préfixés par la langue (intl-fr/track/…)
+		if len(parts) == 3 && strings.HasPrefix(parts[0], "intl-") {
+			parts = parts[1:]
+		}
+		if len(parts) != 2 {
+			return "", "", false
+		}
+		kind, id = parts[0], parts[1]
+	default:
+		return "", "", false
+	}
+
+	itemType, ok := models.ParseFavoriteType(kind)
+	if !ok || id == "" {
+		return "", "", false
+	}
+	return itemType, id, true
+}
+
+// splitArtistTitle découpe une chaîne « Artiste - Titre » (tiret simple,
+// demi-cadratin ou cadratin). Sans séparateur, la chaîne entière est renvoyée
+// comme titre.
+func splitArtistTitle(value string) (string, string) {
+	for _, sep := range []string{" - ", " – ", " — "} {
+		if artist, title, ok := strings.Cut(value, sep); ok {
+			return strings.TrimSpace(artist), strings.TrimSpace(title)
+		}
+	}
+	return "", strings.TrimSpace(value)
+}
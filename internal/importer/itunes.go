@@ -0,0 +1,160 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// parseITunes lit la bibliothèque XML exportée par iTunes ou Apple Music
+// (format plist). Les podcasts, films et séries sont ignorés.
+func parseITunes(data []byte) ([]Entry, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	root, err := decodePlist(decoder)
+	if err != nil {
+		return nil, err
+	}
+
+	library, ok := root.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("bibliothèque iTunes invalide")
+	}
+	tracks, ok := library["Tracks"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("aucune piste dans la bibliothèque iTunes")
+	}
+
+	// Conserver l'ordre des identifiants de piste
+	ids := make([]string, 0, len(tracks))
+	for id := range tracks {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, errA := strconv.Atoi(ids[i])
+		b, errB := strconv.Atoi(ids[j])
+		if errA != nil || errB != nil {
+			return ids[i] < ids[j]
+		}
+		return a < b
+	})
+
+	entries := make([]Entry, 0, len(ids))
+	for i, id := range ids {
+		track, ok := tracks[id].(map[string]interface{})
+		if !ok || isVideoOrPodcast(track) {
+			continue
+		}
+
+		artist := plistString(track, "Artist")
+		if artist == "" {
+			artist = plistString(track, "Album Artist")
+		}
+		duration, _ := strconv.Atoi(plistString(track, "Total Time"))
+
+		entries = append(entries, Entry{
+			Line:       i + 1,
+			Artist:     artist,
+			Album:      plistString(track, "Album"),
+			Title:      plistString(track, "Name"),
+			DurationMs: duration,
+		})
+	}
+	return entries, nil
+}
+
+// isVideoOrPodcast indique si une piste iTunes n'est pas un morceau de musique
+func isVideoOrPodcast(track map[string]interface{}) bool {
+	for _, key := range []string{"Podcast", "Movie", "TV Show", "Music Video", "Has Video"} {
+		if value, ok := track[key].(bool); ok && value {
+			return true
+		}
+	}
+	return false
+}
+
+// plistString renvoie une valeur textuelle d'un dictionnaire plist
+func plistString(dict map[string]interface{}, key string) string {
+	value, _ := dict[key].(string)
+	return value
+}
+
+// decodePlist lit le premier élément d'un document plist. Les dictionnaires
+// deviennent des map, les tableaux des slices, les booléens des bool et les
+// autres valeurs (chaînes, nombres, dates) des chaînes.
+func decodePlist(decoder *xml.Decoder) (interface{}, error) {
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil, fmt.Errorf("document plist vide")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if start, ok := token.(xml.StartElement); ok && start.Name.Local != "plist" {
+			return decodePlistValue(decoder, start)
+		}
+	}
+}
+
+// decodePlistValue lit la valeur dont l'élément ouvrant vient d'être lu
+func decodePlistValue(decoder *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	switch start.Name.Local {
+	case "dict":
+		dict := make(map[string]interface{})
+		var key string
+		for {
+			token, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch t := token.(type) {
+			case xml.StartElement:
+				if t.Name.Local == "key" {
+					if err := decoder.DecodeElement(&key, &t); err != nil {
+						return nil, err
+					}
+					continue
+				}
+				value, err := decodePlistValue(decoder, t)
+				if err != nil {
+					return nil, err
+				}
+				dict[key] = value
+			case xml.EndElement:
+				return dict, nil
+			}
+		}
+	case "array":
+		var array []interface{}
+		for {
+			token, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch t := token.(type) {
+			case xml.StartElement:
+				value, err := decodePlistValue(decoder, t)
+				if err != nil {
+					return nil, err
+				}
+				array = append(array, value)
+			case xml.EndElement:
+				return array, nil
+			}
+		}
+	case "true", "false":
+		if err := decoder.Skip(); err != nil {
+			return nil, err
+		}
+		return start.Name.Local == "true", nil
+	default:
+		var value string
+		if err := decoder.DecodeElement(&value, &start); err != nil {
+			return nil, err
+		}
+		return value, nil
+	}
+}
@@ -0,0 +1,79 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io"
+	"strings"
+)
+
+// lastFMColumns associe les noms de colonnes reconnus aux champs d'une entrée
+var lastFMColumns = map[string]string{
+	"artist":      "artist",
+	"artist_name": "artist",
+	"album":       "album",
+	"album_name":  "album",
+	"track":       "title",
+	"track_name":  "title",
+	"title":       "title",
+	"name":        "title",
+}
+
+// parseLastFM lit un export CSV des écoutes Last.fm. Avec une ligne d'en-tête,
+// les colonnes sont identifiées par leur nom ; sans en-tête, l'ordre
+// artiste, album, titre, date des exports courants est utilisé.
+func parseLastFM(data []byte) ([]Entry, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	columns := map[string]int{"artist": 0, "album": 1, "title": 2}
+	var entries []Entry
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if line == 1 {
+			if header, ok := lastFMHeader(record); ok {
+				columns = header
+				continue
+			}
+		}
+
+		entries = append(entries, Entry{
+			Line:   line,
+			Artist: field(record, columns, "artist"),
+			Album:  field(record, columns, "album"),
+			Title:  field(record, columns, "title"),
+		})
+	}
+	return entries, nil
+}
+
+// lastFMHeader reconnaît une ligne d'en-tête et renvoie la position des colonnes
+func lastFMHeader(record []string) (map[string]int, bool) {
+	columns := make(map[string]int)
+	for i, name := range record {
+		if column, ok := lastFMColumns[strings.ToLower(strings.TrimSpace(name))]; ok {
+			if _, seen := columns[column]; !seen {
+				columns[column] = i
+			}
+		}
+	}
+	_, hasArtist := columns["artist"]
+	return columns, hasArtist
+}
+
+// field renvoie la valeur d'une colonne ou une chaîne vide
+func field(record []string, columns map[string]int, name string) string {
+	i, ok := columns[name]
+	if !ok || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
@@ -0,0 +1,92 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// trackNumberPrefix correspond aux numéros de piste en tête des noms de fichiers
+var trackNumberPrefix = regexp.MustCompile(`^\d{1,3}[\s.\-_]+`)
+
+// parseM3U lit une liste de lecture M3U ou M3U8. Les informations #EXTINF
+// sont utilisées si elles sont présentes, sinon l'artiste et le titre sont
+// déduits du chemin du fichier (Artiste/Album/01 Titre.mp3 ou
+// « Artiste - Titre.mp3 »). Les URI et liens Spotify sont conservés tels quels.
+func parseM3U(data []byte) ([]Entry, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var entries []Entry
+	var pending *Entry
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		switch {
+		case text == "":
+			continue
+		case strings.HasPrefix(text, "#EXTINF:"):
+			entry := parseExtInf(strings.TrimPrefix(text, "#EXTINF:"))
+			entry.Line = line
+			pending = &entry
+			continue
+		case strings.HasPrefix(text, "#"):
+			continue
+		}
+
+		var entry Entry
+		_, _, isURI := parseSpotifyURI(text)
+		switch {
+		case pending != nil:
+			entry = *pending
+		case isURI:
+			entry = Entry{Line: line}
+		default:
+			entry = entryFromPath(text)
+			entry.Line = line
+		}
+		pending = nil
+		if isURI {
+			entry.URI = text
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// parseExtInf lit « durée,Artiste - Titre » ; la durée est en secondes, -1 si inconnue
+func parseExtInf(value string) Entry {
+	durationText, title, _ := strings.Cut(value, ",")
+	// La durée peut être suivie d'attributs (tvg-id="…") séparés par des espaces
+	durationText, _, _ = strings.Cut(strings.TrimSpace(durationText), " ")
+
+	var entry Entry
+	if seconds, err := strconv.Atoi(durationText); err == nil && seconds > 0 {
+		entry.DurationMs = seconds * 1000
+	}
+	entry.Artist, entry.Title = splitArtistTitle(title)
+	return entry
+}
+
+// entryFromPath déduit l'artiste, l'album et le titre d'un chemin de fichier
+func entryFromPath(location string) Entry {
+	location = strings.ReplaceAll(location, `\`, "/")
+	dir, file := path.Split(location)
+	name := strings.TrimSuffix(file, path.Ext(file))
+	name = trackNumberPrefix.ReplaceAllString(name, "")
+
+	var entry Entry
+	entry.Artist, entry.Title = splitArtistTitle(name)
+	if entry.Artist != "" {
+		return entry
+	}
+
+	// Arborescence Artiste/Album/Titre
+	dirs := strings.Split(strings.Trim(dir, "/"), "/")
+	if n := len(dirs); n >= 2 {
+		entry.Artist, entry.Album = dirs[n-2], dirs[n-1]
+	}
+	return entry
+}
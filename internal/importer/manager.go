@@ -0,0 +1,289 @@
+package importer
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/yourusername/melody-explorer/internal/models"
+	"github.com/yourusername/melody-explorer/internal/storage"
+)
+
+// ErrReportNotFound est renvoyée lorsque le rapport d'import n'existe pas ou a expiré
+var ErrReportNotFound = errors.New("rapport d'import introuvable")
+
+// ErrNotReady est renvoyée lorsqu'un import est validé avant la fin de la
+// correspondance ou après avoir déjà été validé
+var ErrNotReady = errors.New("l'import n'est pas prêt à être validé")
+
+// reportTTL est la durée de conservation des rapports en mémoire
+const reportTTL = 24 * time.Hour
+
+// maxReports est le nombre maximal de rapports conservés en mémoire
+const maxReports = 20
+
+// Selection désigne le candidat retenu pour une entrée du rapport
+type Selection struct {
+	Index int    `json:"index"`
+	ID    string `json:"id"`
+}
+
+// CommitResult résume l'ajout aux favoris d'un import validé
+type CommitResult struct {
+	Added   int `json:"added"`
+	Skipped int `json:"skipped"`
+}
+
+// Manager conserve les rapports d'import en mémoire, calcule les
+// correspondances en arrière-plan et ajoute aux favoris les éléments validés
+type Manager struct {
+	matcher   *Matcher
+	favorites storage.FavoritesStore
+
+	reports map[string]*Report
+	mu      sync.Mutex
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewManager crée un gestionnaire d'imports
+func NewManager(matcher *Matcher, favorites storage.FavoritesStore) *Manager {
+	return &Manager{
+		matcher:   matcher,
+		favorites: favorites,
+		reports:   make(map[string]*Report),
+		stop:      make(chan struct{}),
+	}
+}
+
+// Close interrompt les correspondances en cours
+func (m *Manager) Close() {
+	close(m.stop)
+	m.wg.Wait()
+}
+
+// Start lit un fichier et lance la recherche des correspondances en
+// arrière-plan. Le rapport renvoyé est à l'état « matching ».
+func (m *Manager) Start(filename, format string, data []byte) (Report, error) {
+	entries, format, err := Parse(filename, format, data)
+	if err != nil {
+		return Report{}, err
+	}
+
+	report := &Report{
+		ID:        newReportID(),
+		Filename:  filename,
+		Format:    format,
+		Status:    StatusMatching,
+		CreatedAt: time.Now(),
+		Matches:   make([]Match, len(entries)),
+	}
+	for i, entry := range entries {
+		report.Matches[i] = Match{Index: i, Entry: entry}
+	}
+	report.summarize()
+
+	m.mu.Lock()
+	m.prune()
+	m.reports[report.ID] = report
+	snapshot := report.clone()
+	m.mu.Unlock()
+
+	log.Printf("Import %s : %d entrées (%s) depuis %s", report.ID, len(entries), format, filename)
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		m.match(report.ID, entries)
+	}()
+
+	return snapshot, nil
+}
+
+// Get renvoie une copie du rapport
+func (m *Manager) Get(id string) (Report, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	report, ok := m.reports[id]
+	if !ok {
+		return Report{}, false
+	}
+	return report.clone(), true
+}
+
+// Discard abandonne un import et indique s'il existait. Une correspondance en
+// cours s'arrête à l'entrée suivante.
+func (m *Manager) Discard(id string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.reports[id]; !ok {
+		return false
+	}
+	delete(m.reports, id)
+	return true
+}
+
+// Commit ajoute aux favoris les candidats choisis en une seule transaction.
+// Sans sélection, les meilleures correspondances retenues par défaut sont
+// ajoutées. Les éléments déjà en favori sont ignorés.
+func (m *Manager) Commit(id string, selections []Selection) (CommitResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	report, ok := m.reports[id]
+	if !ok {
+		return CommitResult{}, ErrReportNotFound
+	}
+	if report.Status != StatusReady {
+		return CommitResult{}, ErrNotReady
+	}
+
+	if selections == nil {
+		for _, match := range report.Matches {
+			if best := match.Best(); match.Selected && best != nil {
+				selections = append(selections, Selection{Index: match.Index, ID: best.Item.ID})
+			}
+		}
+	}
+
+	var items []models.FavoriteItem
+	for _, selection := range selections {
+		if selection.Index < 0 || selection.Index >= len(report.Matches) {
+			return CommitResult{}, errors.New("entrée inconnue : " + strconv.Itoa(selection.Index))
+		}
+		candidate, ok := report.Matches[selection.Index].candidate(selection.ID)
+		if !ok {
+			return CommitResult{}, errors.New("candidat inconnu pour l'entrée " + strconv.Itoa(selection.Index))
+		}
+		items = append(items, candidate.Item)
+	}
+
+	var result CommitResult
+	now := time.Now()
+	err := m.favorites.Update(func(tx *storage.Tx) error {
+		for _, item := range items {
+			if tx.Contains(item.ID, item.Type) {
+				result.Skipped++
+				continue
+			}
+			item.AddedAt = now
+			if item.Metadata != nil {
+				item.LastRefreshedAt = &now
+			}
+			tx.Add(item)
+			result.Added++
+		}
+		return nil
+	})
+	if err != nil {
+		return CommitResult{}, err
+	}
+
+	report.Status = StatusCommitted
+	report.CommittedAt = &now
+	report.Added = result.Added
+	log.Printf("Import %s validé : %d favoris ajoutés, %d déjà présents", id, result.Added, result.Skipped)
+	return result, nil
+}
+
+// match calcule les correspondances des entrées une par une
+func (m *Manager) match(id string, entries []Entry) {
+	for i, entry := range entries {
+		select {
+		case <-m.stop:
+			m.finish(id, errors.New("import interrompu par l'arrêt du serveur"))
+			return
+		default:
+		}
+
+		result := Match{Index: i, Entry: entry, Confidence: ConfidenceNone}
+		candidates, err := m.matcher.Match(entry)
+		if err != nil {
+			result.Error = err.Error()
+		} else if len(candidates) > 0 {
+			best := candidates[0]
+			result.Candidates = candidates
+			result.Confidence = confidenceFor(best.Score)
+			if entry.URI != "" {
+				result.Confidence = ConfidenceExact
+			}
+			result.AlreadyFavorite = m.favorites.Contains(best.Item.ID, best.Item.Type)
+			result.Selected = !result.AlreadyFavorite && (result.Confidence == ConfidenceExact ||
+				result.Confidence == ConfidenceHigh || result.Confidence == ConfidenceMedium)
+		}
+
+		m.mu.Lock()
+		report, ok := m.reports[id]
+		if ok {
+			report.Matches[i] = result
+			report.summarize()
+		}
+		m.mu.Unlock()
+		if !ok {
+			// Import abandonné
+			return
+		}
+	}
+	m.finish(id, nil)
+}
+
+// finish marque la fin de la correspondance d'un import
+func (m *Manager) finish(id string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	report, ok := m.reports[id]
+	if !ok {
+		return
+	}
+	now := time.Now()
+	report.FinishedAt = &now
+	report.Status = StatusReady
+	if err != nil {
+		report.Status = StatusFailed
+		report.Error = err.Error()
+	}
+	report.summarize()
+	log.Printf("Import %s : correspondances terminées (%d/%d)", id, report.Summary.Done, report.Summary.Total)
+}
+
+// prune supprime les rapports expirés et les plus anciens au-delà de maxReports.
+// Doit être appelée avec le verrou.
+func (m *Manager) prune() {
+	cutoff := time.Now().Add(-reportTTL)
+	var reports []*Report
+	for id, report := range m.reports {
+		if report.CreatedAt.Before(cutoff) {
+			delete(m.reports, id)
+			continue
+		}
+		reports = append(reports, report)
+	}
+
+	if len(reports) < maxReports {
+		return
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].CreatedAt.Before(reports[j].CreatedAt)
+	})
+	for _, report := range reports[:len(reports)-maxReports+1] {
+		delete(m.reports, report.ID)
+	}
+}
+
+// newReportID génère un identifiant de rapport aléatoire
+func newReportID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,203 @@
+package importer
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/yourusername/melody-explorer/internal/enrich"
+	"github.com/yourusername/melody-explorer/internal/models"
+	"github.com/yourusername/melody-explorer/internal/spotify"
+)
+
+// Confidence est le niveau de confiance d'une correspondance
+type Confidence string
+
+// Niveaux de confiance, du plus sûr au moins sûr
+const (
+	ConfidenceExact  Confidence = "exact"
+	ConfidenceHigh   Confidence = "high"
+	ConfidenceMedium Confidence = "medium"
+	ConfidenceLow    Confidence = "low"
+	ConfidenceNone   Confidence = "none"
+)
+
+// confidenceFor convertit un score en niveau de confiance
+func confidenceFor(score float64) Confidence {
+	switch {
+	case score >= 0.9:
+		return ConfidenceHigh
+	case score >= 0.75:
+		return ConfidenceMedium
+	case score >= 0.5:
+		return ConfidenceLow
+	default:
+		return ConfidenceNone
+	}
+}
+
+// searchLimit est le nombre de résultats examinés par recherche
+const searchLimit = 10
+
+// maxAlternatives est le nombre de correspondances proposées en plus de la meilleure
+const maxAlternatives = 3
+
+// Candidate est un élément Spotify proposé pour une entrée
+type Candidate struct {
+	Item  models.FavoriteItem `json:"item"`
+	Score float64             `json:"score"`
+}
+
+// Matcher associe des entrées importées à des éléments Spotify
+type Matcher struct {
+	client *spotify.Client
+}
+
+// NewMatcher crée un outil de correspondance utilisant la recherche Spotify
+func NewMatcher(client *spotify.Client) *Matcher {
+	return &Matcher{client: client}
+}
+
+// Match renvoie les candidats d'une entrée, du plus probable au moins probable
+func (m *Matcher) Match(entry Entry) ([]Candidate, error) {
+	if itemType, id, ok := parseSpotifyURI(entry.URI); ok {
+		snapshot, err := enrich.Fetch(m.client, itemType, id)
+		if err != nil {
+			return nil, err
+		}
+		return []Candidate{{Item: candidateItem(itemType, id, snapshot), Score: 1}}, nil
+	}
+
+	itemType := entry.Type()
+	candidates, err := m.search(itemType, fieldQuery(entry))
+	if err != nil {
+		return nil, err
+	}
+	// Les filtres de champ sont stricts : réessayer en recherche libre
+	if len(candidates) == 0 {
+		if candidates, err = m.search(itemType, freeQuery(entry)); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range candidates {
+		candidates[i].Score = score(entry, candidates[i].Item)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Score > candidates[j].Score
+	})
+	if len(candidates) > maxAlternatives+1 {
+		candidates = candidates[:maxAlternatives+1]
+	}
+	return candidates, nil
+}
+
+// search interroge Spotify et convertit les résultats en candidats
+func (m *Matcher) search(itemType models.FavoriteType, query string) ([]Candidate, error) {
+	if query == "" {
+		return nil, nil
+	}
+	results, err := m.client.Search(query, []string{string(itemType)}, searchLimit, 0)
+	if err != nil {
+		return nil, fmt.Errorf("recherche %q : %w", query, err)
+	}
+
+	var candidates []Candidate
+	switch {
+	case itemType == models.FavoriteTypeArtist && results.Artists != nil:
+		for i := range results.Artists.Items {
+			artist := &results.Artists.Items[i]
+			candidates = append(candidates, Candidate{Item: candidateItem(itemType, artist.ID, enrich.ArtistSnapshot(artist))})
+		}
+	case itemType == models.FavoriteTypeAlbum && results.Albums != nil:
+		for i := range results.Albums.Items {
+			album := &results.Albums.Items[i]
+			candidates = append(candidates, Candidate{Item: candidateItem(itemType, album.ID, enrich.AlbumSnapshot(album))})
+		}
+	case itemType == models.FavoriteTypeTrack && results.Tracks != nil:
+		for i := range results.Tracks.Items {
+			track := &results.Tracks.Items[i]
+			candidates = append(candidates, Candidate{Item: candidateItem(itemType, track.ID, enrich.TrackSnapshot(track))})
+		}
+	}
+	return candidates, nil
+}
+
+// candidateItem construit le favori correspondant à un instantané Spotify
+func candidateItem(itemType models.FavoriteType, id string, snapshot enrich.Snapshot) models.FavoriteItem {
+	item := models.FavoriteItem{ID: id, Type: itemType}
+	snapshot.ApplyTo(&item)
+	return item
+}
+
+// fieldQuery construit une recherche avec les filtres artist:, album: et track:
+func fieldQuery(entry Entry) string {
+	var parts []string
+	switch entry.Type() {
+	case models.FavoriteTypeTrack:
+		parts = append(parts, "track:"+spotify.QuoteFilter(entry.Title))
+	case models.FavoriteTypeAlbum:
+		parts = append(parts, "album:"+spotify.QuoteFilter(entry.Album))
+	}
+	if entry.Artist != "" {
+		parts = append(parts, "artist:"+spotify.QuoteFilter(entry.Artist))
+	}
+	return strings.Join(parts, " ")
+}
+
+// freeQuery construit une recherche libre à partir des termes normalisés
+func freeQuery(entry Entry) string {
+	terms := []string{normalize(entry.Artist)}
+	switch entry.Type() {
+	case models.FavoriteTypeTrack:
+		terms = append(terms, normalize(entry.Title))
+	case models.FavoriteTypeAlbum:
+		terms = append(terms, normalize(entry.Album))
+	}
+	return strings.TrimSpace(strings.Join(terms, " "))
+}
+
+// score compare une entrée à un candidat. Le nom compte pour 55 % et
+// l'artiste pour 45 % ; l'album et la durée des pistes ajustent le résultat.
+func score(entry Entry, item models.FavoriteItem) float64 {
+	if item.Type == models.FavoriteTypeArtist {
+		return similarity(normalize(entry.Artist), normalize(item.Name))
+	}
+
+	name := entry.Title
+	if item.Type == models.FavoriteTypeAlbum {
+		name = entry.Album
+	}
+	nameScore := similarity(normalize(name), normalize(item.Name))
+
+	artistScore := 0.0
+	if item.Metadata != nil {
+		for _, artist := range item.Metadata.Artists {
+			artistScore = math.Max(artistScore, similarity(normalize(entry.Artist), normalize(artist.Name)))
+		}
+	}
+
+	result := 0.55*nameScore + 0.45*artistScore
+	if entry.Artist == "" {
+		// Sans artiste, le nom seul ne suffit pas pour une confiance élevée
+		result = 0.85 * nameScore
+	}
+
+	if item.Type == models.FavoriteTypeTrack {
+		if entry.Album != "" && similarity(normalize(entry.Album), normalize(item.AlbumName())) >= 0.9 {
+			result += 0.05
+		}
+		if entry.DurationMs > 0 && item.DurationMs() > 0 {
+			diff := math.Abs(float64(entry.DurationMs - item.DurationMs()))
+			switch {
+			case diff <= 3000:
+				result += 0.03
+			case diff > 15000:
+				result -= 0.1
+			}
+		}
+	}
+
+	return math.Max(0, math.Min(1, result))
+}
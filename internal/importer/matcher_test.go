@@ -0,0 +1,148 @@
+package importer
+
+import (
+	"math"
+	"testing"
+
+	"github.com/yourusername/melody-explorer/internal/models"
+)
+
+// candidate construit un candidat de test ; durationMs vaut 0 si inconnue
+func candidate(itemType models.FavoriteType, name, album string, durationMs int, artists ...string) models.FavoriteItem {
+	item := models.FavoriteItem{ID: "id", Type: itemType, Name: name}
+	if len(artists) == 0 && album == "" && durationMs == 0 {
+		return item
+	}
+	item.Metadata = &models.FavoriteMetadata{DurationMs: durationMs}
+	for _, artist := range artists {
+		item.Metadata.Artists = append(item.Metadata.Artists, models.FavoriteRef{Name: artist})
+	}
+	if album != "" {
+		item.Metadata.Album = &models.FavoriteRef{Name: album}
+	}
+	return item
+}
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry Entry
+		item  models.FavoriteItem
+		want  float64
+	}{
+		{
+			name:  "artiste sans article",
+			entry: Entry{Artist: "The Beatles"},
+			item:  candidate(models.FavoriteTypeArtist, "Beatles", "", 0),
+			want:  1,
+		},
+		{
+			name:  "artiste différent",
+			entry: Entry{Artist: "abc"},
+			item:  candidate(models.FavoriteTypeArtist, "xyz", "", 0),
+			want:  0,
+		},
+		{
+			name:  "piste identique",
+			entry: Entry{Artist: "Daft Punk", Title: "One More Time"},
+			item:  candidate(models.FavoriteTypeTrack, "One More Time", "", 0, "Daft Punk"),
+			want:  1,
+		},
+		{
+			name:  "accents et mention de version ignorés",
+			entry: Entry{Artist: "Björk", Title: "Café"},
+			item:  candidate(models.FavoriteTypeTrack, "Cafe - Remastered 2009", "", 0, "Bjork"),
+			want:  1,
+		},
+		{
+			name:  "meilleur des artistes crédités",
+			entry: Entry{Artist: "Daft Punk", Title: "Get Lucky"},
+			item:  candidate(models.FavoriteTypeTrack, "Get Lucky (feat. Pharrell)", "", 0, "Pharrell Williams", "Daft Punk"),
+			want:  1,
+		},
+		{
+			name:  "artiste différent : le nom seul",
+			entry: Entry{Artist: "abc", Title: "One More Time"},
+			item:  candidate(models.FavoriteTypeTrack, "One More Time", "", 0, "xyz"),
+			want:  0.55,
+		},
+		{
+			name:  "nom différent : l'artiste seul",
+			entry: Entry{Artist: "Daft Punk", Title: "abc"},
+			item:  candidate(models.FavoriteTypeTrack, "xyz", "", 0, "Daft Punk"),
+			want:  0.45,
+		},
+		{
+			name:  "sans artiste dans l'entrée",
+			entry: Entry{Title: "One More Time"},
+			item:  candidate(models.FavoriteTypeTrack, "One More Time", "", 0, "Daft Punk"),
+			want:  0.85,
+		},
+		{
+			name:  "candidat sans métadonnées",
+			entry: Entry{Artist: "Daft Punk", Title: "One More Time"},
+			item:  candidate(models.FavoriteTypeTrack, "One More Time", "", 0),
+			want:  0.55,
+		},
+		{
+			name:  "album et durée concordants",
+			entry: Entry{Artist: "abc", Title: "One More Time", Album: "Discovery", DurationMs: 320000},
+			item:  candidate(models.FavoriteTypeTrack, "One More Time", "Discovery", 321500, "xyz"),
+			want:  0.63,
+		},
+		{
+			name:  "durée trop différente",
+			entry: Entry{Artist: "Daft Punk", Title: "One More Time", DurationMs: 320000},
+			item:  candidate(models.FavoriteTypeTrack, "One More Time", "", 340000, "Daft Punk"),
+			want:  0.9,
+		},
+		{
+			name:  "durée proche sans bonus au-delà de 1",
+			entry: Entry{Artist: "Daft Punk", Title: "One More Time", Album: "Discovery", DurationMs: 320000},
+			item:  candidate(models.FavoriteTypeTrack, "One More Time", "Discovery", 320000, "Daft Punk"),
+			want:  1,
+		},
+		{
+			name:  "score négatif ramené à zéro",
+			entry: Entry{Title: "abc", DurationMs: 320000},
+			item:  candidate(models.FavoriteTypeTrack, "xyz", "", 200000),
+			want:  0,
+		},
+		{
+			name:  "album comparé au nom de l'album",
+			entry: Entry{Artist: "The Beatles", Album: "Abbey Road", Title: ""},
+			item:  candidate(models.FavoriteTypeAlbum, "Abbey Road (Remastered)", "", 0, "The Beatles"),
+			want:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := score(tt.entry, tt.item); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("score = %v, attendu %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfidenceFor(t *testing.T) {
+	tests := []struct {
+		score float64
+		want  Confidence
+	}{
+		{1, ConfidenceHigh},
+		{0.9, ConfidenceHigh},
+		{0.89, ConfidenceMedium},
+		{0.75, ConfidenceMedium},
+		{0.74, ConfidenceLow},
+		{0.5, ConfidenceLow},
+		{0.49, ConfidenceNone},
+		{0, ConfidenceNone},
+	}
+
+	for _, tt := range tests {
+		if got := confidenceFor(tt.score); got != tt.want {
+			t.Errorf("confidenceFor(%v) = %q, attendu %q", tt.score, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package importer
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// diacritics remplace les lettres accentuées latines par leur forme de base
+var diacritics = strings.NewReplacer(
+	"à", "a", "á", "a", "â", "a", "ã", "a", "ä", "a", "å", "a",
+	"ç", "c", "è", "e", "é", "e", "ê", "e", "ë", "e",
+	"ì", "i", "í", "i", "î", "i", "ï", "i", "ñ", "n",
+	"ò", "o", "ó", "o", "ô", "o", "õ", "o", "ö", "o", "ø", "o",
+	"ù", "u", "ú", "u", "û", "u", "ü", "u", "ý", "y", "ÿ", "y",
+	"œ", "oe", "æ", "ae", "ß", "ss", "&", " and ",
+)
+
+// decorations correspond aux mentions entre parenthèses ou crochets
+// (« (Remastered 2011) », « [Live] »)
+var decorations = regexp.MustCompile(`\s*[(\[][^)\]]*[)\]]`)
+
+// featuring correspond aux artistes invités en fin de titre
+var featuring = regexp.MustCompile(`\s(feat\.?|ft\.?|featuring)\s.*$`)
+
+// normalize prépare une chaîne pour la comparaison : minuscules, sans accents,
+// sans mentions de version ni artistes invités, sans ponctuation
+func normalize(value string) string {
+	value = diacritics.Replace(strings.ToLower(value))
+	value = decorations.ReplaceAllString(value, "")
+	value = featuring.ReplaceAllString(value, "")
+
+	// Spotify ajoute les mentions de version après un tiret (« Titre - Remastered »)
+	if before, _, ok := strings.Cut(value, " - "); ok && before != "" {
+		value = before
+	}
+
+	value = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return ' '
+	}, value)
+
+	words := strings.Fields(value)
+	if len(words) > 1 && words[0] == "the" {
+		words = words[1:]
+	}
+	return strings.Join(words, " ")
+}
+
+// similarity renvoie un score entre 0 et 1 comparant deux chaînes normalisées :
+// le meilleur entre la distance d'édition relative et le recouvrement des mots
+func similarity(a, b string) float64 {
+	if a == "" || b == "" {
+		return 0
+	}
+	if a == b {
+		return 1
+	}
+
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	edit := 1 - float64(levenshtein(ra, rb))/float64(longest)
+
+	words := tokenOverlap(strings.Fields(a), strings.Fields(b))
+	if words > edit {
+		return words
+	}
+	return edit
+}
+
+// tokenOverlap renvoie le rapport entre les mots communs et l'ensemble des mots
+func tokenOverlap(a, b []string) float64 {
+	set := make(map[string]bool, len(a))
+	for _, word := range a {
+		set[word] = true
+	}
+
+	common := 0
+	union := len(set)
+	seen := make(map[string]bool, len(b))
+	for _, word := range b {
+		if seen[word] {
+			continue
+		}
+		seen[word] = true
+		if set[word] {
+			common++
+		} else {
+			union++
+		}
+	}
+	if union == 0 {
+		return 0
+	}
+	return float64(common) / float64(union)
+}
+
+// levenshtein renvoie la distance d'édition entre deux suites de caractères
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
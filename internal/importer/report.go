@@ -0,0 +1,103 @@
+package importer
+
+import (
+	"time"
+)
+
+// Statuts d'un rapport d'import
+const (
+	StatusMatching  = "matching"
+	StatusReady     = "ready"
+	StatusCommitted = "committed"
+	StatusFailed    = "failed"
+)
+
+// Match est le résultat de la correspondance d'une entrée
+type Match struct {
+	Index      int        `json:"index"`
+	Entry      Entry      `json:"entry"`
+	Confidence Confidence `json:"confidence"`
+	// Candidates contient la meilleure correspondance suivie des alternatives
+	Candidates []Candidate `json:"candidates"`
+	// Selected indique si la meilleure correspondance est retenue par défaut
+	Selected bool `json:"selected"`
+	// AlreadyFavorite indique si la meilleure correspondance est déjà en favori
+	AlreadyFavorite bool   `json:"already_favorite"`
+	Error           string `json:"error,omitempty"`
+}
+
+// Best renvoie la meilleure correspondance, ou nil si aucune n'a été trouvée
+func (m Match) Best() *Candidate {
+	if len(m.Candidates) == 0 {
+		return nil
+	}
+	return &m.Candidates[0]
+}
+
+// candidate renvoie le candidat portant l'identifiant donné
+func (m Match) candidate(id string) (Candidate, bool) {
+	for _, candidate := range m.Candidates {
+		if candidate.Item.ID == id {
+			return candidate, true
+		}
+	}
+	return Candidate{}, false
+}
+
+// Summary compte les correspondances par niveau de confiance
+type Summary struct {
+	Total    int                `json:"total"`
+	Done     int                `json:"done"`
+	Selected int                `json:"selected"`
+	Levels   map[Confidence]int `json:"levels"`
+	Errors   int                `json:"errors"`
+}
+
+// Report est le rapport de correspondance d'un fichier importé, à valider
+// avant l'ajout aux favoris
+type Report struct {
+	ID          string     `json:"id"`
+	Filename    string     `json:"filename"`
+	Format      string     `json:"format"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	CommittedAt *time.Time `json:"committed_at,omitempty"`
+	// Added est le nombre de favoris ajoutés lors de la validation
+	Added   int     `json:"added,omitempty"`
+	Error   string  `json:"error,omitempty"`
+	Summary Summary `json:"summary"`
+	Matches []Match `json:"matches"`
+}
+
+// summarize recalcule le résumé du rapport
+func (r *Report) summarize() {
+	summary := Summary{Levels: make(map[Confidence]int)}
+	for _, match := range r.Matches {
+		summary.Total++
+		if match.Confidence == "" {
+			continue
+		}
+		summary.Done++
+		summary.Levels[match.Confidence]++
+		if match.Selected {
+			summary.Selected++
+		}
+		if match.Error != "" {
+			summary.Errors++
+		}
+	}
+	r.Summary = summary
+}
+
+// clone renvoie une copie du rapport pouvant être lue sans verrou
+func (r *Report) clone() Report {
+	report := *r
+	report.Matches = make([]Match, len(r.Matches))
+	copy(report.Matches, r.Matches)
+	report.Summary.Levels = make(map[Confidence]int, len(r.Summary.Levels))
+	for level, count := range r.Summary.Levels {
+		report.Summary.Levels[level] = count
+	}
+	return report
+}
@@ -0,0 +1,33 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+)
+
+// parseText lit une liste en texte brut, une entrée par ligne au format
+// « Artiste - Titre ». Une ligne sans séparateur désigne un artiste ; les
+// lignes vides et celles commençant par # sont ignorées.
+func parseText(data []byte) ([]Entry, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+
+	var entries []Entry
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		entry := Entry{Line: line}
+		if _, _, ok := parseSpotifyURI(text); ok {
+			entry.URI = text
+		} else if artist, title := splitArtistTitle(text); artist != "" {
+			entry.Artist, entry.Title = artist, title
+		} else {
+			entry.Artist = title
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
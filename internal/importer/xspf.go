@@ -0,0 +1,45 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/xml"
+)
+
+// xspfPlaylist est la partie utile d'une liste de lecture XSPF
+type xspfPlaylist struct {
+	Tracks []struct {
+		Locations   []string `xml:"location"`
+		Identifiers []string `xml:"identifier"`
+		Title       string   `xml:"title"`
+		Creator     string   `xml:"creator"`
+		Album       string   `xml:"album"`
+		Duration    int      `xml:"duration"`
+	} `xml:"trackList>track"`
+}
+
+// parseXSPF lit une liste de lecture XSPF
+func parseXSPF(data []byte) ([]Entry, error) {
+	var playlist xspfPlaylist
+	if err := xml.NewDecoder(bytes.NewReader(data)).Decode(&playlist); err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, 0, len(playlist.Tracks))
+	for i, track := range playlist.Tracks {
+		entry := Entry{
+			Line:       i + 1,
+			Artist:     track.Creator,
+			Album:      track.Album,
+			Title:      track.Title,
+			DurationMs: track.Duration,
+		}
+		for _, location := range append(track.Locations, track.Identifiers...) {
+			if _, _, ok := parseSpotifyURI(location); ok {
+				entry.URI = location
+				break
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
@@ -0,0 +1,267 @@
+// Package jsonrpc implémente un serveur JSON-RPC 2.0 sur HTTP : appels
+// simples ou groupés, notifications et codes d'erreur standard.
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+)
+
+// Version est la version du protocole
+const Version = "2.0"
+
+// Codes d'erreur standard de JSON-RPC 2.0. Les codes -32000 à -32099 sont
+// réservés aux erreurs propres à l'application.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// Limites par défaut d'une requête HTTP
+const (
+	DefaultMaxBatch    = 100
+	DefaultMaxBodySize = 1 << 20
+)
+
+// Error est une erreur JSON-RPC renvoyée au client
+type Error struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("jsonrpc %d : %s", e.Code, e.Message)
+}
+
+// NewError crée une erreur avec un code et un message
+func NewError(code int, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// InvalidParams crée une erreur de paramètres invalides
+func InvalidParams(message string) *Error {
+	return NewError(CodeInvalidParams, message)
+}
+
+// Handler traite un appel. params vaut null si l'appel n'a pas de
+// paramètres. Une erreur qui n'est pas une *Error est renvoyée comme erreur
+// interne.
+type Handler func(ctx context.Context, params json.RawMessage) (interface{}, error)
+
+// DecodeParams décode des paramètres nommés (un objet JSON) dans v. Des
+// paramètres absents laissent v inchangé ; les paramètres positionnels et
+// les champs inconnus sont refusés.
+func DecodeParams(params json.RawMessage, v interface{}) error {
+	trimmed := bytes.TrimSpace(params)
+	if len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")) {
+		return nil
+	}
+	if trimmed[0] != '{' {
+		return InvalidParams("paramètres nommés attendus (objet JSON)")
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(trimmed))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return InvalidParams("paramètres invalides : " + err.Error())
+	}
+	return nil
+}
+
+// Request est un appel reçu. ID est nil pour une notification.
+type Request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// Response est la réponse à un appel
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// nullID est l'identifiant des réponses aux requêtes illisibles
+var nullID = json.RawMessage("null")
+
+// Server répartit les appels entre les méthodes enregistrées
+type Server struct {
+	// MaxBatch est le nombre maximal d'appels d'un lot
+	MaxBatch int
+	// MaxBodySize est la taille maximale du corps d'une requête, en octets
+	MaxBodySize int64
+
+	mu      sync.RWMutex
+	methods map[string]Handler
+}
+
+// NewServer crée un serveur sans méthode
+func NewServer() *Server {
+	return &Server{
+		MaxBatch:    DefaultMaxBatch,
+		MaxBodySize: DefaultMaxBodySize,
+		methods:     make(map[string]Handler),
+	}
+}
+
+// Register enregistre une méthode
+func (s *Server) Register(method string, handler Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.methods[method] = handler
+}
+
+// ServeHTTP traite une requête POST contenant un appel ou un lot d'appels.
+// Les notifications ne reçoivent pas de réponse ; une requête qui ne
+// contient que des notifications reçoit une réponse vide (204).
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Méthode non autorisée", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, s.MaxBodySize))
+	if err != nil {
+		writeResponse(w, Response{JSONRPC: Version, Error: NewError(CodeParseError, "corps de requête illisible ou trop volumineux"), ID: nullID})
+		return
+	}
+	body = bytes.TrimSpace(body)
+
+	// Un lot est un tableau d'appels, traités dans l'ordre
+	if len(body) > 0 && body[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(body, &batch); err != nil {
+			writeResponse(w, Response{JSONRPC: Version, Error: NewError(CodeParseError, "JSON invalide : "+err.Error()), ID: nullID})
+			return
+		}
+		if len(batch) == 0 {
+			writeResponse(w, Response{JSONRPC: Version, Error: NewError(CodeInvalidRequest, "lot vide"), ID: nullID})
+			return
+		}
+		if len(batch) > s.MaxBatch {
+			writeResponse(w, Response{JSONRPC: Version, Error: NewError(CodeInvalidRequest, fmt.Sprintf("lot limité à %d appels", s.MaxBatch)), ID: nullID})
+			return
+		}
+
+		responses := make([]Response, 0, len(batch))
+		for _, raw := range batch {
+			if resp, ok := s.call(r.Context(), raw); ok {
+				responses = append(responses, resp)
+			}
+		}
+		if len(responses) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		writeResponse(w, responses)
+		return
+	}
+
+	if !json.Valid(body) {
+		writeResponse(w, Response{JSONRPC: Version, Error: NewError(CodeParseError, "JSON invalide"), ID: nullID})
+		return
+	}
+	resp, ok := s.call(r.Context(), body)
+	if !ok {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeResponse(w, resp)
+}
+
+// call exécute un appel et indique s'il attend une réponse
+func (s *Server) call(ctx context.Context, raw json.RawMessage) (Response, bool) {
+	var req Request
+	if err := json.Unmarshal(raw, &req); err != nil || req.JSONRPC != Version || req.Method == "" || !validID(req.ID) {
+		id := nullID
+		if err == nil && validID(req.ID) && req.ID != nil {
+			id = req.ID
+		}
+		return Response{JSONRPC: Version, Error: NewError(CodeInvalidRequest, "requête JSON-RPC 2.0 invalide"), ID: id}, true
+	}
+	// Un membre "id" absent désigne une notification ; "id": null est un appel
+	notification := req.ID == nil
+
+	s.mu.RLock()
+	handler, ok := s.methods[req.Method]
+	s.mu.RUnlock()
+
+	var result interface{}
+	var err error
+	if !ok {
+		err = NewError(CodeMethodNotFound, "méthode inconnue : "+req.Method)
+	} else {
+		result, err = safeCall(ctx, handler, req.Params)
+	}
+	if notification {
+		if err != nil {
+			log.Printf("Erreur de la notification JSON-RPC %s : %v", req.Method, err)
+		}
+		return Response{}, false
+	}
+
+	resp := Response{JSONRPC: Version, ID: req.ID}
+	if err != nil {
+		var rpcErr *Error
+		if !errors.As(err, &rpcErr) {
+			rpcErr = NewError(CodeInternalError, err.Error())
+		}
+		resp.Error = rpcErr
+		return resp, true
+	}
+	// Un résultat nul est renvoyé explicitement, le membre result étant obligatoire
+	if result == nil {
+		result = json.RawMessage("null")
+	}
+	resp.Result = result
+	return resp, true
+}
+
+// safeCall exécute une méthode en transformant une panique en erreur interne
+func safeCall(ctx context.Context, handler Handler, params json.RawMessage) (result interface{}, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			log.Printf("Panique dans une méthode JSON-RPC : %v", recovered)
+			result, err = nil, NewError(CodeInternalError, "erreur interne")
+		}
+	}()
+	return handler(ctx, params)
+}
+
+// validID indique si l'identifiant est absent, nul, une chaîne ou un nombre
+func validID(id json.RawMessage) bool {
+	if id == nil {
+		return true
+	}
+	switch trimmed := bytes.TrimSpace(id); {
+	case len(trimmed) == 0:
+		return false
+	case trimmed[0] == '"', trimmed[0] == '-', trimmed[0] >= '0' && trimmed[0] <= '9':
+		return true
+	default:
+		return bytes.Equal(trimmed, []byte("null"))
+	}
+}
+
+// writeResponse écrit une réponse ou un lot de réponses
+func writeResponse(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Erreur lors de l'encodage de la réponse JSON-RPC : %v", err)
+	}
+}
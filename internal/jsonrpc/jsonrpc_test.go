@@ -0,0 +1,209 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// newTestServer crée un serveur avec des méthodes de test ; notified compte
+// les appels de la méthode notify
+func newTestServer(notified *int32) *Server {
+	s := NewServer()
+	s.MaxBatch = 3
+	s.Register("echo", func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			Value string `json:"value"`
+		}
+		if err := DecodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return p.Value, nil
+	})
+	s.Register("nothing", func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		return nil, nil
+	})
+	s.Register("fail", func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		return nil, errors.New("échec")
+	})
+	s.Register("panic", func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		panic("panique de test")
+	})
+	s.Register("notify", func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		atomic.AddInt32(notified, 1)
+		return "ignoré", nil
+	})
+	return s
+}
+
+// decodeJSON décode un document JSON pour comparer des réponses
+// indépendamment de leur mise en forme
+func decodeJSON(t *testing.T, data string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(data), &v); err != nil {
+		t.Fatalf("JSON invalide %q : %v", data, err)
+	}
+	return v
+}
+
+func TestServeHTTP(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		// want est la réponse attendue, vide pour une réponse sans corps
+		want     string
+		notified int32
+	}{
+		{
+			name:   "appel simple",
+			body:   `{"jsonrpc":"2.0","method":"echo","params":{"value":"bonjour"},"id":1}`,
+			status: http.StatusOK,
+			want:   `{"jsonrpc":"2.0","result":"bonjour","id":1}`,
+		},
+		{
+			name:   "identifiant chaîne",
+			body:   `{"jsonrpc":"2.0","method":"echo","params":{"value":"a"},"id":"abc"}`,
+			status: http.StatusOK,
+			want:   `{"jsonrpc":"2.0","result":"a","id":"abc"}`,
+		},
+		{
+			name:   "identifiant nul : un appel et non une notification",
+			body:   `{"jsonrpc":"2.0","method":"nothing","id":null}`,
+			status: http.StatusOK,
+			want:   `{"jsonrpc":"2.0","result":null,"id":null}`,
+		},
+		{
+			name:   "identifiant objet",
+			body:   `{"jsonrpc":"2.0","method":"echo","id":{"a":1}}`,
+			status: http.StatusOK,
+			want:   `{"jsonrpc":"2.0","error":{"code":-32600,"message":"requête JSON-RPC 2.0 invalide"},"id":null}`,
+		},
+		{
+			name:   "identifiant booléen",
+			body:   `{"jsonrpc":"2.0","method":"echo","id":true}`,
+			status: http.StatusOK,
+			want:   `{"jsonrpc":"2.0","error":{"code":-32600,"message":"requête JSON-RPC 2.0 invalide"},"id":null}`,
+		},
+		{
+			name:   "version absente",
+			body:   `{"method":"echo","id":7}`,
+			status: http.StatusOK,
+			want:   `{"jsonrpc":"2.0","error":{"code":-32600,"message":"requête JSON-RPC 2.0 invalide"},"id":7}`,
+		},
+		{
+			name:   "méthode inconnue",
+			body:   `{"jsonrpc":"2.0","method":"inconnue","id":2}`,
+			status: http.StatusOK,
+			want:   `{"jsonrpc":"2.0","error":{"code":-32601,"message":"méthode inconnue : inconnue"},"id":2}`,
+		},
+		{
+			name:   "paramètres positionnels",
+			body:   `{"jsonrpc":"2.0","method":"echo","params":["a"],"id":3}`,
+			status: http.StatusOK,
+			want:   `{"jsonrpc":"2.0","error":{"code":-32602,"message":"paramètres nommés attendus (objet JSON)"},"id":3}`,
+		},
+		{
+			name:   "erreur interne",
+			body:   `{"jsonrpc":"2.0","method":"fail","id":4}`,
+			status: http.StatusOK,
+			want:   `{"jsonrpc":"2.0","error":{"code":-32603,"message":"échec"},"id":4}`,
+		},
+		{
+			name:   "panique",
+			body:   `{"jsonrpc":"2.0","method":"panic","id":5}`,
+			status: http.StatusOK,
+			want:   `{"jsonrpc":"2.0","error":{"code":-32603,"message":"erreur interne"},"id":5}`,
+		},
+		{
+			name:   "JSON invalide",
+			body:   `{"jsonrpc":"2.0",`,
+			status: http.StatusOK,
+			want:   `{"jsonrpc":"2.0","error":{"code":-32700,"message":"JSON invalide"},"id":null}`,
+		},
+		{
+			name:     "notification",
+			body:     `{"jsonrpc":"2.0","method":"notify"}`,
+			status:   http.StatusNoContent,
+			notified: 1,
+		},
+		{
+			name:   "notification en erreur",
+			body:   `{"jsonrpc":"2.0","method":"fail"}`,
+			status: http.StatusNoContent,
+		},
+		{
+			name: "lot",
+			body: `[
+				{"jsonrpc":"2.0","method":"echo","params":{"value":"a"},"id":1},
+				{"jsonrpc":"2.0","method":"notify"},
+				{"jsonrpc":"2.0","method":"inconnue","id":2}
+			]`,
+			status:   http.StatusOK,
+			want:     `[{"jsonrpc":"2.0","result":"a","id":1},{"jsonrpc":"2.0","error":{"code":-32601,"message":"méthode inconnue : inconnue"},"id":2}]`,
+			notified: 1,
+		},
+		{
+			name:   "lot avec un appel invalide",
+			body:   `[1,{"jsonrpc":"2.0","method":"echo","params":{"value":"b"},"id":"x"}]`,
+			status: http.StatusOK,
+			want:   `[{"jsonrpc":"2.0","error":{"code":-32600,"message":"requête JSON-RPC 2.0 invalide"},"id":null},{"jsonrpc":"2.0","result":"b","id":"x"}]`,
+		},
+		{
+			name:     "lot de notifications",
+			body:     `[{"jsonrpc":"2.0","method":"notify"},{"jsonrpc":"2.0","method":"notify"}]`,
+			status:   http.StatusNoContent,
+			notified: 2,
+		},
+		{
+			name:   "lot vide",
+			body:   `[]`,
+			status: http.StatusOK,
+			want:   `{"jsonrpc":"2.0","error":{"code":-32600,"message":"lot vide"},"id":null}`,
+		},
+		{
+			name:   "lot trop grand",
+			body:   `[{"jsonrpc":"2.0","method":"notify"},{"jsonrpc":"2.0","method":"notify"},{"jsonrpc":"2.0","method":"notify"},{"jsonrpc":"2.0","method":"notify"}]`,
+			status: http.StatusOK,
+			want:   `{"jsonrpc":"2.0","error":{"code":-32600,"message":"lot limité à 3 appels"},"id":null}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var notified int32
+			s := newTestServer(&notified)
+
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(tt.body)))
+			if w.Code != tt.status {
+				t.Fatalf("statut = %d, attendu %d", w.Code, tt.status)
+			}
+			if tt.want == "" {
+				if w.Body.Len() != 0 {
+					t.Errorf("corps inattendu : %s", w.Body)
+				}
+			} else if got, want := decodeJSON(t, w.Body.String()), decodeJSON(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("réponse = %s, attendu %s", w.Body, tt.want)
+			}
+			if notified != tt.notified {
+				t.Errorf("notifications traitées = %d, attendu %d", notified, tt.notified)
+			}
+		})
+	}
+}
+
+func TestServeHTTPMethod(t *testing.T) {
+	w := httptest.NewRecorder()
+	NewServer().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/rpc", nil))
+	if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != http.MethodPost {
+		t.Errorf("GET : statut %d, Allow %q", w.Code, w.Header().Get("Allow"))
+	}
+}
@@ -108,7 +108,7 @@ func formatInt(num int) string {
 		if i > 0 && i%3 == 0 {
 			str = "," + str
 		}
-		str = string('0'+num%10) + str
+		str = string(rune('0'+num%10)) + str
 		num /= 10
 	}
 	if str == "" {
@@ -0,0 +1,221 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Types de favoris qui ne sont pas des pistes, albums ou artistes
+const (
+	// FavoriteTypePlaylist représente une playlist Spotify épinglée
+	FavoriteTypePlaylist FavoriteType = "playlist"
+	// FavoriteTypeCategory représente une catégorie (genre) de la page /category
+	FavoriteTypeCategory FavoriteType = "category"
+	// FavoriteTypeLabel représente un label discographique
+	FavoriteTypeLabel FavoriteType = "label"
+	// FavoriteTypeSearch représente une recherche enregistrée
+	FavoriteTypeSearch FavoriteType = "search"
+)
+
+// Longueurs maximales des identifiants des favoris hors Spotify
+const (
+	maxCategoryLength = 100
+	maxLabelLength    = 100
+	maxSearchLength   = 200
+)
+
+// FavoriteTypeInfo décrit un type de favori : son affichage, la validation
+// de ses éléments et la page vers laquelle ils renvoient
+type FavoriteTypeInfo struct {
+	Type FavoriteType
+	// Label est le nom du type au singulier, Section le titre de sa section
+	// sur la page des favoris
+	Label   string
+	Section string
+	// Icon est la classe Font Awesome utilisée en l'absence d'image
+	Icon string
+	// Spotify indique que l'élément est une ressource Spotify identifiée par
+	// un ID, dont les métadonnées sont récupérées et rafraîchies
+	Spotify bool
+
+	// normalize vérifie l'élément et met son ID sous forme canonique
+	normalize func(item *FavoriteItem) error
+	// pageURL renvoie le lien de l'élément
+	pageURL func(item FavoriteItem) string
+}
+
+// favoriteTypes est le registre des types de favoris, dans l'ordre d'affichage
+var favoriteTypes = []FavoriteTypeInfo{
+	{Type: FavoriteTypeArtist, Label: "Artiste", Section: "Artistes", Icon: "fa-user", Spotify: true, normalize: normalizeSpotifyID, pageURL: appPageURL},
+	{Type: FavoriteTypeAlbum, Label: "Album", Section: "Albums", Icon: "fa-record-vinyl", Spotify: true, normalize: normalizeSpotifyID, pageURL: appPageURL},
+	{Type: FavoriteTypeTrack, Label: "Piste", Section: "Pistes", Icon: "fa-music", Spotify: true, normalize: normalizeSpotifyID, pageURL: appPageURL},
+	{Type: FavoriteTypePlaylist, Label: "Playlist", Section: "Playlists", Icon: "fa-list", Spotify: true, normalize: normalizeSpotifyID, pageURL: spotifyPageURL},
+	{Type: FavoriteTypeCategory, Label: "Catégorie", Section: "Catégories", Icon: "fa-tags", normalize: normalizeCategory, pageURL: categoryPageURL},
+	{Type: FavoriteTypeLabel, Label: "Label", Section: "Labels", Icon: "fa-building", normalize: normalizeLabel, pageURL: labelPageURL},
+	{Type: FavoriteTypeSearch, Label: "Recherche", Section: "Recherches enregistrées", Icon: "fa-search", normalize: normalizeSearch, pageURL: searchPageURL},
+}
+
+// FavoriteTypes renvoie les types de favoris dans l'ordre d'affichage
+func FavoriteTypes() []FavoriteTypeInfo {
+	return favoriteTypes
+}
+
+// LookupFavoriteType renvoie la description d'un type de favori
+func LookupFavoriteType(itemType FavoriteType) (FavoriteTypeInfo, bool) {
+	for _, info := range favoriteTypes {
+		if info.Type == itemType {
+			return info, true
+		}
+	}
+	return FavoriteTypeInfo{}, false
+}
+
+// ParseFavoriteType convertit une chaîne en type de favori et indique si elle est valide
+func ParseFavoriteType(value string) (FavoriteType, bool) {
+	info, ok := LookupFavoriteType(FavoriteType(value))
+	return info.Type, ok
+}
+
+// Normalize vérifie un élément avant son ajout aux favoris : son ID est mis
+// sous forme canonique et son nom, s'il est vide, déduit de l'ID lorsque
+// c'est possible
+func (t FavoriteTypeInfo) Normalize(item *FavoriteItem) error {
+	item.ID = strings.TrimSpace(item.ID)
+	item.Name = strings.TrimSpace(item.Name)
+	if item.ID == "" {
+		return fmt.Errorf("identifiant manquant")
+	}
+	return t.normalize(item)
+}
+
+// TypeInfo renvoie la description du type de l'élément
+func (i FavoriteItem) TypeInfo() FavoriteTypeInfo {
+	info, _ := LookupFavoriteType(i.Type)
+	return info
+}
+
+// PageURL renvoie le lien de l'élément : sa page dans l'application, ou sa
+// page Spotify s'il n'en a pas
+func (i FavoriteItem) PageURL() string {
+	info, ok := LookupFavoriteType(i.Type)
+	if !ok {
+		return ""
+	}
+	return info.pageURL(i)
+}
+
+// IsExternal indique si le lien de l'élément quitte l'application
+func (i FavoriteItem) IsExternal() bool {
+	return strings.HasPrefix(i.PageURL(), "https://")
+}
+
+// spotifyIDPattern reconnaît un identifiant Spotify en base 62
+var spotifyIDPattern = regexp.MustCompile(`^[0-9A-Za-z]{22}$`)
+
+// normalizeSpotifyID accepte un ID Spotify, une URI (spotify:type:id) ou un
+// lien open.spotify.com du même type
+func normalizeSpotifyID(item *FavoriteItem) error {
+	id := item.ID
+	prefix := "spotify:" + string(item.Type) + ":"
+	switch {
+	case strings.HasPrefix(id, prefix):
+		id = strings.TrimPrefix(id, prefix)
+	case strings.HasPrefix(id, "https://open.spotify.com/"):
+		u, err := url.Parse(id)
+		if err != nil {
+			return fmt.Errorf("lien Spotify invalide")
+		}
+		// Les liens localisés ont la forme /intl-fr/playlist/<id>
+		parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+		if len(parts) > 2 && strings.HasPrefix(parts[0], "intl-") {
+			parts = parts[1:]
+		}
+		if len(parts) != 2 || parts[0] != string(item.Type) {
+			return fmt.Errorf("le lien ne désigne pas un élément de type %s", item.Type)
+		}
+		id = parts[1]
+	}
+	if !spotifyIDPattern.MatchString(id) {
+		return fmt.Errorf("identifiant Spotify invalide : %q", item.ID)
+	}
+	item.ID = id
+	return nil
+}
+
+// normalizeCategory vérifie le nom d'une catégorie, qui sert d'identifiant
+// dans l'URL /category/{genre}
+func normalizeCategory(item *FavoriteItem) error {
+	if strings.Contains(item.ID, "/") {
+		return fmt.Errorf("nom de catégorie invalide : %q", item.ID)
+	}
+	return normalizeFreeText(item, item.ID, maxCategoryLength)
+}
+
+// normalizeLabel identifie un label par son nom en minuscules ; le nom
+// affiché conserve la casse d'origine
+func normalizeLabel(item *FavoriteItem) error {
+	if item.Name == "" {
+		item.Name = strings.Join(strings.Fields(item.ID), " ")
+	}
+	return normalizeFreeText(item, LabelFavoriteID(item.ID), maxLabelLength)
+}
+
+// normalizeSearch identifie une recherche enregistrée par sa requête
+func normalizeSearch(item *FavoriteItem) error {
+	return normalizeFreeText(item, SearchFavoriteID(item.ID), maxSearchLength)
+}
+
+// SearchFavoriteID renvoie l'identifiant d'une recherche enregistrée : la
+// requête sans espaces superflus
+func SearchFavoriteID(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
+
+// LabelFavoriteID renvoie l'identifiant d'un label à partir de son nom
+func LabelFavoriteID(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// normalizeFreeText enregistre un identifiant libre et en déduit le nom si
+// aucun n'est fourni
+func normalizeFreeText(item *FavoriteItem, id string, maxLength int) error {
+	if id == "" {
+		return fmt.Errorf("identifiant manquant")
+	}
+	if utf8.RuneCountInString(id) > maxLength {
+		return fmt.Errorf("identifiant trop long (%d caractères au maximum)", maxLength)
+	}
+	item.ID = id
+	if item.Name == "" {
+		item.Name = id
+	}
+	return nil
+}
+
+// appPageURL renvoie la page de détails de l'application (/type/id)
+func appPageURL(item FavoriteItem) string {
+	return "/" + string(item.Type) + "/" + url.PathEscape(item.ID)
+}
+
+// spotifyPageURL renvoie la page Spotify des éléments sans page dans l'application
+func spotifyPageURL(item FavoriteItem) string {
+	return "https://open.spotify.com/" + string(item.Type) + "/" + item.ID
+}
+
+// categoryPageURL renvoie la page de la catégorie
+func categoryPageURL(item FavoriteItem) string {
+	return "/category/" + url.PathEscape(item.ID)
+}
+
+// labelPageURL recherche les albums du label
+func labelPageURL(item FavoriteItem) string {
+	return "/search?type=album&q=" + url.QueryEscape(`label:"`+item.Name+`"`)
+}
+
+// searchPageURL relance la recherche enregistrée
+func searchPageURL(item FavoriteItem) string {
+	return "/search?q=" + url.QueryEscape(item.ID)
+}
@@ -1,8 +1,12 @@
 package models
 
 import (
-	"sync"
+	"container/list"
+	"encoding/json"
+	"strings"
 	"time"
+
+	"github.com/yourusername/melody-explorer/internal/hlc"
 )
 
 // FavoriteType définit le type d'élément favori
@@ -19,95 +23,449 @@ const (
 
 // FavoriteItem représente un élément favori
 type FavoriteItem struct {
-	ID       string       `json:"id"`
-	Type     FavoriteType `json:"type"`
-	Name     string       `json:"name"`
-	ImageURL string       `json:"image_url"`
-	AddedAt  time.Time    `json:"added_at"`
+	ID       string            `json:"id"`
+	Type     FavoriteType      `json:"type"`
+	Name     string            `json:"name"`
+	ImageURL string            `json:"image_url"`
+	AddedAt  time.Time         `json:"added_at"`
+	Metadata *FavoriteMetadata `json:"metadata,omitempty"`
+
+	// LastRefreshedAt est la date du dernier rafraîchissement depuis Spotify
+	LastRefreshedAt *time.Time `json:"last_refreshed_at,omitempty"`
+
+	// Unavailable est renseigné lorsque l'élément n'existe plus sur Spotify
+	Unavailable *Unavailability `json:"unavailable,omitempty"`
+
+	// Annotations personnelles
+	Note   string   `json:"note,omitempty"`
+	Rating int      `json:"rating,omitempty"`
+	Tags   []string `json:"tags,omitempty"`
+
+	// Version est l'horodatage de la dernière modification par son auteur
+	// (appareil synchronisé ou serveur) ; la plus récente l'emporte en cas
+	// de modifications concurrentes. Revision est l'horodatage auquel le
+	// serveur a enregistré cette version : les curseurs de synchronisation
+	// s'y réfèrent.
+	Version  hlc.Timestamp `json:"version,omitempty"`
+	Revision hlc.Timestamp `json:"revision,omitempty"`
+}
+
+// Bornes de la note d'un favori (0 signifie « non noté »)
+const (
+	MinRating = 1
+	MaxRating = 5
+)
+
+// ValidRating indique si la note est comprise entre MinRating et MaxRating, ou nulle
+func ValidRating(rating int) bool {
+	return rating == 0 || (rating >= MinRating && rating <= MaxRating)
+}
+
+// NormalizeTags met les étiquettes en minuscules, supprime les espaces superflus,
+// les étiquettes vides et les doublons, en conservant l'ordre
+func NormalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.Join(strings.Fields(tag), " "))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	if len(normalized) == 0 {
+		return nil
+	}
+	return normalized
+}
+
+// HasTag indique si l'élément porte l'étiquette, sans tenir compte de la casse
+func (i FavoriteItem) HasTag(tag string) bool {
+	for _, t := range i.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// CopyAnnotations reprend la note, l'évaluation et les étiquettes d'un autre élément
+func (i *FavoriteItem) CopyAnnotations(from FavoriteItem) {
+	i.Note = from.Note
+	i.Rating = from.Rating
+	i.Tags = from.Tags
+}
+
+// Clone renvoie une copie de l'élément qui ne partage ni ses étiquettes, ni
+// ses métadonnées, ni ses dates optionnelles avec l'original
+func (i FavoriteItem) Clone() FavoriteItem {
+	if i.Tags != nil {
+		i.Tags = append([]string(nil), i.Tags...)
+	}
+	if i.LastRefreshedAt != nil {
+		refreshedAt := *i.LastRefreshedAt
+		i.LastRefreshedAt = &refreshedAt
+	}
+	if i.Unavailable != nil {
+		unavailable := *i.Unavailable
+		i.Unavailable = &unavailable
+	}
+	if i.Metadata != nil {
+		metadata := *i.Metadata
+		if metadata.Genres != nil {
+			metadata.Genres = append([]string(nil), metadata.Genres...)
+		}
+		if metadata.Artists != nil {
+			metadata.Artists = append([]FavoriteRef(nil), metadata.Artists...)
+		}
+		if metadata.Album != nil {
+			album := *metadata.Album
+			metadata.Album = &album
+		}
+		i.Metadata = &metadata
+	}
+	return i
+}
+
+// Raisons d'indisponibilité d'un favori
+const (
+	// UnavailableNotFound indique que Spotify a répondu 404 pour l'élément
+	UnavailableNotFound = "not_found"
+	// UnavailableEmpty indique que Spotify a renvoyé un objet vide
+	UnavailableEmpty = "empty"
+)
+
+// Unavailability décrit pourquoi et depuis quand un favori est indisponible
+type Unavailability struct {
+	Reason string    `json:"reason"`
+	Since  time.Time `json:"since"`
+}
+
+// IsUnavailable indique si l'élément a été signalé comme indisponible
+func (i FavoriteItem) IsUnavailable() bool {
+	return i.Unavailable != nil
 }
 
-// Favorites représente une collection d'éléments favoris
+// ReplacementQuery renvoie la recherche permettant de trouver un remplaçant
+// à l'élément : son nom suivi de ses artistes
+func (i FavoriteItem) ReplacementQuery() string {
+	if artists := i.ArtistNames(); artists != "" {
+		return i.Name + " " + artists
+	}
+	return i.Name
+}
+
+// FavoriteRef référence un artiste ou un album lié à un favori
+type FavoriteRef struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// FavoriteMetadata est un instantané des informations Spotify d'un favori,
+// enregistré lors de l'ajout pour que les vues des favoris fonctionnent sans
+// appeler Spotify. Seuls les champs pertinents pour le type sont renseignés.
+type FavoriteMetadata struct {
+	// Artistes (et abonnés des playlists)
+	Genres     []string `json:"genres,omitempty"`
+	Popularity int      `json:"popularity,omitempty"`
+	Followers  int      `json:"followers,omitempty"`
+
+	// Albums et pistes
+	Artists     []FavoriteRef `json:"artists,omitempty"`
+	ReleaseDate string        `json:"release_date,omitempty"`
+
+	// Albums (et nombre de pistes des playlists)
+	Label      string `json:"label,omitempty"`
+	TrackCount int    `json:"track_count,omitempty"`
+
+	// Playlists
+	Owner string `json:"owner,omitempty"`
+
+	// Pistes
+	Album      *FavoriteRef `json:"album,omitempty"`
+	DurationMs int          `json:"duration_ms,omitempty"`
+	Explicit   bool         `json:"explicit,omitempty"`
+
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// RefreshedAt renvoie la date de la dernière mise à jour depuis Spotify :
+// le dernier rafraîchissement, ou à défaut la récupération des métadonnées
+func (i FavoriteItem) RefreshedAt() time.Time {
+	if i.LastRefreshedAt != nil {
+		return *i.LastRefreshedAt
+	}
+	if i.Metadata != nil {
+		return i.Metadata.FetchedAt
+	}
+	return time.Time{}
+}
+
+// ReleaseYear renvoie l'année de sortie de l'élément ou une chaîne vide
+func (i FavoriteItem) ReleaseYear() string {
+	if i.Metadata == nil || len(i.Metadata.ReleaseDate) < 4 {
+		return ""
+	}
+	return i.Metadata.ReleaseDate[:4]
+}
+
+// ArtistNames renvoie les noms des artistes de l'élément séparés par des virgules
+func (i FavoriteItem) ArtistNames() string {
+	if i.Metadata == nil {
+		return ""
+	}
+
+	names := make([]string, 0, len(i.Metadata.Artists))
+	for _, artist := range i.Metadata.Artists {
+		names = append(names, artist.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// Genres renvoie les genres de l'élément
+func (i FavoriteItem) Genres() []string {
+	if i.Metadata == nil {
+		return nil
+	}
+	return i.Metadata.Genres
+}
+
+// URI renvoie l'URI Spotify de l'élément (spotify:type:id), ou une chaîne
+// vide si l'élément n'est pas une ressource Spotify
+func (i FavoriteItem) URI() string {
+	if !i.TypeInfo().Spotify {
+		return ""
+	}
+	return "spotify:" + string(i.Type) + ":" + i.ID
+}
+
+// SpotifyURL renvoie le lien web Spotify de l'élément, ou une chaîne vide
+// si l'élément n'est pas une ressource Spotify
+func (i FavoriteItem) SpotifyURL() string {
+	if !i.TypeInfo().Spotify {
+		return ""
+	}
+	return "https://open.spotify.com/" + string(i.Type) + "/" + i.ID
+}
+
+// AlbumName renvoie le nom de l'album d'une piste ou une chaîne vide
+func (i FavoriteItem) AlbumName() string {
+	if i.Metadata == nil || i.Metadata.Album == nil {
+		return ""
+	}
+	return i.Metadata.Album.Name
+}
+
+// DurationMs renvoie la durée d'une piste en millisecondes, ou 0 si inconnue
+func (i FavoriteItem) DurationMs() int {
+	if i.Metadata == nil {
+		return 0
+	}
+	return i.Metadata.DurationMs
+}
+
+// Key renvoie la clé d'index de l'élément
+func (i FavoriteItem) Key() FavoriteKey {
+	return FavoriteKey{Type: i.Type, ID: i.ID}
+}
+
+// FavoriteKey identifie un favori par son type et son ID
+type FavoriteKey struct {
+	Type FavoriteType
+	ID   string
+}
+
+// String renvoie la clé sous la forme "type:id" utilisée par les templates
+func (k FavoriteKey) String() string {
+	return string(k.Type) + ":" + k.ID
+}
+
+// Favorites représente une collection d'éléments favoris indexée par (type, id)
+// et parcourue dans l'ordre d'insertion. Toutes les opérations unitaires sont
+// en temps constant.
+//
+// Favorites n'est pas sûr pour un usage concurrent : le verrouillage est
+// assuré par le stockage qui la détient.
 type Favorites struct {
-	Items []FavoriteItem `json:"items"`
-	mu    sync.Mutex
+	order *list.List
+	index map[FavoriteKey]*list.Element
 }
 
 // NewFavorites crée une nouvelle instance de Favorites
 func NewFavorites() *Favorites {
 	return &Favorites{
-		Items: []FavoriteItem{},
+		order: list.New(),
+		index: make(map[FavoriteKey]*list.Element),
 	}
 }
 
-// Add ajoute un élément favori
+// NewFavoritesFrom crée une collection à partir d'éléments, dans leur ordre.
+// En cas de doublon, la dernière occurrence remplace la précédente.
+func NewFavoritesFrom(items []FavoriteItem) *Favorites {
+	f := NewFavorites()
+	for _, item := range items {
+		f.Add(item)
+	}
+	return f
+}
+
+// Add ajoute un élément favori, ou le met à jour en conservant sa position
 func (f *Favorites) Add(item FavoriteItem) {
-	f.mu.Lock()
-	defer f.mu.Unlock()
-
-	// Vérifier si l'élément existe déjà
-	for i, existing := range f.Items {
-		if existing.ID == item.ID && existing.Type == item.Type {
-			// Mettre à jour l'élément
-			f.Items[i] = item
-			return
-		}
+	if e, ok := f.index[item.Key()]; ok {
+		e.Value = item
+		return
 	}
 
-	// Ajouter l'élément
 	if item.AddedAt.IsZero() {
 		item.AddedAt = time.Now()
 	}
-	f.Items = append(f.Items, item)
+	f.index[item.Key()] = f.order.PushBack(item)
 }
 
-// Remove supprime un élément favori
-func (f *Favorites) Remove(id string, itemType FavoriteType) {
-	f.mu.Lock()
-	defer f.mu.Unlock()
+// Remove supprime un élément favori et indique s'il était présent
+func (f *Favorites) Remove(id string, itemType FavoriteType) bool {
+	key := FavoriteKey{Type: itemType, ID: id}
+	e, ok := f.index[key]
+	if !ok {
+		return false
+	}
 
-	var newItems []FavoriteItem
-	for _, item := range f.Items {
-		if item.ID != id || item.Type != itemType {
-			newItems = append(newItems, item)
+	f.order.Remove(e)
+	delete(f.index, key)
+	return true
+}
+
+// InsertBefore ajoute l'élément juste avant l'élément mark, ou à la fin si
+// mark est absent. Un élément déjà présent est mis à jour et déplacé.
+func (f *Favorites) InsertBefore(item FavoriteItem, mark FavoriteKey) {
+	e, ok := f.index[item.Key()]
+	if ok {
+		e.Value = item
+	} else {
+		e = f.order.PushBack(item)
+		f.index[item.Key()] = e
+	}
+	next, ok := f.index[mark]
+	switch {
+	case !ok:
+		f.order.MoveToBack(e)
+	case next != e:
+		f.order.MoveBefore(e, next)
+	}
+}
+
+// Next renvoie la clé de l'élément qui suit l'élément donné, tous types
+// confondus, s'il y en a un
+func (f *Favorites) Next(key FavoriteKey) (FavoriteKey, bool) {
+	e, ok := f.index[key]
+	if !ok || e.Next() == nil {
+		return FavoriteKey{}, false
+	}
+	return e.Next().Value.(FavoriteItem).Key(), true
+}
+
+// Move déplace un élément à la position donnée parmi les éléments de même
+// type (à la fin si la position est hors limites) et indique s'il était présent.
+// Contrairement aux autres opérations, le déplacement est en temps linéaire.
+func (f *Favorites) Move(id string, itemType FavoriteType, position int) bool {
+	e, ok := f.index[FavoriteKey{Type: itemType, ID: id}]
+	if !ok {
+		return false
+	}
+
+	var last *list.Element
+	index := 0
+	for cur := f.order.Front(); cur != nil; cur = cur.Next() {
+		if cur == e || cur.Value.(FavoriteItem).Type != itemType {
+			continue
 		}
+		if index == position {
+			f.order.MoveBefore(e, cur)
+			return true
+		}
+		last = cur
+		index++
+	}
+	if last != nil {
+		f.order.MoveAfter(e, last)
 	}
-	f.Items = newItems
+	return true
 }
 
-// Get renvoie tous les éléments favoris
+// Get renvoie une copie de tous les éléments favoris dans l'ordre d'insertion
 func (f *Favorites) Get() []FavoriteItem {
-	f.mu.Lock()
-	defer f.mu.Unlock()
-
-	// Renvoyer une copie pour éviter les conditions de course
-	items := make([]FavoriteItem, len(f.Items))
-	copy(items, f.Items)
+	items := make([]FavoriteItem, 0, f.order.Len())
+	for e := f.order.Front(); e != nil; e = e.Next() {
+		items = append(items, e.Value.(FavoriteItem))
+	}
 	return items
 }
 
 // GetByType renvoie les éléments favoris d'un type spécifique
 func (f *Favorites) GetByType(itemType FavoriteType) []FavoriteItem {
-	f.mu.Lock()
-	defer f.mu.Unlock()
-
 	var items []FavoriteItem
-	for _, item := range f.Items {
-		if item.Type == itemType {
+	for e := f.order.Front(); e != nil; e = e.Next() {
+		if item := e.Value.(FavoriteItem); item.Type == itemType {
 			items = append(items, item)
 		}
 	}
 	return items
 }
 
+// Find renvoie l'élément favori correspondant s'il existe
+func (f *Favorites) Find(id string, itemType FavoriteType) (FavoriteItem, bool) {
+	e, ok := f.index[FavoriteKey{Type: itemType, ID: id}]
+	if !ok {
+		return FavoriteItem{}, false
+	}
+	return e.Value.(FavoriteItem), true
+}
+
 // Contains vérifie si un élément est déjà dans les favoris
 func (f *Favorites) Contains(id string, itemType FavoriteType) bool {
-	f.mu.Lock()
-	defer f.mu.Unlock()
+	_, ok := f.index[FavoriteKey{Type: itemType, ID: id}]
+	return ok
+}
 
-	for _, item := range f.Items {
-		if item.ID == id && item.Type == itemType {
-			return true
+// ContainsMany vérifie la présence de plusieurs éléments en une seule passe.
+// La carte renvoyée est indexée par FavoriteKey.String() et ne contient que
+// les clés présentes dans les favoris.
+func (f *Favorites) ContainsMany(keys []FavoriteKey) map[string]bool {
+	found := make(map[string]bool)
+	for _, key := range keys {
+		if _, ok := f.index[key]; ok {
+			found[key.String()] = true
 		}
 	}
-	return false
+	return found
+}
+
+// Len renvoie le nombre d'éléments favoris
+func (f *Favorites) Len() int {
+	return f.order.Len()
+}
+
+// Clone renvoie une copie indépendante de la collection et de ses éléments
+func (f *Favorites) Clone() *Favorites {
+	clone := NewFavorites()
+	for e := f.order.Front(); e != nil; e = e.Next() {
+		clone.Add(e.Value.(FavoriteItem).Clone())
+	}
+	return clone
+}
+
+// MarshalJSON encode la collection sous forme de tableau ordonné
+func (f *Favorites) MarshalJSON() ([]byte, error) {
+	return json.Marshal(f.Get())
+}
+
+// UnmarshalJSON décode un tableau d'éléments favoris
+func (f *Favorites) UnmarshalJSON(data []byte) error {
+	var items []FavoriteItem
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+	*f = *NewFavoritesFrom(items)
+	return nil
 }
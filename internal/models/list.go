@@ -0,0 +1,209 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// List représente une liste nommée créée par l'utilisateur, pouvant contenir
+// des artistes, des albums et des pistes dans un ordre choisi
+type List struct {
+	ID          string      `json:"id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	CoverURL    string      `json:"cover_url,omitempty"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+	Entries     []ListEntry `json:"entries"`
+
+	// Rule est renseignée pour les listes automatiques, dont les éléments sont
+	// recalculés à chaque évaluation au lieu d'être gérés manuellement
+	Rule            *SmartRule `json:"rule,omitempty"`
+	EvaluatedAt     *time.Time `json:"evaluated_at,omitempty"`
+	EvaluationError string     `json:"evaluation_error,omitempty"`
+}
+
+// ListEntry est un élément d'une liste. Le nom et l'image sont enregistrés
+// pour afficher les éléments qui ne sont pas dans les favoris.
+type ListEntry struct {
+	ID       string       `json:"id"`
+	Type     FavoriteType `json:"type"`
+	Name     string       `json:"name"`
+	ImageURL string       `json:"image_url"`
+	Position int          `json:"position"`
+	AddedAt  time.Time    `json:"added_at"`
+}
+
+// Key renvoie la clé de l'élément
+func (e ListEntry) Key() FavoriteKey {
+	return FavoriteKey{Type: e.Type, ID: e.ID}
+}
+
+// FavoriteItem convertit l'élément en favori minimal pour l'affichage
+func (e ListEntry) FavoriteItem() FavoriteItem {
+	return FavoriteItem{
+		ID:       e.ID,
+		Type:     e.Type,
+		Name:     e.Name,
+		ImageURL: e.ImageURL,
+		AddedAt:  e.AddedAt,
+	}
+}
+
+// NewList crée une liste vide avec un identifiant aléatoire
+func NewList(name, description, coverURL string) List {
+	now := time.Now()
+	return List{
+		ID:          newListID(),
+		Name:        name,
+		Description: description,
+		CoverURL:    coverURL,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		Entries:     []ListEntry{},
+	}
+}
+
+// newListID génère un identifiant de liste aléatoire
+func newListID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand ne devrait pas échouer ; se rabattre sur l'horloge
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
+
+// Cover renvoie l'image de couverture de la liste, ou à défaut celle de son
+// premier élément illustré
+func (l List) Cover() string {
+	if l.CoverURL != "" {
+		return l.CoverURL
+	}
+	for _, entry := range l.Entries {
+		if entry.ImageURL != "" {
+			return entry.ImageURL
+		}
+	}
+	return ""
+}
+
+// IsSmart indique si la liste est une liste automatique
+func (l List) IsSmart() bool {
+	return l.Rule != nil
+}
+
+// SetEntries remplace les éléments d'une liste automatique par le résultat
+// d'une évaluation, en conservant la date d'ajout des éléments déjà présents
+func (l *List) SetEntries(entries []ListEntry, evaluatedAt time.Time) {
+	previous := make(map[FavoriteKey]time.Time, len(l.Entries))
+	for _, entry := range l.Entries {
+		previous[entry.Key()] = entry.AddedAt
+	}
+
+	l.Entries = make([]ListEntry, 0, len(entries))
+	for _, entry := range entries {
+		if addedAt, ok := previous[entry.Key()]; ok {
+			entry.AddedAt = addedAt
+		} else if entry.AddedAt.IsZero() {
+			entry.AddedAt = evaluatedAt
+		}
+		l.Entries = append(l.Entries, entry)
+	}
+	l.renumber()
+	l.EvaluatedAt = &evaluatedAt
+	l.EvaluationError = ""
+}
+
+// Len renvoie le nombre d'éléments de la liste
+func (l List) Len() int {
+	return len(l.Entries)
+}
+
+// Clone renvoie une copie indépendante de la liste
+func (l List) Clone() List {
+	entries := make([]ListEntry, len(l.Entries))
+	copy(entries, l.Entries)
+	l.Entries = entries
+	if l.Rule != nil {
+		rule := *l.Rule
+		rule.Tags = append([]string(nil), rule.Tags...)
+		l.Rule = &rule
+	}
+	return l
+}
+
+// indexOf renvoie la position d'un élément dans la liste ou -1
+func (l *List) indexOf(key FavoriteKey) int {
+	for i, entry := range l.Entries {
+		if entry.Key() == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// Contains indique si la liste contient l'élément
+func (l *List) Contains(id string, itemType FavoriteType) bool {
+	return l.indexOf(FavoriteKey{Type: itemType, ID: id}) >= 0
+}
+
+// Add insère un élément à la position donnée, ou à la fin si la position est
+// négative ou hors limites. Un élément déjà présent est déplacé.
+func (l *List) Add(entry ListEntry, position int) {
+	if i := l.indexOf(entry.Key()); i >= 0 {
+		existing := l.Entries[i]
+		entry.AddedAt = existing.AddedAt
+		l.Entries = append(l.Entries[:i], l.Entries[i+1:]...)
+	}
+	if entry.AddedAt.IsZero() {
+		entry.AddedAt = time.Now()
+	}
+
+	if position < 0 || position > len(l.Entries) {
+		position = len(l.Entries)
+	}
+	l.Entries = append(l.Entries, ListEntry{})
+	copy(l.Entries[position+1:], l.Entries[position:])
+	l.Entries[position] = entry
+	l.renumber()
+}
+
+// Remove retire un élément de la liste et indique s'il était présent
+func (l *List) Remove(id string, itemType FavoriteType) bool {
+	i := l.indexOf(FavoriteKey{Type: itemType, ID: id})
+	if i < 0 {
+		return false
+	}
+	l.Entries = append(l.Entries[:i], l.Entries[i+1:]...)
+	l.renumber()
+	return true
+}
+
+// Move déplace un élément à une nouvelle position et indique s'il était présent
+func (l *List) Move(id string, itemType FavoriteType, position int) bool {
+	i := l.indexOf(FavoriteKey{Type: itemType, ID: id})
+	if i < 0 {
+		return false
+	}
+	l.Add(l.Entries[i], position)
+	return true
+}
+
+// renumber réattribue des positions consécutives dans l'ordre actuel
+func (l *List) renumber() {
+	for i := range l.Entries {
+		l.Entries[i].Position = i
+	}
+	l.UpdatedAt = time.Now()
+}
+
+// SortByPosition trie les éléments selon leur position enregistrée
+func (l *List) SortByPosition() {
+	sort.SliceStable(l.Entries, func(i, j int) bool {
+		return l.Entries[i].Position < l.Entries[j].Position
+	})
+}
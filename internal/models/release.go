@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// SeenReleases est la discographie d'un artiste favori vue lors de la
+// dernière recherche de nouvelles sorties
+type SeenReleases struct {
+	// Latest est la date de sortie la plus récente vue
+	Latest string `json:"latest"`
+	// Albums sont les identifiants des albums vus
+	Albums    []string  `json:"albums"`
+	CheckedAt time.Time `json:"checked_at"`
+}
@@ -0,0 +1,187 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sources des règles de listes automatiques
+const (
+	// RuleSourceFavorites sélectionne des éléments parmi les favoris
+	RuleSourceFavorites = "favorites"
+	// RuleSourceCatalog recherche des éléments dans le catalogue Spotify
+	RuleSourceCatalog = "catalog"
+)
+
+// Bornes du nombre d'éléments d'une liste automatique
+const (
+	DefaultRuleLimit = 50
+	MaxRuleLimit     = 100
+)
+
+// SmartRule décrit une liste automatique, réévaluée à la demande ou
+// périodiquement. Les critères vides sont ignorés.
+type SmartRule struct {
+	Source string       `json:"source"`
+	Type   FavoriteType `json:"type"`
+
+	// Year est une année ("2024"), une plage ("2020-2024"), "this" pour
+	// l'année en cours ou "last" pour l'année précédente
+	Year  string `json:"year,omitempty"`
+	Genre string `json:"genre,omitempty"`
+
+	// Critères sur les favoris
+	Tags              []string `json:"tags,omitempty"`
+	MinRating         int      `json:"min_rating,omitempty"`
+	PopularityBelow   int      `json:"popularity_below,omitempty"`
+	PopularityAtLeast int      `json:"popularity_at_least,omitempty"`
+	AddedWithinDays   int      `json:"added_within_days,omitempty"`
+
+	// FromFavoriteArtists limite la recherche dans le catalogue aux artistes favoris
+	FromFavoriteArtists bool `json:"from_favorite_artists,omitempty"`
+
+	Limit int `json:"limit,omitempty"`
+}
+
+// Validate vérifie la cohérence de la règle
+func (r SmartRule) Validate() error {
+	if r.Source != RuleSourceFavorites && r.Source != RuleSourceCatalog {
+		return fmt.Errorf("source inconnue : %q", r.Source)
+	}
+	// Les règles portent sur les artistes, albums et pistes, seuls types
+	// recherchables dans le catalogue et dotés des métadonnées filtrées
+	if r.Type != FavoriteTypeArtist && r.Type != FavoriteTypeAlbum && r.Type != FavoriteTypeTrack {
+		return fmt.Errorf("type inconnu : %q", r.Type)
+	}
+	if r.Year != "" {
+		if _, _, ok := r.YearRange(time.Now()); !ok {
+			return fmt.Errorf("année invalide : %q", r.Year)
+		}
+	}
+	if r.MinRating != 0 && !ValidRating(r.MinRating) {
+		return fmt.Errorf("note minimale invalide : %d", r.MinRating)
+	}
+	if r.PopularityBelow < 0 || r.PopularityBelow > 100 || r.PopularityAtLeast < 0 || r.PopularityAtLeast > 100 {
+		return fmt.Errorf("la popularité doit être comprise entre 0 et 100")
+	}
+	if r.AddedWithinDays < 0 || r.Limit < 0 || r.Limit > MaxRuleLimit {
+		return fmt.Errorf("durée ou limite invalide")
+	}
+	if r.Source == RuleSourceCatalog {
+		if r.Year == "" && r.Genre == "" && !r.FromFavoriteArtists {
+			return fmt.Errorf("une recherche dans le catalogue nécessite une année, un genre ou les artistes favoris")
+		}
+		if r.FromFavoriteArtists && r.Type == FavoriteTypeArtist {
+			return fmt.Errorf("la recherche par artistes favoris ne s'applique qu'aux albums et pistes")
+		}
+		if len(r.Tags) > 0 || r.MinRating > 0 || r.AddedWithinDays > 0 {
+			return fmt.Errorf("les étiquettes, notes et dates d'ajout ne s'appliquent qu'aux favoris")
+		}
+		// La recherche Spotify ne renvoie pas la popularité des albums
+		if r.Type == FavoriteTypeAlbum && (r.PopularityBelow > 0 || r.PopularityAtLeast > 0) {
+			return fmt.Errorf("la popularité des albums ne peut pas être filtrée dans le catalogue")
+		}
+	}
+	return nil
+}
+
+// Normalize nettoie les critères saisis et applique la limite par défaut
+func (r *SmartRule) Normalize() {
+	r.Year = strings.ToLower(strings.TrimSpace(r.Year))
+	r.Genre = strings.TrimSpace(r.Genre)
+	r.Tags = NormalizeTags(r.Tags)
+	if r.Limit == 0 {
+		r.Limit = DefaultRuleLimit
+	}
+}
+
+// YearRange renvoie les années de début et de fin correspondant au critère
+// d'année, évalué à la date donnée
+func (r SmartRule) YearRange(now time.Time) (int, int, bool) {
+	switch r.Year {
+	case "":
+		return 0, 0, false
+	case "this":
+		return now.Year(), now.Year(), true
+	case "last":
+		return now.Year() - 1, now.Year() - 1, true
+	}
+
+	from, to, isRange := strings.Cut(r.Year, "-")
+	start, err := strconv.Atoi(from)
+	if err != nil {
+		return 0, 0, false
+	}
+	end := start
+	if isRange {
+		if end, err = strconv.Atoi(to); err != nil || end < start {
+			return 0, 0, false
+		}
+	}
+	return start, end, true
+}
+
+// YearQuery renvoie le critère d'année au format de recherche Spotify
+// ("year:2024" ou "year:2020-2024"), ou une chaîne vide
+func (r SmartRule) YearQuery(now time.Time) string {
+	start, end, ok := r.YearRange(now)
+	if !ok {
+		return ""
+	}
+	if start == end {
+		return "year:" + strconv.Itoa(start)
+	}
+	return "year:" + strconv.Itoa(start) + "-" + strconv.Itoa(end)
+}
+
+// Describe renvoie une description lisible de la règle
+func (r SmartRule) Describe() string {
+	var parts []string
+
+	subject := map[FavoriteType]string{
+		FavoriteTypeArtist: "Artistes",
+		FavoriteTypeAlbum:  "Albums",
+		FavoriteTypeTrack:  "Pistes",
+	}[r.Type]
+	switch {
+	case r.Source == RuleSourceFavorites:
+		subject += " favoris"
+	case r.FromFavoriteArtists:
+		subject += " des artistes favoris"
+	default:
+		subject += " du catalogue"
+	}
+	parts = append(parts, subject)
+
+	switch r.Year {
+	case "":
+	case "this":
+		parts = append(parts, "sortis cette année")
+	case "last":
+		parts = append(parts, "sortis l'année dernière")
+	default:
+		parts = append(parts, "sortis en "+r.Year)
+	}
+	if r.Genre != "" {
+		parts = append(parts, "genre « "+r.Genre+" »")
+	}
+	if len(r.Tags) > 0 {
+		parts = append(parts, "étiquetés « "+strings.Join(r.Tags, " », « ")+" »")
+	}
+	if r.MinRating > 0 {
+		parts = append(parts, "notés "+strconv.Itoa(r.MinRating)+"★ ou plus")
+	}
+	if r.PopularityBelow > 0 {
+		parts = append(parts, "popularité < "+strconv.Itoa(r.PopularityBelow))
+	}
+	if r.PopularityAtLeast > 0 {
+		parts = append(parts, "popularité ≥ "+strconv.Itoa(r.PopularityAtLeast))
+	}
+	if r.AddedWithinDays > 0 {
+		parts = append(parts, "ajoutés ces "+strconv.Itoa(r.AddedWithinDays)+" derniers jours")
+	}
+
+	return strings.Join(parts, " · ")
+}
@@ -0,0 +1,148 @@
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TokenScope est la portée d'un jeton d'accès personnel
+type TokenScope string
+
+// Portées des jetons d'accès personnels
+const (
+	// TokenScopeRead n'autorise que la lecture (GET)
+	TokenScopeRead TokenScope = "read"
+	// TokenScopeFavoritesWrite autorise en plus la modification des favoris
+	TokenScopeFavoritesWrite TokenScope = "favorites-write"
+)
+
+// TokenPrefix préfixe les jetons d'accès personnels, pour les reconnaître
+// dans un fichier de configuration ou un dépôt de code
+const TokenPrefix = "mex_"
+
+// ParseTokenScope valide une portée de jeton
+func ParseTokenScope(value string) (TokenScope, bool) {
+	switch scope := TokenScope(value); scope {
+	case TokenScopeRead, TokenScopeFavoritesWrite:
+		return scope, true
+	}
+	return "", false
+}
+
+// Label renvoie le libellé de la portée
+func (s TokenScope) Label() string {
+	switch s {
+	case TokenScopeRead:
+		return "Lecture seule"
+	case TokenScopeFavoritesWrite:
+		return "Lecture et modification des favoris"
+	default:
+		return string(s)
+	}
+}
+
+// APIToken est un jeton d'accès personnel à l'API. Seule l'empreinte SHA-256
+// du secret est conservée : le secret n'est montré qu'à la création.
+type APIToken struct {
+	ID    string     `json:"id"`
+	Name  string     `json:"name"`
+	Scope TokenScope `json:"scope"`
+	// Owner et OwnerName identifient l'utilisateur Spotify qui a créé le jeton
+	Owner     string `json:"owner"`
+	OwnerName string `json:"owner_name,omitempty"`
+	// Hash est l'empreinte hexadécimale du secret ; Hint en est le début, affiché
+	// pour reconnaître le jeton
+	Hash       string     `json:"hash,omitempty"`
+	Hint       string     `json:"hint"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// NewAPIToken crée un jeton et renvoie son secret, qui n'est pas conservé.
+// Une durée de validité nulle crée un jeton sans expiration.
+func NewAPIToken(name string, scope TokenScope, owner, ownerName string, ttl time.Duration) (APIToken, string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return APIToken{}, "", fmt.Errorf("génération du jeton : %w", err)
+	}
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return APIToken{}, "", fmt.Errorf("génération du jeton : %w", err)
+	}
+
+	plain := TokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	token := APIToken{
+		ID:        hex.EncodeToString(id),
+		Name:      name,
+		Scope:     scope,
+		Owner:     owner,
+		OwnerName: ownerName,
+		Hash:      HashAPIToken(plain),
+		Hint:      plain[:len(TokenPrefix)+6],
+		CreatedAt: time.Now(),
+	}
+	if ttl > 0 {
+		expires := token.CreatedAt.Add(ttl)
+		token.ExpiresAt = &expires
+	}
+	return token, plain, nil
+}
+
+// HashAPIToken renvoie l'empreinte d'un secret de jeton. Les secrets étant
+// aléatoires et longs, un hachage rapide suffit.
+func HashAPIToken(plain string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(plain)))
+	return hex.EncodeToString(sum[:])
+}
+
+// Public renvoie le jeton sans son empreinte, pour les réponses de l'API
+func (t APIToken) Public() APIToken {
+	t.Hash = ""
+	return t
+}
+
+// Revoked indique si le jeton a été révoqué
+func (t APIToken) Revoked() bool {
+	return t.RevokedAt != nil
+}
+
+// Expired indique si le jeton a expiré à l'instant donné
+func (t APIToken) Expired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
+// Active indique si le jeton peut être utilisé à l'instant donné
+func (t APIToken) Active(now time.Time) bool {
+	return !t.Revoked() && !t.Expired(now)
+}
+
+// Status renvoie le libellé de l'état du jeton
+func (t APIToken) Status() string {
+	switch {
+	case t.Revoked():
+		return "Révoqué"
+	case t.Expired(time.Now()):
+		return "Expiré"
+	default:
+		return "Actif"
+	}
+}
+
+// Allows indique si la portée du jeton couvre la portée demandée
+func (t APIToken) Allows(scope TokenScope) bool {
+	switch scope {
+	case TokenScopeRead:
+		return t.Scope == TokenScopeRead || t.Scope == TokenScopeFavoritesWrite
+	case TokenScopeFavoritesWrite:
+		return t.Scope == TokenScopeFavoritesWrite
+	default:
+		return false
+	}
+}
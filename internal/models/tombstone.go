@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/yourusername/melody-explorer/internal/hlc"
+)
+
+// Tombstone conserve la trace d'un favori supprimé, pour que la suppression
+// soit transmise aux appareils synchronisés et l'emporte sur une version
+// plus ancienne de l'élément
+type Tombstone struct {
+	Type FavoriteType `json:"type"`
+	ID   string       `json:"id"`
+	// Version et Revision ont le même sens que pour FavoriteItem
+	Version   hlc.Timestamp `json:"version"`
+	Revision  hlc.Timestamp `json:"revision"`
+	DeletedAt time.Time     `json:"deleted_at"`
+}
+
+// Key renvoie la clé du favori supprimé
+func (t Tombstone) Key() FavoriteKey {
+	return FavoriteKey{Type: t.Type, ID: t.ID}
+}
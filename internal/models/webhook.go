@@ -0,0 +1,164 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// WebhookEvent est un type d'événement transmis aux webhooks
+type WebhookEvent string
+
+// Événements transmis aux webhooks
+const (
+	WebhookFavoriteAdded   WebhookEvent = "favorite.added"
+	WebhookFavoriteRemoved WebhookEvent = "favorite.removed"
+	WebhookListChanged     WebhookEvent = "list.changed"
+	WebhookReleaseNew      WebhookEvent = "release.new"
+	// WebhookPing est envoyé à la demande pour tester un webhook
+	WebhookPing WebhookEvent = "ping"
+)
+
+// WebhookEvents liste les événements auxquels un webhook peut s'abonner
+var WebhookEvents = []WebhookEvent{WebhookFavoriteAdded, WebhookFavoriteRemoved, WebhookListChanged, WebhookReleaseNew}
+
+// ParseWebhookEvent valide un type d'événement d'abonnement
+func ParseWebhookEvent(value string) (WebhookEvent, bool) {
+	for _, event := range WebhookEvents {
+		if string(event) == value {
+			return event, true
+		}
+	}
+	return "", false
+}
+
+// Webhook est un abonnement d'un outil externe aux événements. Les
+// notifications sont signées avec Secret (HMAC-SHA256), qui n'est montré
+// qu'à la création.
+type Webhook struct {
+	ID          string         `json:"id"`
+	URL         string         `json:"url"`
+	Description string         `json:"description,omitempty"`
+	Events      []WebhookEvent `json:"events"`
+	Secret      string         `json:"secret,omitempty"`
+	Active      bool           `json:"active"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
+
+// NewWebhook crée un webhook actif avec un secret aléatoire
+func NewWebhook(url, description string, events []WebhookEvent) (Webhook, error) {
+	id, err := randomHex(8)
+	if err != nil {
+		return Webhook{}, fmt.Errorf("génération du webhook : %w", err)
+	}
+	secret, err := randomHex(32)
+	if err != nil {
+		return Webhook{}, fmt.Errorf("génération du webhook : %w", err)
+	}
+	return Webhook{
+		ID:          id,
+		URL:         url,
+		Description: description,
+		Events:      events,
+		Secret:      "whsec_" + secret,
+		Active:      true,
+		CreatedAt:   time.Now(),
+	}, nil
+}
+
+// Public renvoie le webhook sans son secret, pour les réponses de l'API
+func (w Webhook) Public() Webhook {
+	w.Secret = ""
+	return w
+}
+
+// Subscribed indique si le webhook reçoit l'événement donné
+func (w Webhook) Subscribed(event WebhookEvent) bool {
+	if !w.Active {
+		return false
+	}
+	for _, subscribed := range w.Events {
+		if subscribed == event {
+			return true
+		}
+	}
+	return false
+}
+
+// DeliveryStatus est l'état d'une notification
+type DeliveryStatus string
+
+// États d'une notification
+const (
+	// DeliveryPending attend sa première tentative ou une nouvelle tentative
+	DeliveryPending DeliveryStatus = "pending"
+	// DeliveryDelivered a reçu une réponse 2xx
+	DeliveryDelivered DeliveryStatus = "delivered"
+	// DeliveryFailed a épuisé ses tentatives
+	DeliveryFailed DeliveryStatus = "failed"
+)
+
+// DeliveryAttempt est une tentative d'envoi d'une notification
+type DeliveryAttempt struct {
+	At time.Time `json:"at"`
+	// StatusCode est le code de la réponse, 0 si le destinataire n'a pas répondu
+	StatusCode int    `json:"status_code,omitempty"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// WebhookDelivery est une notification d'un événement à un webhook, avec
+// l'historique de ses tentatives
+type WebhookDelivery struct {
+	ID        string            `json:"id"`
+	WebhookID string            `json:"webhook_id"`
+	Event     WebhookEvent      `json:"event"`
+	Payload   json.RawMessage   `json:"payload"`
+	Status    DeliveryStatus    `json:"status"`
+	Attempts  []DeliveryAttempt `json:"attempts"`
+	// NextAttemptAt est la date de la prochaine tentative d'une notification en attente
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	// RedeliveryOf est la notification renvoyée manuellement, le cas échéant
+	RedeliveryOf string    `json:"redelivery_of,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// NewWebhookDelivery crée une notification en attente, à envoyer immédiatement
+func NewWebhookDelivery(webhookID string, event WebhookEvent, payload json.RawMessage) (WebhookDelivery, error) {
+	id, err := randomHex(8)
+	if err != nil {
+		return WebhookDelivery{}, fmt.Errorf("génération de la notification : %w", err)
+	}
+	now := time.Now()
+	return WebhookDelivery{
+		ID:            id,
+		WebhookID:     webhookID,
+		Event:         event,
+		Payload:       payload,
+		Status:        DeliveryPending,
+		Attempts:      []DeliveryAttempt{},
+		NextAttemptAt: &now,
+		CreatedAt:     now,
+	}, nil
+}
+
+// NewWebhookEventID génère l'identifiant d'un événement, commun à toutes
+// ses notifications
+func NewWebhookEventID() (string, error) {
+	id, err := randomHex(8)
+	if err != nil {
+		return "", fmt.Errorf("génération de l'événement : %w", err)
+	}
+	return "evt_" + id, nil
+}
+
+// randomHex renvoie n octets aléatoires en hexadécimal
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,167 @@
+// Package openapi décrit une API HTTP au format OpenAPI 3.0 et génère les
+// schémas des corps de réponse à partir des types Go.
+package openapi
+
+// Version est la version de la spécification OpenAPI produite
+const Version = "3.0.3"
+
+// Document est la racine d'un document OpenAPI
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Servers    []Server             `json:"servers,omitempty"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+	// Security liste les schémas d'authentification acceptés par défaut ; un
+	// élément vide rend l'authentification facultative
+	Security []map[string][]string `json:"security,omitempty"`
+}
+
+// Info décrit l'API
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Server est une URL de base de l'API
+type Server struct {
+	URL string `json:"url"`
+}
+
+// PathItem regroupe les opérations d'un chemin par méthode HTTP
+type PathItem struct {
+	Get    *Operation `json:"get,omitempty"`
+	Post   *Operation `json:"post,omitempty"`
+	Put    *Operation `json:"put,omitempty"`
+	Patch  *Operation `json:"patch,omitempty"`
+	Delete *Operation `json:"delete,omitempty"`
+}
+
+// Operations renvoie les opérations du chemin indexées par méthode
+func (p *PathItem) Operations() map[string]*Operation {
+	operations := make(map[string]*Operation)
+	for method, op := range map[string]*Operation{"GET": p.Get, "POST": p.Post, "PUT": p.Put, "PATCH": p.Patch, "DELETE": p.Delete} {
+		if op != nil {
+			operations[method] = op
+		}
+	}
+	return operations
+}
+
+// SetOperation associe une opération à une méthode HTTP et indique si la
+// méthode est prise en charge
+func (p *PathItem) SetOperation(method string, op *Operation) bool {
+	switch method {
+	case "GET":
+		p.Get = op
+	case "POST":
+		p.Post = op
+	case "PUT":
+		p.Put = op
+	case "PATCH":
+		p.Patch = op
+	case "DELETE":
+		p.Delete = op
+	default:
+		return false
+	}
+	return true
+}
+
+// Operation décrit une opération sur un chemin
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+// Parameter décrit un paramètre de chemin ou de requête
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody décrit le corps d'une requête
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response décrit une réponse
+type Response struct {
+	Description string                `json:"description"`
+	Headers     map[string]*Header    `json:"headers,omitempty"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// Header décrit un en-tête de réponse
+type Header struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// MediaType associe un schéma à un type de contenu
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// JSON renvoie un contenu application/json décrit par le schéma
+func JSON(schema *Schema) map[string]*MediaType {
+	return map[string]*MediaType{"application/json": {Schema: schema}}
+}
+
+// Text renvoie un contenu text/plain
+func Text() map[string]*MediaType {
+	return map[string]*MediaType{"text/plain": {Schema: &Schema{Type: "string"}}}
+}
+
+// Components contient les schémas réutilisables
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme décrit un mode d'authentification
+type SecurityScheme struct {
+	Type        string `json:"type"`
+	Scheme      string `json:"scheme,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// Schema est un schéma de données (sous-ensemble de JSON Schema utilisé par OpenAPI 3.0)
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Minimum              *int               `json:"minimum,omitempty"`
+	Maximum              *int               `json:"maximum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+}
+
+// RefName renvoie le nom du schéma référencé, ou une chaîne vide
+func (s *Schema) RefName() string {
+	const prefix = "#/components/schemas/"
+	if len(s.Ref) > len(prefix) && s.Ref[:len(prefix)] == prefix {
+		return s.Ref[len(prefix):]
+	}
+	return ""
+}
+
+// Int renvoie un pointeur vers un entier, pour les bornes des schémas
+func Int(v int) *int {
+	return &v
+}
@@ -0,0 +1,168 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// Generator construit les schémas des types Go à partir de leurs balises
+// json. Les structures nommées sont placées dans les composants et
+// référencées par $ref.
+type Generator struct {
+	// TypeName renvoie le nom du composant d'une structure ; par défaut,
+	// le nom du type avec une majuscule initiale
+	TypeName func(t reflect.Type) string
+
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+// NewGenerator crée un générateur de schémas
+func NewGenerator() *Generator {
+	return &Generator{
+		schemas: make(map[string]*Schema),
+		names:   make(map[reflect.Type]string),
+	}
+}
+
+// Schemas renvoie les schémas des composants générés
+func (g *Generator) Schemas() map[string]*Schema {
+	return g.schemas
+}
+
+// SchemaOf renvoie le schéma du type de la valeur
+func (g *Generator) SchemaOf(v interface{}) *Schema {
+	if v == nil {
+		return &Schema{}
+	}
+	return g.Schema(reflect.TypeOf(v))
+}
+
+// Schema renvoie le schéma d'un type Go
+func (g *Generator) Schema(t reflect.Type) *Schema {
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case rawMessageType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return g.Schema(t.Elem())
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		// Les tranches nil sont encodées en null
+		return &Schema{Type: "array", Items: g.Schema(t.Elem()), Nullable: t.Kind() == reflect.Slice}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.Schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + g.component(t)}
+	default:
+		// interface{} et types non représentables : valeur quelconque
+		return &Schema{}
+	}
+}
+
+// component enregistre une structure nommée et renvoie le nom de son composant
+func (g *Generator) component(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+
+	name := g.typeName(t)
+	// Deux types de paquets différents portant le même nom sont distingués
+	// par le nom de leur paquet
+	if _, taken := g.schemas[name]; taken {
+		pkg := t.PkgPath()
+		name = upperFirst(pkg[strings.LastIndex(pkg, "/")+1:]) + name
+	}
+	g.names[t] = name
+	// Réserver le nom avant de parcourir les champs, pour les types récursifs
+	g.schemas[name] = &Schema{}
+	*g.schemas[name] = *g.structSchema(t)
+	return name
+}
+
+func (g *Generator) typeName(t reflect.Type) string {
+	if g.TypeName != nil {
+		return g.TypeName(t)
+	}
+	return upperFirst(t.Name())
+}
+
+// structSchema décrit les champs exportés d'une structure. Les champs sans
+// omitempty sont toujours présents et donc requis.
+func (g *Generator) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	g.addFields(schema, t)
+	return schema
+}
+
+func (g *Generator) addFields(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+
+		// Les champs des structures incorporées sans nom JSON sont remontés
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				g.addFields(schema, embedded)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		fieldSchema := g.Schema(field.Type)
+		if strings.Contains(options, "string") {
+			fieldSchema = &Schema{Type: "string"}
+		}
+		schema.Properties[name] = fieldSchema
+		if !strings.Contains(options, "omitempty") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+// upperFirst met la première lettre en majuscule
+func upperFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[size:]
+}
@@ -0,0 +1,193 @@
+// Package releases détecte les nouvelles sorties des artistes favoris en
+// comparant périodiquement leur discographie sur Spotify à celle déjà vue.
+package releases
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/yourusername/melody-explorer/internal/models"
+	"github.com/yourusername/melody-explorer/internal/spotify"
+	"github.com/yourusername/melody-explorer/internal/storage"
+)
+
+// ErrNotAuthenticated est renvoyée lorsque la vérification est lancée sans
+// session Spotify active
+var ErrNotAuthenticated = errors.New("aucune session Spotify active pour interroger le catalogue")
+
+// albumsPerArtist est le nombre d'albums consultés par artiste : Spotify
+// les renvoie du plus récent au plus ancien au sein de chaque catégorie
+const albumsPerArtist = 50
+
+// Release est une nouvelle sortie d'un artiste favori
+type Release struct {
+	ArtistID    string `json:"artist_id"`
+	ArtistName  string `json:"artist_name"`
+	AlbumID     string `json:"album_id"`
+	AlbumName   string `json:"album_name"`
+	AlbumType   string `json:"album_type"`
+	ReleaseDate string `json:"release_date"`
+	ImageURL    string `json:"image_url,omitempty"`
+	URL         string `json:"url,omitempty"`
+}
+
+// Watcher vérifie périodiquement la discographie des artistes favoris et
+// signale les albums sortis depuis la vérification précédente. La première
+// vérification d'un artiste enregistre sa discographie sans rien signaler.
+type Watcher struct {
+	client    *spotify.Client
+	favorites storage.FavoritesStore
+	seen      *storage.ReleasesStorage
+	interval  time.Duration
+
+	mu        sync.Mutex
+	observers []func(Release)
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewWatcher crée un observateur des sorties exécuté toutes les interval
+func NewWatcher(client *spotify.Client, favorites storage.FavoritesStore, seen *storage.ReleasesStorage, interval time.Duration) *Watcher {
+	return &Watcher{
+		client:    client,
+		favorites: favorites,
+		seen:      seen,
+		interval:  interval,
+	}
+}
+
+// OnRelease enregistre fn, appelée pour chaque nouvelle sortie détectée.
+// Les observateurs doivent être enregistrés avant le démarrage.
+func (w *Watcher) OnRelease(fn func(Release)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.observers = append(w.observers, fn)
+}
+
+// Start lance la vérification périodique en arrière-plan (sans effet si l'intervalle est nul)
+func (w *Watcher) Start() {
+	if w.interval <= 0 {
+		return
+	}
+
+	w.stop = make(chan struct{})
+	w.done = make(chan struct{})
+
+	go func() {
+		defer close(w.done)
+
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := w.CheckAll(); err != nil && err != ErrNotAuthenticated {
+					log.Printf("Erreur lors de la recherche de nouvelles sorties : %v", err)
+				}
+			case <-w.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop arrête la vérification périodique
+func (w *Watcher) Stop() {
+	if w.stop == nil {
+		return
+	}
+	close(w.stop)
+	<-w.done
+}
+
+// CheckAll vérifie la discographie de tous les artistes favoris et renvoie
+// les nouvelles sorties, après les avoir signalées aux observateurs
+func (w *Watcher) CheckAll() ([]Release, error) {
+	if !w.client.Auth.IsTokenValid() {
+		return nil, ErrNotAuthenticated
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// Les artistes qui ne sont plus en favoris sont oubliés
+	previous := w.seen.All()
+	seen := make(map[string]models.SeenReleases)
+	var found []Release
+	for _, item := range w.favorites.GetByType(models.FavoriteTypeArtist) {
+		state, known := previous[item.ID]
+		releases, next, err := w.check(item, state, known)
+		if err != nil {
+			log.Printf("Impossible de vérifier les sorties de %s (%s) : %v", item.Name, item.ID, err)
+			if known {
+				seen[item.ID] = state
+			}
+			continue
+		}
+		seen[item.ID] = next
+		found = append(found, releases...)
+	}
+	if err := w.seen.Replace(seen); err != nil {
+		return nil, fmt.Errorf("enregistrement des sorties vues : %w", err)
+	}
+
+	for _, release := range found {
+		log.Printf("Nouvelle sortie : %s - %s (%s)", release.ArtistName, release.AlbumName, release.ReleaseDate)
+		for _, fn := range w.observers {
+			fn(release)
+		}
+	}
+	return found, nil
+}
+
+// check compare la discographie d'un artiste à celle déjà vue (previous,
+// si known) et renvoie les nouvelles sorties et la discographie à retenir
+func (w *Watcher) check(artist models.FavoriteItem, previous models.SeenReleases, known bool) ([]Release, models.SeenReleases, error) {
+	results, err := w.client.GetArtistAlbums(artist.ID, albumsPerArtist, 0)
+	if err != nil {
+		return nil, models.SeenReleases{}, err
+	}
+
+	seen := make(map[string]bool, len(previous.Albums))
+	for _, id := range previous.Albums {
+		seen[id] = true
+	}
+
+	next := models.SeenReleases{Latest: previous.Latest, Albums: make([]string, 0, len(results.Items)), CheckedAt: time.Now()}
+	var releases []Release
+	for _, album := range results.Items {
+		next.Albums = append(next.Albums, album.ID)
+		if album.ReleaseDate > next.Latest {
+			next.Latest = album.ReleaseDate
+		}
+		// Un album ancien qui apparaît (réédition, page de résultats
+		// différente) n'est pas une nouvelle sortie
+		if !known || seen[album.ID] || album.ReleaseDate < previous.Latest {
+			continue
+		}
+		releases = append(releases, newRelease(artist, album))
+	}
+	return releases, next, nil
+}
+
+// newRelease construit la sortie d'un album
+func newRelease(artist models.FavoriteItem, album spotify.Album) Release {
+	release := Release{
+		ArtistID:    artist.ID,
+		ArtistName:  artist.Name,
+		AlbumID:     album.ID,
+		AlbumName:   album.Name,
+		AlbumType:   album.AlbumType,
+		ReleaseDate: album.ReleaseDate,
+		URL:         album.ExternalURLs["spotify"],
+	}
+	if len(album.Images) > 0 {
+		release.ImageURL = album.Images[0].URL
+	}
+	return release
+}
@@ -0,0 +1,264 @@
+package smartlist
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yourusername/melody-explorer/internal/enrich"
+	"github.com/yourusername/melody-explorer/internal/models"
+	"github.com/yourusername/melody-explorer/internal/spotify"
+	"github.com/yourusername/melody-explorer/internal/storage"
+)
+
+// ErrNotAuthenticated est renvoyée lorsqu'une règle sur le catalogue est
+// évaluée sans session Spotify active
+var ErrNotAuthenticated = errors.New("aucune session Spotify active pour interroger le catalogue")
+
+// maxArtistQueries est le nombre maximal d'artistes favoris interrogés par évaluation
+const maxArtistQueries = 10
+
+// maxSearchLimit est le nombre maximal de résultats par recherche Spotify
+const maxSearchLimit = 50
+
+// maxSearchPages est le nombre maximal de pages parcourues par recherche
+const maxSearchPages = 2
+
+// Evaluator calcule les éléments des listes automatiques à partir des favoris
+// et de la recherche dans le catalogue Spotify
+type Evaluator struct {
+	client    *spotify.Client
+	favorites storage.FavoritesStore
+}
+
+// NewEvaluator crée un évaluateur de règles
+func NewEvaluator(client *spotify.Client, favorites storage.FavoritesStore) *Evaluator {
+	return &Evaluator{
+		client:    client,
+		favorites: favorites,
+	}
+}
+
+// Evaluate renvoie les éléments correspondant à la règle, évaluée à la date donnée
+func (e *Evaluator) Evaluate(rule models.SmartRule, now time.Time) ([]models.ListEntry, error) {
+	rule.Normalize()
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+
+	if rule.Source == models.RuleSourceFavorites {
+		return e.evaluateFavorites(rule, now), nil
+	}
+
+	if !e.client.Auth.IsTokenValid() {
+		return nil, ErrNotAuthenticated
+	}
+	return e.evaluateCatalog(rule, now)
+}
+
+// evaluateFavorites sélectionne les favoris correspondant à la règle, les plus
+// récemment ajoutés d'abord
+func (e *Evaluator) evaluateFavorites(rule models.SmartRule, now time.Time) []models.ListEntry {
+	items := e.favorites.GetByType(rule.Type)
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].AddedAt.After(items[j].AddedAt)
+	})
+
+	entries := []models.ListEntry{}
+	for _, item := range items {
+		if len(entries) >= rule.Limit {
+			break
+		}
+		if matchesFavorite(rule, item, now) {
+			entries = append(entries, models.ListEntry{
+				ID:       item.ID,
+				Type:     item.Type,
+				Name:     item.Name,
+				ImageURL: item.ImageURL,
+			})
+		}
+	}
+	return entries
+}
+
+// matchesFavorite indique si un favori satisfait tous les critères de la règle
+func matchesFavorite(rule models.SmartRule, item models.FavoriteItem, now time.Time) bool {
+	if item.IsUnavailable() {
+		return false
+	}
+	for _, tag := range rule.Tags {
+		if !item.HasTag(tag) {
+			return false
+		}
+	}
+	if rule.MinRating > 0 && item.Rating < rule.MinRating {
+		return false
+	}
+	if rule.AddedWithinDays > 0 && item.AddedAt.Before(now.AddDate(0, 0, -rule.AddedWithinDays)) {
+		return false
+	}
+	if rule.Genre != "" && !containsFold(item.Genres(), rule.Genre) {
+		return false
+	}
+	if start, end, ok := rule.YearRange(now); ok {
+		year, err := strconv.Atoi(item.ReleaseYear())
+		if err != nil || year < start || year > end {
+			return false
+		}
+	}
+	if rule.PopularityBelow > 0 || rule.PopularityAtLeast > 0 {
+		if item.Metadata == nil {
+			return false
+		}
+		return matchesPopularity(rule, item.Metadata.Popularity)
+	}
+	return true
+}
+
+// matchesPopularity vérifie les critères de popularité de la règle
+func matchesPopularity(rule models.SmartRule, popularity int) bool {
+	if rule.PopularityBelow > 0 && popularity >= rule.PopularityBelow {
+		return false
+	}
+	if rule.PopularityAtLeast > 0 && popularity < rule.PopularityAtLeast {
+		return false
+	}
+	return true
+}
+
+// evaluateCatalog recherche dans le catalogue Spotify avec la syntaxe
+// year:/genre:/artist:, pour chaque artiste favori si la règle le demande
+func (e *Evaluator) evaluateCatalog(rule models.SmartRule, now time.Time) ([]models.ListEntry, error) {
+	var filters []string
+	if year := rule.YearQuery(now); year != "" {
+		filters = append(filters, year)
+	}
+	if rule.Genre != "" {
+		filters = append(filters, "genre:"+spotify.QuoteFilter(rule.Genre))
+	}
+
+	if !rule.FromFavoriteArtists {
+		return e.search(rule, strings.Join(filters, " "), "", rule.Limit)
+	}
+
+	artists := e.favorites.GetByType(models.FavoriteTypeArtist)
+	sort.SliceStable(artists, func(i, j int) bool {
+		return artists[i].AddedAt.After(artists[j].AddedAt)
+	})
+	if len(artists) > maxArtistQueries {
+		artists = artists[:maxArtistQueries]
+	}
+	if len(artists) == 0 {
+		return []models.ListEntry{}, nil
+	}
+
+	perArtist := (rule.Limit + len(artists) - 1) / len(artists)
+	entries := []models.ListEntry{}
+	seen := make(map[models.FavoriteKey]bool)
+	for _, artist := range artists {
+		query := strings.Join(append([]string{"artist:" + spotify.QuoteFilter(artist.Name)}, filters...), " ")
+		found, err := e.search(rule, query, artist.ID, perArtist)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range found {
+			if !seen[entry.Key()] && len(entries) < rule.Limit {
+				seen[entry.Key()] = true
+				entries = append(entries, entry)
+			}
+		}
+	}
+	return entries, nil
+}
+
+// search exécute une recherche paginée et convertit les résultats en éléments.
+// Si artistID est renseigné, seuls les résultats de cet artiste sont conservés.
+func (e *Evaluator) search(rule models.SmartRule, query, artistID string, limit int) ([]models.ListEntry, error) {
+	entries := []models.ListEntry{}
+
+	for page := 0; page < maxSearchPages && len(entries) < limit; page++ {
+		offset := page * maxSearchLimit
+		results, err := e.client.Search(query, []string{string(rule.Type)}, maxSearchLimit, offset)
+		if err != nil {
+			return nil, fmt.Errorf("recherche %q : %w", query, err)
+		}
+
+		found, total := searchEntries(rule, results, artistID)
+		for _, entry := range found {
+			if len(entries) < limit {
+				entries = append(entries, entry)
+			}
+		}
+		if offset+maxSearchLimit >= total {
+			break
+		}
+	}
+
+	return entries, nil
+}
+
+// searchEntries convertit une page de résultats en éléments filtrés et
+// renvoie le nombre total de résultats. La recherche Spotify ne renvoie pas
+// la popularité des albums : SmartRule.Validate refuse ce critère pour eux.
+func searchEntries(rule models.SmartRule, results *spotify.SearchResults, artistID string) ([]models.ListEntry, int) {
+	var entries []models.ListEntry
+	add := func(id string, snapshot enrich.Snapshot) {
+		entries = append(entries, models.ListEntry{
+			ID:       id,
+			Type:     rule.Type,
+			Name:     snapshot.Name,
+			ImageURL: snapshot.ImageURL,
+		})
+	}
+
+	switch {
+	case rule.Type == models.FavoriteTypeArtist && results.Artists != nil:
+		for i := range results.Artists.Items {
+			artist := &results.Artists.Items[i]
+			if matchesPopularity(rule, artist.Popularity) {
+				add(artist.ID, enrich.ArtistSnapshot(artist))
+			}
+		}
+		return entries, results.Artists.Total
+	case rule.Type == models.FavoriteTypeAlbum && results.Albums != nil:
+		for i := range results.Albums.Items {
+			album := &results.Albums.Items[i]
+			if artistID == "" || hasArtist(album.Artists, artistID) {
+				add(album.ID, enrich.AlbumSnapshot(album))
+			}
+		}
+		return entries, results.Albums.Total
+	case rule.Type == models.FavoriteTypeTrack && results.Tracks != nil:
+		for i := range results.Tracks.Items {
+			track := &results.Tracks.Items[i]
+			if (artistID == "" || hasArtist(track.Artists, artistID)) && matchesPopularity(rule, track.Popularity) {
+				add(track.ID, enrich.TrackSnapshot(track))
+			}
+		}
+		return entries, results.Tracks.Total
+	}
+	return entries, 0
+}
+
+// hasArtist indique si l'artiste fait partie des artistes crédités
+func hasArtist(artists []spotify.Artist, id string) bool {
+	for _, artist := range artists {
+		if artist.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// containsFold indique si la liste contient la valeur, sans tenir compte de la casse
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
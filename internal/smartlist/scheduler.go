@@ -0,0 +1,132 @@
+package smartlist
+
+import (
+	"log"
+	"reflect"
+	"time"
+
+	"github.com/yourusername/melody-explorer/internal/models"
+	"github.com/yourusername/melody-explorer/internal/storage"
+)
+
+// Scheduler réévalue périodiquement toutes les listes automatiques et
+// enregistre leurs éléments dans le stockage des listes
+type Scheduler struct {
+	evaluator *Evaluator
+	lists     *storage.ListsStorage
+	interval  time.Duration
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewScheduler crée un planificateur exécuté toutes les interval
+func NewScheduler(evaluator *Evaluator, lists *storage.ListsStorage, interval time.Duration) *Scheduler {
+	return &Scheduler{
+		evaluator: evaluator,
+		lists:     lists,
+		interval:  interval,
+	}
+}
+
+// Start lance l'évaluation périodique en arrière-plan (sans effet si l'intervalle est nul)
+func (s *Scheduler) Start() {
+	if s.interval <= 0 {
+		return
+	}
+
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.RefreshAll()
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop arrête l'évaluation périodique
+func (s *Scheduler) Stop() {
+	if s.stop == nil {
+		return
+	}
+	close(s.stop)
+	<-s.done
+}
+
+// RefreshAll réévalue toutes les listes automatiques. Les règles sur le
+// catalogue sont ignorées tant qu'aucune session Spotify n'est active.
+func (s *Scheduler) RefreshAll() {
+	refreshed := 0
+	for _, list := range s.lists.All() {
+		if !list.IsSmart() {
+			continue
+		}
+		if _, err := s.Refresh(list.ID); err != nil {
+			if err != ErrNotAuthenticated {
+				log.Printf("Erreur lors de l'évaluation de la liste automatique %s : %v", list.ID, err)
+			}
+			continue
+		}
+		refreshed++
+	}
+	if refreshed > 0 {
+		log.Printf("Listes automatiques réévaluées : %d", refreshed)
+	}
+}
+
+// Refresh réévalue une liste automatique et renvoie la liste mise à jour.
+// L'évaluation a lieu hors du verrou du stockage ; son résultat est ignoré si
+// la règle a été modifiée entre-temps. Une erreur d'évaluation est enregistrée
+// sur la liste, dont les éléments précédents sont conservés.
+func (s *Scheduler) Refresh(id string) (models.List, error) {
+	list, ok := s.lists.Get(id)
+	if !ok {
+		return models.List{}, storage.ErrListNotFound
+	}
+	if !list.IsSmart() {
+		return list, nil
+	}
+
+	rule := *list.Rule
+	now := time.Now()
+	entries, evalErr := s.evaluator.Evaluate(rule, now)
+	if evalErr == ErrNotAuthenticated {
+		return list, evalErr
+	}
+
+	var updated models.List
+	err := s.lists.Update(func(tx *storage.ListsTx) error {
+		current, ok := tx.Get(id)
+		if !ok {
+			return storage.ErrListNotFound
+		}
+		if current.Rule == nil || !reflect.DeepEqual(*current.Rule, rule) {
+			updated = current.Clone()
+			return nil
+		}
+
+		if evalErr != nil {
+			current.EvaluationError = evalErr.Error()
+		} else {
+			current.SetEntries(entries, now)
+		}
+		updated = current.Clone()
+		return nil
+	})
+	if err != nil {
+		return models.List{}, err
+	}
+
+	return updated, evalErr
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"golang.org/x/oauth2"
@@ -123,14 +124,36 @@ func (a *Auth) EnsureValidToken() error {
 // AuthMiddleware est un middleware qui garantit l'existence d'un jeton valide
 func (a *Auth) AuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Ignorer la vérification d'authentification pour les points de terminaison liés à la connexion
-		if r.URL.Path == "/login" || r.URL.Path == "/callback" || r.URL.Path == "/" {
+		// Ignorer la vérification d'authentification pour les points de terminaison liés
+		// à la connexion et pour la documentation de l'API
+		if r.URL.Path == "/login" || r.URL.Path == "/callback" || r.URL.Path == "/" ||
+			r.URL.Path == "/api/openapi.json" || r.URL.Path == "/api/docs" {
 			next.ServeHTTP(w, r)
 			return
 		}
 
-		// Vérifier si nous avons un jeton valide
+		// Les requêtes portant un jeton d'accès personnel ont déjà été
+		// authentifiées par le middleware des jetons
+		if strings.HasPrefix(strings.ToLower(r.Header.Get("Authorization")), "bearer ") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Vérifier si nous avons un jeton valide. L'API JSON versionnée et le
+		// point d'entrée JSON-RPC répondent eux-mêmes par une erreur plutôt que
+		// par une redirection, et les clients qui demandent une page en JSON
+		// reçoivent une erreur 401.
 		if err := a.EnsureValidToken(); err != nil {
+			if strings.HasPrefix(r.URL.Path, "/api/v1/") || r.URL.Path == "/rpc" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if r.URL.Query().Get("format") == "json" || strings.Contains(r.Header.Get("Accept"), "application/json") {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"success":false,"error":"Non autorisé"}` + "\n"))
+				return
+			}
 			http.Redirect(w, r, "/login", http.StatusSeeOther)
 			return
 		}
@@ -2,28 +2,34 @@ package spotify
 
 import (
 	"encoding/json"
-	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 )
 
 const (
 	baseURL = "https://api.spotify.com/v1"
 )
 
+// maxRateLimitRetries est le nombre de nouvelles tentatives après une réponse 429
+const maxRateLimitRetries = 3
+
 // Client est un client d'API Spotify
 type Client struct {
-	Auth *Auth
+	Auth    *Auth
+	limiter *rateLimiter
 }
 
-// NewClient crée un nouveau client d'API Spotify
-func NewClient(auth *Auth) *Client {
+// NewClient crée un nouveau client d'API Spotify limité à rateLimit requêtes
+// par seconde (0 pour ne pas limiter)
+func NewClient(auth *Auth, rateLimit float64) *Client {
 	return &Client{
-		Auth: auth,
+		Auth:    auth,
+		limiter: newRateLimiter(rateLimit),
 	}
 }
 
@@ -60,12 +66,28 @@ func (c *Client) makeRequest(method, endpoint string, params url.Values) ([]byte
 	}
 	log.Printf("Utilisation du jeton : %s", tokenPreview)
 
-	// Effectuer la requête
+	// Effectuer la requête en respectant la limite de débit
 	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		log.Printf("Erreur lors de l'exécution de la requête : %v", err)
-		return nil, err
+	var resp *http.Response
+	for attempt := 0; ; attempt++ {
+		c.limiter.wait()
+		resp, err = client.Do(req)
+		if err != nil {
+			log.Printf("Erreur lors de l'exécution de la requête : %v", err)
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusTooManyRequests || attempt >= maxRateLimitRetries {
+			break
+		}
+
+		// Spotify demande de ralentir : suspendre toutes les requêtes du client
+		delay := retryAfter(resp.Header.Get("Retry-After"))
+		log.Printf("Limite de débit Spotify atteinte, nouvelle tentative dans %s", delay)
+		resp.Body.Close()
+		c.limiter.pause(delay)
+		if c.limiter == nil {
+			time.Sleep(delay)
+		}
 	}
 	defer resp.Body.Close()
 
@@ -75,9 +97,8 @@ func (c *Client) makeRequest(method, endpoint string, params url.Values) ([]byte
 	// Vérifier les erreurs
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		errorMsg := fmt.Sprintf("erreur API Spotify (%d) : %s", resp.StatusCode, string(body))
 		log.Printf("Réponse d'erreur API : %s", string(body))
-		return nil, fmt.Errorf(errorMsg)
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
 	// Lire le corps de la réponse
@@ -148,6 +169,9 @@ type Album struct {
 	Artists      []Artist          `json:"artists"`
 	TotalTracks  int               `json:"total_tracks"`
 	AlbumType    string            `json:"album_type"`
+	Label        string            `json:"label"`
+	Genres       []string          `json:"genres"`
+	Popularity   int               `json:"popularity"`
 	ExternalURLs map[string]string `json:"external_urls"`
 }
 
@@ -158,10 +182,40 @@ type Track struct {
 	Album        Album             `json:"album"`
 	Artists      []Artist          `json:"artists"`
 	Duration     int               `json:"duration_ms"`
+	Explicit     bool              `json:"explicit"`
 	Popularity   int               `json:"popularity"`
 	ExternalURLs map[string]string `json:"external_urls"`
 }
 
+// Playlist représente une playlist Spotify
+type Playlist struct {
+	ID           string            `json:"id"`
+	Name         string            `json:"name"`
+	Description  string            `json:"description"`
+	Images       []Image           `json:"images"`
+	Owner        PlaylistOwner     `json:"owner"`
+	Tracks       PlaylistTracks    `json:"tracks"`
+	Followers    Followers         `json:"followers"`
+	ExternalURLs map[string]string `json:"external_urls"`
+}
+
+// PlaylistOwner représente le propriétaire d'une playlist
+type PlaylistOwner struct {
+	ID          string `json:"id"`
+	DisplayName string `json:"display_name"`
+}
+
+// PlaylistTracks contient le nombre de pistes d'une playlist
+type PlaylistTracks struct {
+	Total int `json:"total"`
+}
+
+// User représente l'utilisateur Spotify connecté
+type User struct {
+	ID          string `json:"id"`
+	DisplayName string `json:"display_name"`
+}
+
 // Image représente une image Spotify
 type Image struct {
 	URL    string `json:"url"`
@@ -174,6 +228,17 @@ type Followers struct {
 	Total int `json:"total"`
 }
 
+// QuoteFilter prépare la valeur d'un filtre de recherche (artist:, genre:…) :
+// les guillemets sont retirés et la valeur est entourée de guillemets si
+// elle contient des espaces
+func QuoteFilter(value string) string {
+	value = strings.ReplaceAll(value, `"`, "")
+	if strings.ContainsAny(value, " \t") {
+		return `"` + value + `"`
+	}
+	return value
+}
+
 // Search recherche des artistes, des albums et des pistes
 func (c *Client) Search(query string, types []string, limit, offset int) (*SearchResults, error) {
 	params := url.Values{}
@@ -278,6 +343,112 @@ func (c *Client) GetTrack(id string) (*Track, error) {
 	return &track, nil
 }
 
+// GetPlaylist récupère une playlist par ID, sans le détail de ses pistes
+func (c *Client) GetPlaylist(id string) (*Playlist, error) {
+	params := url.Values{}
+	params.Add("fields", "id,name,description,images,owner(id,display_name),tracks(total),followers(total),external_urls")
+
+	body, err := c.makeRequest("GET", "/playlists/"+id, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var playlist Playlist
+	if err := json.Unmarshal(body, &playlist); err != nil {
+		return nil, err
+	}
+
+	return &playlist, nil
+}
+
+// GetCurrentUser récupère le profil de l'utilisateur connecté
+func (c *Client) GetCurrentUser() (*User, error) {
+	body, err := c.makeRequest("GET", "/me", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var user User
+	if err := json.Unmarshal(body, &user); err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// Tailles maximales des requêtes groupées acceptées par Spotify
+const (
+	MaxArtistsPerRequest = 50
+	MaxAlbumsPerRequest  = 20
+	MaxTracksPerRequest  = 50
+)
+
+// MaxSearchLimit est le nombre maximal de résultats par type d'une recherche
+const MaxSearchLimit = 50
+
+// GetArtists récupère plusieurs artistes en une requête. Le résultat suit
+// l'ordre des IDs ; un élément nil correspond à un ID inconnu de Spotify.
+func (c *Client) GetArtists(ids []string) ([]*Artist, error) {
+	params := url.Values{}
+	params.Add("ids", strings.Join(ids, ","))
+
+	body, err := c.makeRequest("GET", "/artists", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Artists []*Artist `json:"artists"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+
+	return response.Artists, nil
+}
+
+// GetAlbums récupère plusieurs albums en une requête. Le résultat suit
+// l'ordre des IDs ; un élément nil correspond à un ID inconnu de Spotify.
+func (c *Client) GetAlbums(ids []string) ([]*Album, error) {
+	params := url.Values{}
+	params.Add("ids", strings.Join(ids, ","))
+
+	body, err := c.makeRequest("GET", "/albums", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Albums []*Album `json:"albums"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+
+	return response.Albums, nil
+}
+
+// GetTracks récupère plusieurs pistes en une requête. Le résultat suit
+// l'ordre des IDs ; un élément nil correspond à un ID inconnu de Spotify.
+func (c *Client) GetTracks(ids []string) ([]*Track, error) {
+	params := url.Values{}
+	params.Add("ids", strings.Join(ids, ","))
+
+	body, err := c.makeRequest("GET", "/tracks", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Tracks []*Track `json:"tracks"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+
+	return response.Tracks, nil
+}
+
 // GetGenres récupère les catégories musicales de Spotify
 // Comme le point de terminaison genre-seeds peut être obsolète, nous utilisons les catégories à la place
 func (c *Client) GetGenres() ([]string, error) {
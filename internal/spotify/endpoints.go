@@ -15,6 +15,8 @@ const (
 	AlbumEndpoint           = "/albums/%s"
 	AlbumTracksEndpoint     = "/albums/%s/tracks"
 	TrackEndpoint           = "/tracks/%s"
+	PlaylistEndpoint        = "/playlists/%s"
+	CurrentUserEndpoint     = "/me"
 	GenresEndpoint          = "/recommendations/available-genre-seeds"
 	RecommendationsEndpoint = "/recommendations"
 )
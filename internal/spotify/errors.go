@@ -0,0 +1,24 @@
+package spotify
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// APIError représente une réponse d'erreur de l'API Spotify
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+// Error implémente l'interface error
+func (e *APIError) Error() string {
+	return fmt.Sprintf("erreur API Spotify (%d) : %s", e.StatusCode, e.Body)
+}
+
+// IsNotFound indique si l'erreur correspond à une ressource introuvable sur Spotify
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,64 @@
+package spotify
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+// rateLimiter espace les requêtes vers l'API Spotify pour ne pas dépasser
+// un nombre de requêtes par seconde, partagé par tous les appelants du client
+type rateLimiter struct {
+	interval time.Duration
+	next     time.Time
+	mu       sync.Mutex
+}
+
+// newRateLimiter crée un limiteur ; renvoie nil (pas de limite) si perSecond <= 0
+func newRateLimiter(perSecond float64) *rateLimiter {
+	if perSecond <= 0 {
+		return nil
+	}
+	return &rateLimiter{interval: time.Duration(float64(time.Second) / perSecond)}
+}
+
+// wait bloque jusqu'à ce que la prochaine requête soit autorisée
+func (l *rateLimiter) wait() {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	delay := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	time.Sleep(delay)
+}
+
+// pause retarde toutes les requêtes suivantes, après une réponse 429 de Spotify
+func (l *rateLimiter) pause(d time.Duration) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if until := time.Now().Add(d); until.After(l.next) {
+		l.next = until
+	}
+}
+
+// retryAfter lit l'en-tête Retry-After (en secondes) d'une réponse 429
+func retryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds <= 0 {
+		return time.Second
+	}
+	return time.Duration(seconds) * time.Second
+}
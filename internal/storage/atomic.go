@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// writeFileAtomic écrit les données dans un fichier temporaire du même répertoire,
+// le synchronise sur le disque puis le renomme à la place du fichier cible.
+// Un arrêt brutal laisse donc soit l'ancien contenu, soit le nouveau, jamais un fichier tronqué.
+func writeFileAtomic(filename string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(filename)
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(filename)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+
+	// Nettoyer le fichier temporaire en cas d'échec
+	success := false
+	defer func() {
+		if !success {
+			tmp.Close()
+			os.Remove(tmpName)
+		}
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpName, perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpName, filename); err != nil {
+		return err
+	}
+	success = true
+
+	// Synchroniser le répertoire pour rendre le renommage durable
+	syncDir(dir)
+	return nil
+}
+
+// syncDir synchronise un répertoire sur le disque, en ignorant les systèmes qui ne le permettent pas
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	defer d.Close()
+	d.Sync()
+}
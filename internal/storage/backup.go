@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// backupTimeFormat produit des noms de sauvegarde triables dans l'ordre chronologique
+const backupTimeFormat = "20060102T150405.000000000Z"
+
+// backupManager gère les sauvegardes tournantes horodatées d'un fichier
+type backupManager struct {
+	dir    string
+	prefix string
+	keep   int
+}
+
+// newBackupManager crée un gestionnaire de sauvegardes pour le fichier donné.
+// Les sauvegardes sont rangées dans un sous-répertoire "backups" à côté du fichier.
+func newBackupManager(filename string, keep int) *backupManager {
+	base := filepath.Base(filename)
+	return &backupManager{
+		dir:    filepath.Join(filepath.Dir(filename), "backups"),
+		prefix: strings.TrimSuffix(base, filepath.Ext(base)) + "-",
+		keep:   keep,
+	}
+}
+
+// enabled indique si les sauvegardes sont activées
+func (b *backupManager) enabled() bool {
+	return b.keep > 0
+}
+
+// create enregistre une nouvelle sauvegarde puis supprime les plus anciennes
+func (b *backupManager) create(data []byte) error {
+	if !b.enabled() {
+		return nil
+	}
+
+	if err := os.MkdirAll(b.dir, 0755); err != nil {
+		return err
+	}
+
+	name := filepath.Join(b.dir, b.prefix+time.Now().UTC().Format(backupTimeFormat)+".json")
+	if err := writeFileAtomic(name, data, 0644); err != nil {
+		return fmt.Errorf("création de la sauvegarde %s: %w", name, err)
+	}
+
+	return b.prune()
+}
+
+// list renvoie les chemins des sauvegardes existantes, de la plus récente à la plus ancienne
+func (b *backupManager) list() ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(b.dir, b.prefix+"*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Sort(sort.Reverse(sort.StringSlice(matches)))
+	return matches, nil
+}
+
+// prune supprime les sauvegardes au-delà du nombre à conserver
+func (b *backupManager) prune() error {
+	backups, err := b.list()
+	if err != nil {
+		return err
+	}
+
+	for i := b.keep; i < len(backups); i++ {
+		if err := os.Remove(backups[i]); err != nil && !os.IsNotExist(err) {
+			log.Printf("Impossible de supprimer l'ancienne sauvegarde %s : %v", backups[i], err)
+		}
+	}
+	return nil
+}
+
+// recover parcourt les sauvegardes de la plus récente à la plus ancienne et
+// renvoie le contenu de la première que la fonction de validation accepte
+func (b *backupManager) recover(validate func([]byte) error) ([]byte, string, error) {
+	backups, err := b.list()
+	if err != nil {
+		return nil, "", err
+	}
+
+	for _, backup := range backups {
+		data, err := os.ReadFile(backup)
+		if err != nil {
+			log.Printf("Sauvegarde illisible %s : %v", backup, err)
+			continue
+		}
+		if err := validate(data); err != nil {
+			log.Printf("Sauvegarde invalide %s : %v", backup, err)
+			continue
+		}
+		return data, backup, nil
+	}
+
+	return nil, "", fmt.Errorf("aucune sauvegarde valide dans %s", b.dir)
+}
+
+// keepCorrupt met à l'écart un fichier corrompu sous le nom
+// <fichier>.corrupt-<horodatage>, pour qu'aucune écriture ne l'écrase
+func keepCorrupt(filename string) error {
+	corrupt := filename + ".corrupt-" + time.Now().UTC().Format(backupTimeFormat)
+	if err := os.Rename(filename, corrupt); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	log.Printf("Fichier corrompu conservé sous %s", corrupt)
+	return nil
+}
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
 	"os"
 	"path/filepath"
@@ -10,15 +11,36 @@ import (
 	"github.com/yourusername/melody-explorer/internal/models"
 )
 
-// FavoritesStorage gère le stockage des favoris
+var _ FavoritesStore = (*FavoritesStorage)(nil)
+
+// FavoritesStorage stocke les favoris dans un fichier JSON réécrit à chaque modification
+//
+// Les écritures sont protégées par un verrou consultatif sur le répertoire de
+// données : si un autre processus a modifié le fichier depuis la dernière
+// lecture, ses modifications sont fusionnées avant d'écrire au lieu d'être écrasées.
 type FavoritesStorage struct {
 	filename  string
 	favorites *models.Favorites
-	mu        sync.Mutex
+	backups   *backupManager
+	lock      *fileLock
+	watcher   *poller
+
+	// base est le contenu du fichier lors de la dernière synchronisation et
+	// state son état sur le disque, pour détecter les modifications externes
+	base  *models.Favorites
+	state fileState
+
+	// blocked interdit toute écriture lorsque le fichier corrompu n'a pas pu
+	// être mis à l'écart : il serait écrasé par la sauvegarde suivante
+	blocked error
+
+	mu sync.RWMutex
 }
 
-// NewFavoritesStorage crée un nouveau FavoritesStorage
-func NewFavoritesStorage(dataDir string) (*FavoritesStorage, error) {
+// NewFavoritesStorage crée un nouveau FavoritesStorage dans dataDir. Le fichier
+// est surveillé toutes les opts.WatchInterval pour recharger les modifications
+// faites par d'autres processus.
+func NewFavoritesStorage(dataDir string, opts Options) (*FavoritesStorage, error) {
 	// S'assurer que le répertoire de données existe
 	if err := os.MkdirAll(dataDir, 0755); err != nil {
 		return nil, err
@@ -31,7 +53,7 @@ func NewFavoritesStorage(dataDir string) (*FavoritesStorage, error) {
 	if _, err := os.Stat(filename); os.IsNotExist(err) {
 		log.Printf("Création d'un nouveau fichier de favoris à %s", filename)
 		emptyJSON := []byte("[]")
-		if err := os.WriteFile(filename, emptyJSON, 0644); err != nil {
+		if err := writeFileAtomic(filename, emptyJSON, 0644); err != nil {
 			return nil, err
 		}
 	}
@@ -39,27 +61,29 @@ func NewFavoritesStorage(dataDir string) (*FavoritesStorage, error) {
 	storage := &FavoritesStorage{
 		filename:  filename,
 		favorites: favorites,
+		backups:   newBackupManager(filename, opts.Backups),
+		lock:      newFileLock(filepath.Join(dataDir, ".favorites.lock")),
+		base:      models.NewFavorites(),
 	}
 
 	// Charger les favoris existants
 	if err := storage.Load(); err != nil {
 		log.Printf("Erreur lors du chargement des favoris, démarrage avec une liste vide : %v", err)
 	} else {
-		log.Printf("Chargement de %d favoris depuis %s", len(storage.favorites.Items), filename)
+		log.Printf("Chargement de %d favoris depuis %s", storage.favorites.Len(), filename)
 	}
 
+	storage.watcher = startPoller(opts.WatchInterval, storage.reloadIfChanged)
+
 	return storage, nil
 }
 
 // Add ajoute un élément favori
 func (s *FavoritesStorage) Add(item models.FavoriteItem) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	s.favorites.Add(item)
-
-	// Sauvegarder immédiatement
-	err := s.save()
+	err := s.Update(func(tx *Tx) error {
+		tx.Add(item)
+		return nil
+	})
 	if err != nil {
 		log.Printf("Erreur lors de la sauvegarde des favoris : %v", err)
 	} else {
@@ -71,13 +95,10 @@ func (s *FavoritesStorage) Add(item models.FavoriteItem) error {
 
 // Remove supprime un élément favori
 func (s *FavoritesStorage) Remove(id string, itemType models.FavoriteType) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	s.favorites.Remove(id, itemType)
-
-	// Sauvegarder immédiatement
-	err := s.save()
+	err := s.Update(func(tx *Tx) error {
+		tx.Remove(id, itemType)
+		return nil
+	})
 	if err != nil {
 		log.Printf("Erreur lors de la sauvegarde des favoris après suppression : %v", err)
 	} else {
@@ -87,65 +108,241 @@ func (s *FavoritesStorage) Remove(id string, itemType models.FavoriteType) error
 	return err
 }
 
-// GetAll renvoie tous les éléments favoris
-func (s *FavoritesStorage) GetAll() []models.FavoriteItem {
+// Update exécute fn dans une transaction et réécrit le fichier une seule fois
+// si elle a modifié les favoris. En cas d'échec, l'état en mémoire est conservé.
+func (s *FavoritesStorage) Update(fn func(tx *Tx) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.blocked != nil {
+		return s.blocked
+	}
+	if err := s.lock.Lock(); err != nil {
+		return fmt.Errorf("verrouillage des favoris : %w", err)
+	}
+	defer s.lock.Unlock()
+
+	// Intégrer les modifications d'un autre processus avant d'écrire
+	s.syncFromDisk(true)
+
+	tx := newTx(s.favorites)
+	if err := fn(tx); err != nil {
+		tx.rollback()
+		return err
+	}
+	if len(tx.ops) == 0 {
+		return nil
+	}
+
+	if err := s.save(); err != nil {
+		tx.rollback()
+		return err
+	}
+
+	return nil
+}
+
+// Close arrête la surveillance du fichier
+func (s *FavoritesStorage) Close() error {
+	s.watcher.Stop()
+	return nil
+}
+
+// reloadIfChanged recharge le fichier s'il a été modifié par un autre processus
+func (s *FavoritesStorage) reloadIfChanged() {
+	s.mu.RLock()
+	changed := s.state.statChanged(s.filename)
+	s.mu.RUnlock()
+	if !changed {
+		return
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if err := s.lock.Lock(); err != nil {
+		log.Printf("Verrouillage des favoris impossible : %v", err)
+		return
+	}
+	defer s.lock.Unlock()
+
+	s.syncFromDisk(false)
+}
+
+// syncFromDisk fusionne le contenu du fichier s'il a changé depuis la dernière
+// synchronisation. Sans force, seuls la date et la taille sont d'abord comparées.
+// Doit être appelée avec s.mu et le verrou de fichier détenus.
+func (s *FavoritesStorage) syncFromDisk(force bool) {
+	if !force && !s.state.statChanged(s.filename) {
+		return
+	}
+
+	data, state, err := readFileState(s.filename)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Lecture de %s impossible : %v", s.filename, err)
+		}
+		return
+	}
+	if state.sum == s.state.sum {
+		s.state = state
+		return
+	}
+
+	items, err := parseFavorites(data)
+	if err != nil {
+		log.Printf("Modification externe invalide de %s ignorée : %v", s.filename, err)
+		return
+	}
+
+	theirs := models.NewFavoritesFrom(items)
+	s.favorites = mergeFavorites(s.base, s.favorites, theirs)
+	s.base = theirs
+	s.state = state
+	log.Printf("Favoris modifiés par un autre processus : %d éléments après fusion", s.favorites.Len())
+}
+
+// GetAll renvoie tous les éléments favoris
+func (s *FavoritesStorage) GetAll() []models.FavoriteItem {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	return s.favorites.Get()
 }
 
 // GetByType renvoie les éléments favoris d'un type spécifique
 func (s *FavoritesStorage) GetByType(itemType models.FavoriteType) []models.FavoriteItem {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
 	return s.favorites.GetByType(itemType)
 }
 
+// ContainsMany vérifie la présence de plusieurs éléments sous un seul verrou
+func (s *FavoritesStorage) ContainsMany(keys []models.FavoriteKey) map[string]bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.favorites.ContainsMany(keys)
+}
+
 // Contains vérifie si un élément est dans les favoris
 func (s *FavoritesStorage) Contains(id string, itemType models.FavoriteType) bool {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
 	return s.favorites.Contains(id, itemType)
 }
 
-// save sauvegarde les favoris dans le fichier
+// save sauvegarde les favoris dans le fichier de manière atomique puis
+// enregistre une copie dans les sauvegardes tournantes
 func (s *FavoritesStorage) save() error {
 	log.Printf("Sauvegarde des favoris dans %s", s.filename)
-	data, err := json.MarshalIndent(s.favorites.Items, "", "  ")
+	data, err := json.MarshalIndent(s.favorites, "", "  ")
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(s.filename, data, 0644)
+	if err := writeFileAtomic(s.filename, data, 0644); err != nil {
+		return err
+	}
+	s.markSynced()
+
+	// Un échec de sauvegarde ne doit pas faire échouer l'écriture principale
+	if err := s.backups.create(data); err != nil {
+		log.Printf("Erreur lors de la création de la sauvegarde des favoris : %v", err)
+	}
+
+	return nil
 }
 
-// Load charge les favoris depuis le fichier
+// markSynced enregistre que le fichier contient exactement les favoris en
+// mémoire. La base est une copie : les transactions modifient directement
+// la collection.
+func (s *FavoritesStorage) markSynced() {
+	s.base = s.favorites.Clone()
+	if _, state, err := readFileState(s.filename); err == nil {
+		s.state = state
+	}
+}
+
+// Load charge les favoris depuis le fichier. Si le fichier est corrompu,
+// les favoris sont restaurés depuis la sauvegarde valide la plus récente.
 func (s *FavoritesStorage) Load() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.lock.Lock(); err != nil {
+		return err
+	}
+	defer s.lock.Unlock()
+
 	log.Printf("Chargement des favoris depuis %s", s.filename)
 	data, err := os.ReadFile(s.filename)
 	if err != nil {
 		// Si le fichier n'existe pas, initialiser avec un tableau vide
 		if os.IsNotExist(err) {
-			s.favorites.Items = []models.FavoriteItem{}
+			s.favorites = models.NewFavorites()
 			return nil
 		}
-		return err
+		return s.recover(err)
 	}
 
-	// Si le fichier est vide ou ne contient que des espaces, initialiser avec un tableau vide
-	if len(data) == 0 || string(data) == "[]" {
-		s.favorites.Items = []models.FavoriteItem{}
-		return nil
+	items, err := parseFavorites(data)
+	if err != nil {
+		return s.recover(err)
 	}
 
-	var items []models.FavoriteItem
-	if err := json.Unmarshal(data, &items); err != nil {
+	s.favorites = models.NewFavoritesFrom(items)
+	s.markSynced()
+	return nil
+}
+
+// recover met le fichier corrompu à l'écart, puis restaure les favoris
+// depuis la sauvegarde valide la plus récente et réécrit le fichier
+// principal. Sans sauvegarde valide, les favoris démarrent vides ; si le
+// fichier corrompu n'a pas pu être mis à l'écart, toute écriture est refusée.
+func (s *FavoritesStorage) recover(cause error) error {
+	log.Printf("Fichier des favoris %s corrompu : %v", s.filename, cause)
+
+	if err := keepCorrupt(s.filename); err != nil {
+		s.blocked = fmt.Errorf("favoris en lecture seule : %s est corrompu et n'a pas pu être mis à l'écart (%v)", s.filename, err)
+		return fmt.Errorf("%v (%w)", cause, s.blocked)
+	}
+
+	data, backup, err := s.backups.recover(func(data []byte) error {
+		_, err := parseFavorites(data)
 		return err
+	})
+	if err != nil {
+		return fmt.Errorf("%v (récupération impossible : %w)", cause, err)
+	}
+
+	items, _ := parseFavorites(data)
+	if err := writeFileAtomic(s.filename, data, 0644); err != nil {
+		log.Printf("Impossible de réécrire %s depuis la sauvegarde : %v", s.filename, err)
 	}
 
-	s.favorites.Items = items
+	log.Printf("Récupération de %d favoris depuis la sauvegarde %s", len(items), backup)
+	s.favorites = models.NewFavoritesFrom(items)
+	s.markSynced()
 	return nil
 }
+
+// parseFavorites décode le contenu d'un fichier de favoris. Un fichier vide est
+// considéré comme invalide : avec des écritures atomiques, il ne peut provenir
+// que d'une écriture interrompue.
+func parseFavorites(data []byte) ([]models.FavoriteItem, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("fichier vide")
+	}
+
+	var items []models.FavoriteItem
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []models.FavoriteItem{}
+	}
+	return items, nil
+}
@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFavoritesStorageRecover(t *testing.T) {
+	const (
+		older = "favorites-20240101T000000.000000000Z.json"
+		newer = "favorites-20240102T000000.000000000Z.json"
+	)
+
+	tests := []struct {
+		name    string
+		backups map[string]string
+		want    []string
+	}{
+		{
+			name:    "sauvegarde valide",
+			backups: map[string]string{newer: `[{"id":"a","type":"track","name":"A"}]`},
+			want:    []string{"a"},
+		},
+		{
+			name: "sauvegarde la plus récente invalide",
+			backups: map[string]string{
+				older: `[{"id":"a","type":"track","name":"A"},{"id":"b","type":"track","name":"B"}]`,
+				newer: `[{"id":"a",`,
+			},
+			want: []string{"a", "b"},
+		},
+		{
+			name: "aucune sauvegarde",
+			want: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			filename := filepath.Join(dir, "favorites.json")
+			const corrupted = `[{"id":"a","typ`
+			if err := os.WriteFile(filename, []byte(corrupted), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if len(tt.backups) > 0 {
+				if err := os.MkdirAll(filepath.Join(dir, "backups"), 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for name, content := range tt.backups {
+				if err := os.WriteFile(filepath.Join(dir, "backups", name), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			s, err := NewFavoritesStorage(dir, Options{Backups: 3})
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { s.Close() })
+
+			if got := ids(s.GetAll()); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("favoris récupérés = %v, attendu %v", got, tt.want)
+			}
+
+			// Le fichier corrompu est conservé à l'écart
+			matches, _ := filepath.Glob(filename + ".corrupt-*")
+			if len(matches) != 1 {
+				t.Fatalf("fichiers corrompus conservés : %v", matches)
+			}
+			if data, _ := os.ReadFile(matches[0]); string(data) != corrupted {
+				t.Errorf("contenu du fichier conservé = %q", data)
+			}
+
+			// Les écritures restent possibles et n'écrasent pas le fichier conservé
+			update(t, s, func(tx *Tx) { tx.Add(track("c")) })
+			if data, _ := os.ReadFile(filename); !strings.Contains(string(data), `"c"`) {
+				t.Errorf("favoris non enregistrés :\n%s", data)
+			}
+			if data, _ := os.ReadFile(matches[0]); string(data) != corrupted {
+				t.Errorf("fichier corrompu écrasé : %q", data)
+			}
+		})
+	}
+}
+
+func TestFavoritesStorageBlocked(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("les droits du dossier n'empêchent pas le renommage pour root")
+	}
+
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "favorites.json")
+	const corrupted = `[{"id":"a","typ`
+	if err := os.WriteFile(filename, []byte(corrupted), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Le verrou doit exister avant de retirer le droit d'écriture du dossier
+	if err := os.WriteFile(filepath.Join(dir, ".favorites.lock"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(dir, 0555); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(dir, 0755) })
+
+	s, err := NewFavoritesStorage(dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	if err := s.Add(track("b")); err == nil || !strings.Contains(err.Error(), "lecture seule") {
+		t.Errorf("écriture acceptée malgré le fichier corrompu : %v", err)
+	}
+	if data, _ := os.ReadFile(filename); string(data) != corrupted {
+		t.Errorf("fichier corrompu écrasé : %q", data)
+	}
+}
@@ -0,0 +1,383 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+
+	"github.com/yourusername/melody-explorer/internal/models"
+)
+
+// ErrListNotFound est renvoyée lorsqu'une liste n'existe pas
+var ErrListNotFound = errors.New("liste introuvable")
+
+// ListsStorage stocke les listes personnalisées dans lists.json, avec les mêmes
+// garanties que les favoris : écritures atomiques, sauvegardes tournantes et
+// verrou partagé entre processus. Avant chaque modification, le fichier est
+// relu s'il a été modifié par un autre processus.
+type ListsStorage struct {
+	filename string
+	lists    []models.List
+	backups  *backupManager
+	lock     *fileLock
+	watcher  *poller
+	state    fileState
+	// blocked interdit toute écriture lorsque le fichier corrompu n'a pas pu
+	// être mis à l'écart
+	blocked error
+	// observers sont appelés après chaque transaction qui modifie des listes
+	observers []func([]ListChange)
+
+	mu sync.RWMutex
+}
+
+// Actions des modifications de listes
+const (
+	ListCreated = "created"
+	ListUpdated = "updated"
+	ListDeleted = "deleted"
+)
+
+// ListChange décrit la modification d'une liste par une transaction
+type ListChange struct {
+	Action string `json:"action"`
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+}
+
+// NewListsStorage crée un nouveau ListsStorage dans dataDir
+func NewListsStorage(dataDir string, opts Options) (*ListsStorage, error) {
+	// S'assurer que le répertoire de données existe
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, err
+	}
+
+	storage := &ListsStorage{
+		filename: filepath.Join(dataDir, "lists.json"),
+		lists:    []models.List{},
+		backups:  newBackupManager(filepath.Join(dataDir, "lists.json"), opts.Backups),
+		lock:     newFileLock(filepath.Join(dataDir, ".lists.lock")),
+	}
+
+	if err := storage.Load(); err != nil {
+		log.Printf("Erreur lors du chargement des listes, démarrage sans liste : %v", err)
+	} else {
+		log.Printf("Chargement de %d listes depuis %s", len(storage.lists), storage.filename)
+	}
+
+	storage.watcher = startPoller(opts.WatchInterval, storage.reloadIfChanged)
+
+	return storage, nil
+}
+
+// All renvoie une copie de toutes les listes, dans l'ordre de création
+func (s *ListsStorage) All() []models.List {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	lists := make([]models.List, len(s.lists))
+	for i, list := range s.lists {
+		lists[i] = list.Clone()
+	}
+	return lists
+}
+
+// Get renvoie une copie de la liste correspondante
+func (s *ListsStorage) Get(id string) (models.List, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, list := range s.lists {
+		if list.ID == id {
+			return list.Clone(), true
+		}
+	}
+	return models.List{}, false
+}
+
+// ListsTx est une transaction sur les listes. Elle travaille sur une copie
+// des listes, enregistrée seulement si la fonction de mise à jour réussit.
+type ListsTx struct {
+	lists   []models.List
+	changed bool
+}
+
+// All renvoie les listes telles que vues par la transaction
+func (tx *ListsTx) All() []models.List {
+	return tx.lists
+}
+
+// Get renvoie la liste correspondante pour modification
+func (tx *ListsTx) Get(id string) (*models.List, bool) {
+	for i := range tx.lists {
+		if tx.lists[i].ID == id {
+			tx.changed = true
+			return &tx.lists[i], true
+		}
+	}
+	return nil, false
+}
+
+// Create ajoute une nouvelle liste
+func (tx *ListsTx) Create(list models.List) {
+	tx.lists = append(tx.lists, list)
+	tx.changed = true
+}
+
+// Delete supprime une liste et indique si elle existait
+func (tx *ListsTx) Delete(id string) bool {
+	for i := range tx.lists {
+		if tx.lists[i].ID == id {
+			tx.lists = append(tx.lists[:i], tx.lists[i+1:]...)
+			tx.changed = true
+			return true
+		}
+	}
+	return false
+}
+
+// OnChange enregistre fn, appelée après chaque transaction qui modifie des
+// listes. fn est appelée dans l'ordre des transactions et ne doit pas bloquer.
+func (s *ListsStorage) OnChange(fn func(changes []ListChange)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.observers = append(s.observers, fn)
+}
+
+// Update exécute fn dans une transaction et réécrit le fichier une seule fois.
+// En cas d'erreur de fn ou d'écriture, les listes restent inchangées.
+func (s *ListsStorage) Update(fn func(tx *ListsTx) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.blocked != nil {
+		return s.blocked
+	}
+	if err := s.lock.Lock(); err != nil {
+		return fmt.Errorf("verrouillage des listes : %w", err)
+	}
+	defer s.lock.Unlock()
+
+	// Partir de la dernière version écrite par un éventuel autre processus
+	s.syncFromDisk()
+
+	tx := &ListsTx{lists: make([]models.List, len(s.lists))}
+	for i, list := range s.lists {
+		tx.lists[i] = list.Clone()
+	}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if !tx.changed {
+		return nil
+	}
+
+	previous := s.lists
+	s.lists = tx.lists
+	if err := s.save(); err != nil {
+		s.lists = previous
+		return err
+	}
+
+	if changes := diffLists(previous, s.lists); len(changes) > 0 {
+		for _, fn := range s.observers {
+			fn(changes)
+		}
+	}
+	return nil
+}
+
+// diffLists renvoie les listes créées, modifiées et supprimées entre deux états
+func diffLists(before, after []models.List) []ListChange {
+	previous := make(map[string]models.List, len(before))
+	for _, list := range before {
+		previous[list.ID] = list
+	}
+
+	var changes []ListChange
+	for _, list := range after {
+		old, existed := previous[list.ID]
+		delete(previous, list.ID)
+		switch {
+		case !existed:
+			changes = append(changes, ListChange{Action: ListCreated, ID: list.ID, Name: list.Name})
+		case !reflect.DeepEqual(old, list):
+			changes = append(changes, ListChange{Action: ListUpdated, ID: list.ID, Name: list.Name})
+		}
+	}
+	for _, list := range before {
+		if _, deleted := previous[list.ID]; deleted {
+			changes = append(changes, ListChange{Action: ListDeleted, ID: list.ID, Name: list.Name})
+		}
+	}
+	return changes
+}
+
+// Close arrête la surveillance du fichier
+func (s *ListsStorage) Close() error {
+	s.watcher.Stop()
+	return nil
+}
+
+// reloadIfChanged recharge le fichier s'il a été modifié par un autre processus
+func (s *ListsStorage) reloadIfChanged() {
+	s.mu.RLock()
+	changed := s.state.statChanged(s.filename)
+	s.mu.RUnlock()
+	if !changed {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.lock.Lock(); err != nil {
+		log.Printf("Verrouillage des listes impossible : %v", err)
+		return
+	}
+	defer s.lock.Unlock()
+
+	s.syncFromDisk()
+}
+
+// syncFromDisk recharge le fichier s'il a changé depuis la dernière lecture.
+// Doit être appelée avec s.mu et le verrou de fichier détenus.
+func (s *ListsStorage) syncFromDisk() {
+	if !s.state.statChanged(s.filename) {
+		return
+	}
+
+	data, state, err := readFileState(s.filename)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Lecture de %s impossible : %v", s.filename, err)
+		}
+		return
+	}
+	if state.sum == s.state.sum {
+		s.state = state
+		return
+	}
+
+	lists, err := parseLists(data)
+	if err != nil {
+		log.Printf("Modification externe invalide de %s ignorée : %v", s.filename, err)
+		return
+	}
+
+	s.lists = lists
+	s.state = state
+	log.Printf("Listes modifiées par un autre processus : %d listes rechargées", len(lists))
+}
+
+// save sauvegarde les listes de manière atomique puis enregistre une copie
+// dans les sauvegardes tournantes
+func (s *ListsStorage) save() error {
+	data, err := json.MarshalIndent(s.lists, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := writeFileAtomic(s.filename, data, 0644); err != nil {
+		return err
+	}
+	if _, state, err := readFileState(s.filename); err == nil {
+		s.state = state
+	}
+
+	// Un échec de sauvegarde ne doit pas faire échouer l'écriture principale
+	if err := s.backups.create(data); err != nil {
+		log.Printf("Erreur lors de la création de la sauvegarde des listes : %v", err)
+	}
+
+	return nil
+}
+
+// Load charge les listes depuis le fichier. Si le fichier est corrompu, les
+// listes sont restaurées depuis la sauvegarde valide la plus récente.
+func (s *ListsStorage) Load() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.lock.Lock(); err != nil {
+		return err
+	}
+	defer s.lock.Unlock()
+
+	data, state, err := readFileState(s.filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			s.lists = []models.List{}
+			return nil
+		}
+		return s.recover(err)
+	}
+
+	lists, err := parseLists(data)
+	if err != nil {
+		return s.recover(err)
+	}
+
+	s.lists = lists
+	s.state = state
+	return nil
+}
+
+// recover met le fichier corrompu à l'écart, puis restaure les listes
+// depuis la sauvegarde valide la plus récente et réécrit le fichier
+// principal. Si le fichier corrompu n'a pas pu être mis à l'écart, toute
+// écriture est refusée.
+func (s *ListsStorage) recover(cause error) error {
+	log.Printf("Fichier des listes %s corrompu : %v", s.filename, cause)
+
+	if err := keepCorrupt(s.filename); err != nil {
+		s.blocked = fmt.Errorf("listes en lecture seule : %s est corrompu et n'a pas pu être mis à l'écart (%v)", s.filename, err)
+		return fmt.Errorf("%v (%w)", cause, s.blocked)
+	}
+
+	data, backup, err := s.backups.recover(func(data []byte) error {
+		_, err := parseLists(data)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("%v (récupération impossible : %w)", cause, err)
+	}
+
+	lists, _ := parseLists(data)
+
+	s.lists = lists
+	if err := s.save(); err != nil {
+		log.Printf("Impossible de réécrire %s depuis la sauvegarde : %v", s.filename, err)
+	}
+
+	log.Printf("Récupération de %d listes depuis la sauvegarde %s", len(lists), backup)
+	return nil
+}
+
+// parseLists décode le contenu d'un fichier de listes et trie les éléments
+// de chaque liste selon leur position
+func parseLists(data []byte) ([]models.List, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("fichier vide")
+	}
+
+	var lists []models.List
+	if err := json.Unmarshal(data, &lists); err != nil {
+		return nil, err
+	}
+	if lists == nil {
+		lists = []models.List{}
+	}
+	for i := range lists {
+		if lists[i].Entries == nil {
+			lists[i].Entries = []models.ListEntry{}
+		}
+		lists[i].SortByPosition()
+	}
+	return lists, nil
+}
@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"os"
+	"sync"
+)
+
+// fileLock est un verrou consultatif inter-processus posé sur un fichier
+// dédié du répertoire de données. Le fichier verrouillé n'est jamais renommé,
+// contrairement aux fichiers de données réécrits de manière atomique.
+type fileLock struct {
+	path string
+	file *os.File
+	mu   sync.Mutex
+}
+
+// newFileLock crée un verrou sur le chemin donné
+func newFileLock(path string) *fileLock {
+	return &fileLock{path: path}
+}
+
+// Lock acquiert le verrou, en attendant qu'un autre processus le libère.
+// Le verrou n'est pas réentrant ; le mutex interne sérialise aussi les
+// goroutines du processus courant.
+func (l *fileLock) Lock() error {
+	l.mu.Lock()
+
+	f, err := lockFile(l.path)
+	if err != nil {
+		l.mu.Unlock()
+		return err
+	}
+	l.file = f
+	return nil
+}
+
+// Unlock libère le verrou
+func (l *fileLock) Unlock() error {
+	defer l.mu.Unlock()
+
+	err := unlockFile(l.path, l.file)
+	l.file = nil
+	return err
+}
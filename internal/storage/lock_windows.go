@@ -0,0 +1,48 @@
+//go:build windows
+
+package storage
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// staleLockAge est l'âge au-delà duquel un fichier de verrou est considéré
+// comme abandonné par un processus arrêté brutalement
+const staleLockAge = 30 * time.Second
+
+// lockFile crée le fichier de verrou de manière exclusive, en attendant
+// qu'un autre processus le supprime
+func lockFile(path string) (*os.File, error) {
+	deadline := time.Now().Add(2 * staleLockAge)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_RDWR, 0644)
+		if err == nil {
+			fmt.Fprintf(f, "%d\n", os.Getpid())
+			return f, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+
+		// Supprimer un verrou abandonné
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > staleLockAge {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("délai dépassé pour le verrou %s", path)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// unlockFile ferme et supprime le fichier de verrou
+func unlockFile(path string, f *os.File) error {
+	if f == nil {
+		return nil
+	}
+	f.Close()
+	return os.Remove(path)
+}
@@ -0,0 +1,488 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/yourusername/melody-explorer/internal/models"
+)
+
+var _ FavoritesStore = (*LogStorage)(nil)
+
+// logEntry est une ligne du journal : les opérations d'une transaction
+type logEntry struct {
+	Seq  uint64      `json:"seq"`
+	Time time.Time   `json:"time"`
+	Ops  []operation `json:"ops"`
+}
+
+// logSnapshot est l'état compacté des favoris jusqu'à une séquence donnée
+type logSnapshot struct {
+	Seq   uint64                `json:"seq"`
+	Items []models.FavoriteItem `json:"items"`
+}
+
+// LogStorage stocke les favoris dans un journal d'opérations en ajout seul.
+// Chaque transaction est une seule ligne ajoutée au journal ; le journal est
+// régulièrement compacté dans un instantané puis vidé.
+//
+// Plusieurs processus peuvent partager le même journal : les écritures sont
+// protégées par un verrou consultatif, et les entrées ajoutées par les autres
+// processus sont rejouées avant chaque écriture et lors de la surveillance.
+type LogStorage struct {
+	logFile      string
+	snapshotFile string
+	legacyFile   string
+	favorites    *models.Favorites
+	backups      *backupManager
+	lock         *fileLock
+
+	log       *os.File
+	logSize   int64
+	seq       uint64
+	pending   int
+	snapState fileState
+
+	compactEvery int
+	compactor    *poller
+	watcher      *poller
+	mu           sync.RWMutex
+}
+
+// NewLogStorage ouvre le journal des favoris dans dataDir, en rejouant les
+// opérations postérieures au dernier instantané
+func NewLogStorage(dataDir string, opts Options) (*LogStorage, error) {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, err
+	}
+
+	snapshotFile := filepath.Join(dataDir, "favorites.snapshot.json")
+	s := &LogStorage{
+		logFile:      filepath.Join(dataDir, "favorites.log"),
+		snapshotFile: snapshotFile,
+		legacyFile:   filepath.Join(dataDir, "favorites.json"),
+		favorites:    models.NewFavorites(),
+		backups:      newBackupManager(snapshotFile, opts.Backups),
+		lock:         newFileLock(filepath.Join(dataDir, ".favorites.lock")),
+		compactEvery: opts.CompactEvery,
+	}
+
+	if err := s.lock.Lock(); err != nil {
+		return nil, err
+	}
+	err := s.load()
+	s.lock.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(s.logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	s.log = f
+
+	log.Printf("Chargement de %d favoris depuis %s (séquence %d)", s.favorites.Len(), s.logFile, s.seq)
+
+	s.compactor = startPoller(opts.CompactInterval, s.compactIfPending)
+	s.watcher = startPoller(opts.WatchInterval, s.reloadIfChanged)
+
+	return s, nil
+}
+
+// load reconstruit l'état complet depuis l'instantané et le journal
+func (s *LogStorage) load() error {
+	s.favorites = models.NewFavorites()
+	s.seq = 0
+	s.pending = 0
+
+	if err := s.loadSnapshot(); err != nil {
+		return err
+	}
+	s.statSnapshot()
+	return s.replay(0)
+}
+
+// statSnapshot enregistre l'état de l'instantané pour détecter une
+// compaction faite par un autre processus
+func (s *LogStorage) statSnapshot() {
+	if info, err := os.Stat(s.snapshotFile); err == nil {
+		s.snapState = fileState{modTime: info.ModTime(), size: info.Size()}
+	}
+}
+
+// loadSnapshot charge le dernier instantané. En son absence, le fichier
+// favorites.json existant est importé pour migrer depuis le backend JSON.
+func (s *LogStorage) loadSnapshot() error {
+	data, err := os.ReadFile(s.snapshotFile)
+	if os.IsNotExist(err) {
+		return s.importLegacy()
+	}
+	if err == nil {
+		var snapshot *logSnapshot
+		if snapshot, err = parseSnapshot(data); err == nil {
+			s.favorites = models.NewFavoritesFrom(snapshot.Items)
+			s.seq = snapshot.Seq
+			return nil
+		}
+	}
+
+	// Instantané corrompu : restaurer la sauvegarde valide la plus récente
+	log.Printf("Instantané des favoris %s corrompu : %v", s.snapshotFile, err)
+	data, backup, rerr := s.backups.recover(func(data []byte) error {
+		_, err := parseSnapshot(data)
+		return err
+	})
+	if rerr != nil {
+		return fmt.Errorf("%v (récupération impossible : %w)", err, rerr)
+	}
+
+	snapshot, _ := parseSnapshot(data)
+	if err := writeFileAtomic(s.snapshotFile, data, 0644); err != nil {
+		log.Printf("Impossible de réécrire %s depuis la sauvegarde : %v", s.snapshotFile, err)
+	}
+
+	log.Printf("Récupération de %d favoris depuis la sauvegarde %s", len(snapshot.Items), backup)
+	s.favorites = models.NewFavoritesFrom(snapshot.Items)
+	s.seq = snapshot.Seq
+	return nil
+}
+
+// importLegacy initialise l'instantané depuis favorites.json s'il existe
+func (s *LogStorage) importLegacy() error {
+	data, err := os.ReadFile(s.legacyFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	items, err := parseFavorites(data)
+	if err != nil {
+		log.Printf("favorites.json ignoré lors de la migration vers le journal : %v", err)
+		return nil
+	}
+
+	log.Printf("Migration de %d favoris depuis %s vers le journal", len(items), s.legacyFile)
+	s.favorites = models.NewFavoritesFrom(items)
+	return s.writeSnapshot()
+}
+
+// replay rejoue les entrées du journal à partir de l'octet from qui sont
+// postérieures à l'état courant. Une dernière ligne incomplète, laissée par
+// une écriture interrompue, est tronquée ; une ligne illisible suivie
+// d'autres entrées est une erreur, le journal est alors laissé intact.
+func (s *LogStorage) replay(from int64) error {
+	f, err := os.OpenFile(s.logFile, os.O_RDWR, 0644)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(from, io.SeekStart); err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(f)
+	offset := from
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF && len(line) == 0 {
+			break
+		}
+		if err != nil && err != io.EOF {
+			return err
+		}
+
+		var entry logEntry
+		torn := err == io.EOF
+		if !torn {
+			if uerr := json.Unmarshal(bytes.TrimSpace(line), &entry); uerr != nil {
+				// Seule la dernière ligne peut avoir été interrompue : une ligne
+				// illisible suivie d'autres entrées ne doit pas être tronquée
+				if _, perr := reader.Peek(1); perr != io.EOF {
+					return fmt.Errorf("entrée illisible dans %s (octet %d) : %w", s.logFile, offset, uerr)
+				}
+				torn = true
+			}
+		}
+		if torn {
+			log.Printf("Entrée incomplète à la fin de %s (octet %d), troncature", s.logFile, offset)
+			if err := f.Truncate(offset); err != nil {
+				return err
+			}
+			break
+		}
+		offset += int64(len(line))
+
+		// Les entrées déjà incluses dans l'instantané sont ignorées
+		if entry.Seq <= s.seq {
+			continue
+		}
+		for _, op := range entry.Ops {
+			op.apply(s.favorites)
+		}
+		s.seq = entry.Seq
+		s.pending++
+	}
+
+	s.logSize = offset
+	return nil
+}
+
+// Add ajoute un élément favori
+func (s *LogStorage) Add(item models.FavoriteItem) error {
+	return s.Update(func(tx *Tx) error {
+		tx.Add(item)
+		return nil
+	})
+}
+
+// Remove supprime un élément favori
+func (s *LogStorage) Remove(id string, itemType models.FavoriteType) error {
+	return s.Update(func(tx *Tx) error {
+		tx.Remove(id, itemType)
+		return nil
+	})
+}
+
+// Update exécute fn dans une transaction et ajoute ses opérations au journal
+// sous forme d'une seule ligne
+func (s *LogStorage) Update(fn func(tx *Tx) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.lock.Lock(); err != nil {
+		return fmt.Errorf("verrouillage des favoris : %w", err)
+	}
+	defer s.lock.Unlock()
+
+	// Rejouer les entrées ajoutées par un autre processus avant d'écrire
+	if err := s.refresh(); err != nil {
+		return err
+	}
+
+	tx := newTx(s.favorites)
+	if err := fn(tx); err != nil {
+		tx.rollback()
+		return err
+	}
+	if len(tx.ops) == 0 {
+		return nil
+	}
+
+	entry := logEntry{Seq: s.seq + 1, Time: time.Now(), Ops: tx.ops}
+	if err := s.append(entry); err != nil {
+		tx.rollback()
+		log.Printf("Erreur lors de l'écriture dans le journal des favoris : %v", err)
+		return err
+	}
+
+	s.seq = entry.Seq
+	s.pending++
+
+	if s.compactEvery > 0 && s.pending >= s.compactEvery {
+		if err := s.compact(); err != nil {
+			log.Printf("Erreur lors de la compaction du journal des favoris : %v", err)
+		}
+	}
+
+	return nil
+}
+
+// append écrit une entrée à la fin du journal et la synchronise sur le disque
+func (s *LogStorage) append(entry logEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	if _, err := s.log.Write(line); err != nil {
+		// Retirer une éventuelle écriture partielle
+		s.log.Truncate(s.logSize)
+		return err
+	}
+	if err := s.log.Sync(); err != nil {
+		s.log.Truncate(s.logSize)
+		return err
+	}
+
+	s.logSize += int64(len(line))
+	return nil
+}
+
+// refresh intègre les modifications faites par un autre processus : nouvelles
+// entrées du journal, ou rechargement complet après une compaction externe.
+// Doit être appelée avec s.mu et le verrou de fichier détenus.
+func (s *LogStorage) refresh() error {
+	info, err := os.Stat(s.logFile)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case s.snapState.statChanged(s.snapshotFile) || info.Size() < s.logSize:
+		log.Printf("Journal des favoris compacté par un autre processus, rechargement")
+		return s.load()
+	case info.Size() > s.logSize:
+		before := s.seq
+		if err := s.replay(s.logSize); err != nil {
+			return err
+		}
+		log.Printf("Rejeu de %d entrées du journal ajoutées par un autre processus", s.seq-before)
+	}
+	return nil
+}
+
+// reloadIfChanged rejoue le journal s'il a été modifié par un autre processus
+func (s *LogStorage) reloadIfChanged() {
+	s.mu.RLock()
+	info, err := os.Stat(s.logFile)
+	changed := err == nil && (info.Size() != s.logSize || s.snapState.statChanged(s.snapshotFile))
+	s.mu.RUnlock()
+	if !changed {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.lock.Lock(); err != nil {
+		log.Printf("Verrouillage des favoris impossible : %v", err)
+		return
+	}
+	defer s.lock.Unlock()
+
+	if err := s.refresh(); err != nil {
+		log.Printf("Erreur lors du rechargement du journal des favoris : %v", err)
+	}
+}
+
+// compact écrit un instantané de l'état courant puis vide le journal.
+// Un arrêt entre les deux étapes est sans conséquence : les entrées déjà
+// couvertes par l'instantané sont ignorées au rechargement.
+func (s *LogStorage) compact() error {
+	if err := s.writeSnapshot(); err != nil {
+		return err
+	}
+	if err := s.log.Truncate(0); err != nil {
+		return err
+	}
+	if err := s.log.Sync(); err != nil {
+		return err
+	}
+
+	log.Printf("Journal des favoris compacté à la séquence %d", s.seq)
+	s.logSize = 0
+	s.pending = 0
+	return nil
+}
+
+// writeSnapshot enregistre l'état courant dans l'instantané et ses sauvegardes
+func (s *LogStorage) writeSnapshot() error {
+	data, err := json.MarshalIndent(logSnapshot{Seq: s.seq, Items: s.favorites.Get()}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(s.snapshotFile, data, 0644); err != nil {
+		return err
+	}
+	s.statSnapshot()
+	if err := s.backups.create(data); err != nil {
+		log.Printf("Erreur lors de la création de la sauvegarde de l'instantané : %v", err)
+	}
+	return nil
+}
+
+// compactIfPending compacte le journal s'il contient des entrées
+func (s *LogStorage) compactIfPending() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pending == 0 {
+		return
+	}
+	if err := s.lock.Lock(); err != nil {
+		log.Printf("Verrouillage des favoris impossible : %v", err)
+		return
+	}
+	defer s.lock.Unlock()
+
+	if err := s.refresh(); err != nil {
+		log.Printf("Erreur lors du rechargement du journal des favoris : %v", err)
+		return
+	}
+	if err := s.compact(); err != nil {
+		log.Printf("Erreur lors de la compaction du journal des favoris : %v", err)
+	}
+}
+
+// GetAll renvoie tous les éléments favoris
+func (s *LogStorage) GetAll() []models.FavoriteItem {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.favorites.Get()
+}
+
+// GetByType renvoie les éléments favoris d'un type spécifique
+func (s *LogStorage) GetByType(itemType models.FavoriteType) []models.FavoriteItem {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.favorites.GetByType(itemType)
+}
+
+// ContainsMany vérifie la présence de plusieurs éléments sous un seul verrou
+func (s *LogStorage) ContainsMany(keys []models.FavoriteKey) map[string]bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.favorites.ContainsMany(keys)
+}
+
+// Contains vérifie si un élément est dans les favoris
+func (s *LogStorage) Contains(id string, itemType models.FavoriteType) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.favorites.Contains(id, itemType)
+}
+
+// Close arrête la compaction périodique et la surveillance, compacte le
+// journal et ferme le fichier
+func (s *LogStorage) Close() error {
+	s.compactor.Stop()
+	s.watcher.Stop()
+	s.compactIfPending()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.log.Close()
+}
+
+// parseSnapshot décode le contenu d'un instantané
+func parseSnapshot(data []byte) (*logSnapshot, error) {
+	var snapshot logSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, err
+	}
+	if snapshot.Items == nil {
+		snapshot.Items = []models.FavoriteItem{}
+	}
+	return &snapshot, nil
+}
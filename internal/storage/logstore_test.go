@@ -0,0 +1,239 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/melody-explorer/internal/models"
+)
+
+// track crée un morceau favori de test
+func track(id string) models.FavoriteItem {
+	return models.FavoriteItem{ID: id, Type: models.FavoriteTypeTrack, Name: "Morceau " + id}
+}
+
+// ids renvoie les identifiants des favoris, dans leur ordre
+func ids(items []models.FavoriteItem) []string {
+	result := []string{}
+	for _, item := range items {
+		result = append(result, item.ID)
+	}
+	return result
+}
+
+// openLog ouvre un journal dans dir sans surveillance ni compaction
+// périodique. Seul le fichier du journal est fermé à la fin du test, pour
+// que Close ne compacte pas le journal examiné par le test.
+func openLog(t *testing.T, dir string, compactEvery int) *LogStorage {
+	t.Helper()
+	s, err := NewLogStorage(dir, Options{CompactEvery: compactEvery})
+	if err != nil {
+		t.Fatalf("ouverture du journal : %v", err)
+	}
+	t.Cleanup(func() { s.log.Close() })
+	return s
+}
+
+// update applique une transaction en échouant le test en cas d'erreur
+func update(t *testing.T, s FavoritesStore, fn func(tx *Tx)) {
+	t.Helper()
+	err := s.Update(func(tx *Tx) error {
+		fn(tx)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("transaction : %v", err)
+	}
+}
+
+func TestLogStorageReplay(t *testing.T) {
+	tests := []struct {
+		name string
+		txs  []func(tx *Tx)
+		want []string
+		seq  uint64
+	}{
+		{
+			name: "journal vide",
+			want: []string{},
+		},
+		{
+			name: "ajouts successifs",
+			txs: []func(tx *Tx){
+				func(tx *Tx) { tx.Add(track("a")) },
+				func(tx *Tx) { tx.Add(track("b")); tx.Add(track("c")) },
+			},
+			want: []string{"a", "b", "c"},
+			seq:  2,
+		},
+		{
+			name: "suppression et déplacement",
+			txs: []func(tx *Tx){
+				func(tx *Tx) { tx.Add(track("a")); tx.Add(track("b")); tx.Add(track("c")) },
+				func(tx *Tx) { tx.Remove("b", models.FavoriteTypeTrack) },
+				func(tx *Tx) { tx.Move("c", models.FavoriteTypeTrack, 0) },
+			},
+			want: []string{"c", "a"},
+			seq:  3,
+		},
+		{
+			name: "transaction sans effet non journalisée",
+			txs: []func(tx *Tx){
+				func(tx *Tx) { tx.Add(track("a")) },
+				func(tx *Tx) { tx.Remove("absent", models.FavoriteTypeTrack) },
+			},
+			want: []string{"a"},
+			seq:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			s := openLog(t, dir, 0)
+			for _, fn := range tt.txs {
+				update(t, s, fn)
+			}
+
+			reopened := openLog(t, dir, 0)
+			if got := ids(reopened.GetAll()); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("favoris rejoués = %v, attendu %v", got, tt.want)
+			}
+			if reopened.seq != tt.seq {
+				t.Errorf("séquence = %d, attendu %d", reopened.seq, tt.seq)
+			}
+		})
+	}
+}
+
+func TestLogStorageTornTail(t *testing.T) {
+	tests := []struct {
+		name    string
+		tail    string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "ligne interrompue sans fin de ligne",
+			tail: `{"seq":3,"time":"2024-01-01T00:00:00Z","ops":[{"op":"ad`,
+			want: []string{"a", "b"},
+		},
+		{
+			name: "dernière ligne illisible",
+			tail: "{\"seq\":3,\"ops\n",
+			want: []string{"a", "b"},
+		},
+		{
+			name:    "ligne illisible suivie d'une entrée",
+			tail:    "illisible\n" + `{"seq":3,"time":"2024-01-01T00:00:00Z","ops":[{"op":"remove","id":"a","type":"track"}]}` + "\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			s := openLog(t, dir, 0)
+			update(t, s, func(tx *Tx) { tx.Add(track("a")) })
+			update(t, s, func(tx *Tx) { tx.Add(track("b")) })
+
+			logFile := filepath.Join(dir, "favorites.log")
+			valid, err := os.ReadFile(logFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			corrupted := string(valid) + tt.tail
+			if err := os.WriteFile(logFile, []byte(corrupted), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			reopened, err := NewLogStorage(dir, Options{})
+			if tt.wantErr {
+				if err == nil {
+					reopened.log.Close()
+					t.Fatal("erreur attendue pour une entrée illisible au milieu du journal")
+				}
+				// Le journal est laissé intact
+				if data, _ := os.ReadFile(logFile); string(data) != corrupted {
+					t.Errorf("journal modifié malgré l'erreur :\n%s", data)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ouverture du journal : %v", err)
+			}
+			t.Cleanup(func() { reopened.log.Close() })
+
+			if got := ids(reopened.GetAll()); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("favoris = %v, attendu %v", got, tt.want)
+			}
+			if data, _ := os.ReadFile(logFile); string(data) != string(valid) {
+				t.Errorf("journal non tronqué :\n%s", data)
+			}
+
+			// Les écritures suivantes reprennent après la troncature
+			update(t, reopened, func(tx *Tx) { tx.Add(track("c")) })
+			if got := ids(openLog(t, dir, 0).GetAll()); !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+				t.Errorf("favoris après écriture = %v", got)
+			}
+		})
+	}
+}
+
+func TestLogStorageCompaction(t *testing.T) {
+	tests := []struct {
+		name string
+		// replayStale remet dans le journal les entrées compactées, comme
+		// après un arrêt entre l'écriture de l'instantané et la troncature
+		replayStale bool
+	}{
+		{name: "compaction complète"},
+		{name: "arrêt avant la troncature du journal", replayStale: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			logFile := filepath.Join(dir, "favorites.log")
+			s := openLog(t, dir, 3)
+			update(t, s, func(tx *Tx) { tx.Add(track("a")); tx.Add(track("b")) })
+			update(t, s, func(tx *Tx) { tx.Remove("a", models.FavoriteTypeTrack) })
+
+			stale, err := os.ReadFile(logFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// La troisième écriture déclenche la compaction
+			update(t, s, func(tx *Tx) { tx.Move("b", models.FavoriteTypeTrack, 0); tx.Add(track("c")) })
+			if info, err := os.Stat(logFile); err != nil || info.Size() != 0 {
+				t.Fatalf("journal non vidé après compaction : %v", err)
+			}
+			if _, err := os.Stat(filepath.Join(dir, "favorites.snapshot.json")); err != nil {
+				t.Fatalf("instantané absent : %v", err)
+			}
+
+			if tt.replayStale {
+				if err := os.WriteFile(logFile, stale, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			update(t, s, func(tx *Tx) { tx.Add(track("d")) })
+
+			reopened := openLog(t, dir, 0)
+			want := []string{"b", "c", "d"}
+			if got := ids(reopened.GetAll()); !reflect.DeepEqual(got, want) {
+				t.Errorf("favoris = %v, attendu %v", got, want)
+			}
+			if reopened.seq != 4 {
+				t.Errorf("séquence = %d, attendu 4", reopened.seq)
+			}
+			if data, _ := os.ReadFile(logFile); !strings.Contains(string(data), `"seq":4`) {
+				t.Errorf("entrée postérieure à la compaction absente du journal :\n%s", data)
+			}
+		})
+	}
+}
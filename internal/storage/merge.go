@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"encoding/json"
+
+	"github.com/yourusername/melody-explorer/internal/models"
+)
+
+// mergeFavorites fusionne les modifications faites en mémoire (ours) avec
+// celles faites sur le disque par un autre processus (theirs), par rapport à
+// leur ancêtre commun (base). Le résultat part de l'état du disque, auquel
+// sont réappliqués les ajouts, modifications et suppressions locaux.
+func mergeFavorites(base, ours, theirs *models.Favorites) *models.Favorites {
+	merged := theirs.Clone()
+
+	// Réappliquer les ajouts et modifications locaux
+	for _, item := range ours.Get() {
+		original, existed := base.Find(item.ID, item.Type)
+		if !existed || !sameItem(original, item) {
+			merged.Add(item)
+		}
+	}
+
+	// Réappliquer les suppressions locales
+	for _, item := range base.Get() {
+		if !ours.Contains(item.ID, item.Type) {
+			merged.Remove(item.ID, item.Type)
+		}
+	}
+
+	return merged
+}
+
+// sameItem compare deux éléments favoris par leur représentation JSON
+func sameItem(a, b models.FavoriteItem) bool {
+	encodedA, errA := json.Marshal(a)
+	encodedB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(encodedA) == string(encodedB)
+}
@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/yourusername/melody-explorer/internal/models"
+)
+
+func TestMergeFavorites(t *testing.T) {
+	added := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	item := func(id, note string) models.FavoriteItem {
+		favorite := track(id)
+		favorite.AddedAt = added
+		favorite.Note = note
+		return favorite
+	}
+	collection := func(items ...models.FavoriteItem) *models.Favorites {
+		return models.NewFavoritesFrom(items)
+	}
+
+	base := collection(item("a", ""), item("b", ""), item("c", ""))
+
+	tests := []struct {
+		name   string
+		ours   *models.Favorites
+		theirs *models.Favorites
+		want   []models.FavoriteItem
+	}{
+		{
+			name:   "aucune modification",
+			ours:   base.Clone(),
+			theirs: base.Clone(),
+			want:   []models.FavoriteItem{item("a", ""), item("b", ""), item("c", "")},
+		},
+		{
+			name:   "ajouts des deux côtés",
+			ours:   collection(item("a", ""), item("b", ""), item("c", ""), item("d", "")),
+			theirs: collection(item("a", ""), item("b", ""), item("c", ""), item("e", "")),
+			want:   []models.FavoriteItem{item("a", ""), item("b", ""), item("c", ""), item("e", ""), item("d", "")},
+		},
+		{
+			name:   "suppressions des deux côtés",
+			ours:   collection(item("a", ""), item("c", "")),
+			theirs: collection(item("a", ""), item("b", "")),
+			want:   []models.FavoriteItem{item("a", "")},
+		},
+		{
+			name:   "modification locale conservée",
+			ours:   collection(item("a", "locale"), item("b", ""), item("c", "")),
+			theirs: base.Clone(),
+			want:   []models.FavoriteItem{item("a", "locale"), item("b", ""), item("c", "")},
+		},
+		{
+			name:   "modification du disque conservée",
+			ours:   base.Clone(),
+			theirs: collection(item("a", ""), item("b", "disque"), item("c", "")),
+			want:   []models.FavoriteItem{item("a", ""), item("b", "disque"), item("c", "")},
+		},
+		{
+			name:   "modification concurrente : la locale l'emporte",
+			ours:   collection(item("a", "locale"), item("b", ""), item("c", "")),
+			theirs: collection(item("a", "disque"), item("b", ""), item("c", "")),
+			want:   []models.FavoriteItem{item("a", "locale"), item("b", ""), item("c", "")},
+		},
+		{
+			name:   "suppression locale d'un favori modifié sur le disque",
+			ours:   collection(item("b", ""), item("c", "")),
+			theirs: collection(item("a", "disque"), item("b", ""), item("c", "")),
+			want:   []models.FavoriteItem{item("b", ""), item("c", "")},
+		},
+		{
+			name:   "modification locale d'un favori supprimé sur le disque",
+			ours:   collection(item("a", "locale"), item("b", ""), item("c", "")),
+			theirs: collection(item("b", ""), item("c", "")),
+			want:   []models.FavoriteItem{item("b", ""), item("c", ""), item("a", "locale")},
+		},
+		{
+			name:   "favori supprimé sur le disque et inchangé localement",
+			ours:   base.Clone(),
+			theirs: collection(item("a", ""), item("c", "")),
+			want:   []models.FavoriteItem{item("a", ""), item("c", "")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mergeFavorites(base, tt.ours, tt.theirs).Get()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fusion = %+v, attendu %+v", got, tt.want)
+			}
+		})
+	}
+}
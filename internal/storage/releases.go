@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/yourusername/melody-explorer/internal/models"
+)
+
+// ReleasesStorage conserve dans releases.json la discographie déjà vue des
+// artistes favoris, pour la détection des nouvelles sorties
+type ReleasesStorage struct {
+	filename string
+	seen     map[string]models.SeenReleases
+
+	mu sync.RWMutex
+}
+
+// NewReleasesStorage crée un nouveau ReleasesStorage dans dataDir
+func NewReleasesStorage(dataDir string) (*ReleasesStorage, error) {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, err
+	}
+
+	storage := &ReleasesStorage{
+		filename: filepath.Join(dataDir, "releases.json"),
+		seen:     make(map[string]models.SeenReleases),
+	}
+
+	data, err := os.ReadFile(storage.filename)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, fmt.Errorf("lecture de %s : %w", storage.filename, err)
+	default:
+		if err := json.Unmarshal(data, &storage.seen); err != nil {
+			return nil, fmt.Errorf("fichier des sorties %s invalide : %w", storage.filename, err)
+		}
+	}
+
+	return storage, nil
+}
+
+// All renvoie une copie de la discographie vue, par identifiant d'artiste
+func (s *ReleasesStorage) All() map[string]models.SeenReleases {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	seen := make(map[string]models.SeenReleases, len(s.seen))
+	for id, releases := range s.seen {
+		seen[id] = releases
+	}
+	return seen
+}
+
+// Replace remplace et enregistre la discographie vue
+func (s *ReleasesStorage) Replace(seen map[string]models.SeenReleases) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.MarshalIndent(seen, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(s.filename, data, 0644); err != nil {
+		return err
+	}
+	s.seen = seen
+	return nil
+}
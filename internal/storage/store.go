@@ -0,0 +1,237 @@
+package storage
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/yourusername/melody-explorer/internal/models"
+)
+
+// Backends de stockage des favoris disponibles
+const (
+	// BackendJSON réécrit l'intégralité de favorites.json à chaque modification
+	BackendJSON = "json"
+	// BackendLog ajoute chaque modification à un journal compacté périodiquement
+	BackendLog = "log"
+)
+
+// FavoritesStore définit les opérations de stockage des favoris
+// indépendamment du format de persistance
+type FavoritesStore interface {
+	// Add ajoute ou met à jour un élément favori
+	Add(item models.FavoriteItem) error
+	// Remove supprime un élément favori
+	Remove(id string, itemType models.FavoriteType) error
+	// GetAll renvoie tous les éléments favoris
+	GetAll() []models.FavoriteItem
+	// GetByType renvoie les éléments favoris d'un type spécifique
+	GetByType(itemType models.FavoriteType) []models.FavoriteItem
+	// Contains vérifie si un élément est dans les favoris
+	Contains(id string, itemType models.FavoriteType) bool
+	// ContainsMany vérifie la présence de plusieurs éléments, indexés par FavoriteKey.String()
+	ContainsMany(keys []models.FavoriteKey) map[string]bool
+	// Update exécute fn dans une transaction : toutes ses modifications sont
+	// persistées ensemble si fn renvoie nil, aucune sinon
+	Update(fn func(tx *Tx) error) error
+	// Close libère les ressources du stockage
+	Close() error
+}
+
+// Options configure l'ouverture du stockage des favoris
+type Options struct {
+	DataDir string
+	Backend string
+	// Backups est le nombre de sauvegardes tournantes conservées
+	Backups int
+	// CompactEvery déclenche une compaction du journal après ce nombre d'écritures
+	CompactEvery int
+	// CompactInterval déclenche une compaction périodique du journal
+	CompactInterval time.Duration
+	// WatchInterval est la période de détection des modifications faites par
+	// d'autres processus (0 pour désactiver la surveillance)
+	WatchInterval time.Duration
+}
+
+// OpenFavoritesStore ouvre le stockage des favoris correspondant au backend configuré
+func OpenFavoritesStore(opts Options) (FavoritesStore, error) {
+	switch opts.Backend {
+	case "", BackendJSON:
+		return NewFavoritesStorage(opts.DataDir, opts)
+	case BackendLog:
+		return NewLogStorage(opts.DataDir, opts)
+	default:
+		return nil, fmt.Errorf("backend de stockage inconnu : %q", opts.Backend)
+	}
+}
+
+// operation représente une modification élémentaire des favoris
+type operation struct {
+	Op   string               `json:"op"`
+	ID   string               `json:"id"`
+	Type models.FavoriteType  `json:"type"`
+	Item *models.FavoriteItem `json:"item,omitempty"`
+	// Position est la nouvelle position d'un élément déplacé
+	Position int `json:"position,omitempty"`
+}
+
+// Types d'opérations
+const (
+	opAdd    = "add"
+	opRemove = "remove"
+	opMove   = "move"
+)
+
+// apply applique l'opération à une collection de favoris
+func (o operation) apply(favorites *models.Favorites) {
+	switch o.Op {
+	case opAdd:
+		if o.Item != nil {
+			favorites.Add(*o.Item)
+		}
+	case opRemove:
+		favorites.Remove(o.ID, o.Type)
+	case opMove:
+		favorites.Move(o.ID, o.Type, o.Position)
+	}
+}
+
+// Tx est une transaction sur les favoris. Elle modifie directement la
+// collection du stockage, qui détient son verrou d'écriture pendant toute la
+// transaction, enregistre les opérations effectuées pour les persister et
+// leurs inverses pour les défaire si la transaction échoue.
+type Tx struct {
+	favorites *models.Favorites
+	ops       []operation
+	undo      []func()
+
+	// touched liste les favoris modifiés dans l'ordre de leur première
+	// modification et original leur état avant la transaction (nil s'ils
+	// étaient absents)
+	touched  []models.FavoriteKey
+	original map[models.FavoriteKey]*models.FavoriteItem
+}
+
+// FavoriteChange est l'état d'un favori avant et après une transaction.
+// Old est nil pour un ajout, New pour une suppression.
+type FavoriteChange struct {
+	Old *models.FavoriteItem
+	New *models.FavoriteItem
+}
+
+// newTx crée une transaction sur la collection
+func newTx(favorites *models.Favorites) *Tx {
+	return &Tx{
+		favorites: favorites,
+		original:  make(map[models.FavoriteKey]*models.FavoriteItem),
+	}
+}
+
+// touch conserve l'état d'un favori avant sa première modification
+func (tx *Tx) touch(key models.FavoriteKey) {
+	if _, ok := tx.original[key]; ok {
+		return
+	}
+	tx.touched = append(tx.touched, key)
+	tx.original[key] = nil
+	if item, ok := tx.favorites.Find(key.ID, key.Type); ok {
+		item = item.Clone()
+		tx.original[key] = &item
+	}
+}
+
+// rollback défait toutes les modifications de la transaction
+func (tx *Tx) rollback() {
+	for i := len(tx.undo) - 1; i >= 0; i-- {
+		tx.undo[i]()
+	}
+	tx.ops, tx.undo = nil, nil
+}
+
+// Add ajoute ou met à jour un élément favori dans la transaction
+func (tx *Tx) Add(item models.FavoriteItem) {
+	if item.AddedAt.IsZero() {
+		item.AddedAt = time.Now()
+	}
+	tx.touch(item.Key())
+
+	if previous, ok := tx.favorites.Find(item.ID, item.Type); ok {
+		tx.undo = append(tx.undo, func() { tx.favorites.Add(previous) })
+	} else {
+		tx.undo = append(tx.undo, func() { tx.favorites.Remove(item.ID, item.Type) })
+	}
+	tx.favorites.Add(item)
+	tx.ops = append(tx.ops, operation{Op: opAdd, ID: item.ID, Type: item.Type, Item: &item})
+}
+
+// Remove supprime un élément favori dans la transaction
+func (tx *Tx) Remove(id string, itemType models.FavoriteType) {
+	key := models.FavoriteKey{Type: itemType, ID: id}
+	previous, ok := tx.favorites.Find(id, itemType)
+	if !ok {
+		return
+	}
+	tx.touch(key)
+
+	next, _ := tx.favorites.Next(key)
+	tx.undo = append(tx.undo, func() { tx.favorites.InsertBefore(previous, next) })
+	tx.favorites.Remove(id, itemType)
+	tx.ops = append(tx.ops, operation{Op: opRemove, ID: id, Type: itemType})
+}
+
+// Move déplace un élément parmi les favoris de même type dans la transaction.
+// Le déplacement est enregistré et annulé avec la transaction, mais n'apparaît
+// pas dans Changes : l'ordre des favoris n'est pas versionné.
+func (tx *Tx) Move(id string, itemType models.FavoriteType, position int) {
+	key := models.FavoriteKey{Type: itemType, ID: id}
+	item, ok := tx.favorites.Find(id, itemType)
+	if !ok {
+		return
+	}
+
+	next, _ := tx.favorites.Next(key)
+	tx.undo = append(tx.undo, func() { tx.favorites.InsertBefore(item, next) })
+	tx.favorites.Move(id, itemType, position)
+	tx.ops = append(tx.ops, operation{Op: opMove, ID: id, Type: itemType, Position: position})
+}
+
+// Changes renvoie les favoris ajoutés, modifiés ou supprimés par la
+// transaction, dans l'ordre de leur première modification. Les
+// déplacements et les éléments revenus à leur état initial sont ignorés.
+func (tx *Tx) Changes() []FavoriteChange {
+	var changes []FavoriteChange
+	for _, key := range tx.touched {
+		old := tx.original[key]
+		var cur *models.FavoriteItem
+		if item, ok := tx.favorites.Find(key.ID, key.Type); ok {
+			item = item.Clone()
+			cur = &item
+		}
+
+		if old == nil && cur == nil || old != nil && cur != nil && reflect.DeepEqual(*old, *cur) {
+			continue
+		}
+		changes = append(changes, FavoriteChange{Old: old, New: cur})
+	}
+	return changes
+}
+
+// Get renvoie l'élément favori correspondant tel que vu par la transaction
+func (tx *Tx) Get(id string, itemType models.FavoriteType) (models.FavoriteItem, bool) {
+	return tx.favorites.Find(id, itemType)
+}
+
+// Contains vérifie si un élément est dans les favoris tels que vus par la transaction
+func (tx *Tx) Contains(id string, itemType models.FavoriteType) bool {
+	return tx.favorites.Contains(id, itemType)
+}
+
+// GetAll renvoie tous les éléments favoris tels que vus par la transaction
+func (tx *Tx) GetAll() []models.FavoriteItem {
+	return tx.favorites.Get()
+}
+
+// GetByType renvoie les éléments d'un type tels que vus par la transaction
+func (tx *Tx) GetByType(itemType models.FavoriteType) []models.FavoriteItem {
+	return tx.favorites.GetByType(itemType)
+}
@@ -0,0 +1,192 @@
+package storage
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/yourusername/melody-explorer/internal/models"
+)
+
+// initialFavorites renvoie une collection de test : trois morceaux et un album
+func initialFavorites() *models.Favorites {
+	added := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	items := []models.FavoriteItem{track("a"), track("b"), {ID: "x", Type: models.FavoriteTypeAlbum, Name: "Album"}, track("c")}
+	for i := range items {
+		items[i].AddedAt = added
+	}
+	return models.NewFavoritesFrom(items)
+}
+
+func TestTxRollback(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(tx *Tx)
+	}{
+		{
+			name: "ajout",
+			fn:   func(tx *Tx) { tx.Add(track("d")) },
+		},
+		{
+			name: "modification",
+			fn: func(tx *Tx) {
+				item, _ := tx.Get("b", models.FavoriteTypeTrack)
+				item.Note = "modifié"
+				tx.Add(item)
+			},
+		},
+		{
+			name: "suppression au milieu",
+			fn:   func(tx *Tx) { tx.Remove("b", models.FavoriteTypeTrack) },
+		},
+		{
+			name: "déplacement",
+			fn:   func(tx *Tx) { tx.Move("c", models.FavoriteTypeTrack, 0) },
+		},
+		{
+			name: "suppression puis ajout du même favori",
+			fn: func(tx *Tx) {
+				tx.Remove("a", models.FavoriteTypeTrack)
+				tx.Add(track("a"))
+			},
+		},
+		{
+			name: "suite d'opérations",
+			fn: func(tx *Tx) {
+				tx.Move("a", models.FavoriteTypeTrack, 2)
+				tx.Remove("x", models.FavoriteTypeAlbum)
+				tx.Add(track("d"))
+				tx.Move("d", models.FavoriteTypeTrack, 0)
+				tx.Remove("c", models.FavoriteTypeTrack)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			favorites := initialFavorites()
+			want := favorites.Get()
+
+			tx := newTx(favorites)
+			tt.fn(tx)
+			tx.rollback()
+
+			if got := favorites.Get(); !reflect.DeepEqual(got, want) {
+				t.Errorf("après annulation = %v, attendu %v", ids(got), ids(want))
+			}
+			if len(tx.ops) != 0 || len(tx.undo) != 0 {
+				t.Errorf("opérations conservées après annulation : %d, %d", len(tx.ops), len(tx.undo))
+			}
+		})
+	}
+}
+
+func TestFavoritesStorageUpdateError(t *testing.T) {
+	s, err := NewFavoritesStorage(t.TempDir(), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	update(t, s, func(tx *Tx) { tx.Add(track("a")); tx.Add(track("b")) })
+
+	failure := errors.New("échec")
+	err = s.Update(func(tx *Tx) error {
+		tx.Remove("a", models.FavoriteTypeTrack)
+		tx.Add(track("c"))
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("erreur = %v, attendu %v", err, failure)
+	}
+	if got := ids(s.GetAll()); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("favoris après échec = %v", got)
+	}
+}
+
+func TestTxChanges(t *testing.T) {
+	noted := func(id, note string) models.FavoriteItem {
+		item := track(id)
+		item.AddedAt = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		item.Note = note
+		return item
+	}
+
+	tests := []struct {
+		name string
+		fn   func(tx *Tx)
+		// want liste les changements sous la forme ancien → nouveau
+		// identifiant, vide pour un favori absent
+		want [][2]string
+	}{
+		{
+			name: "aucune modification",
+			fn:   func(tx *Tx) {},
+		},
+		{
+			name: "ajout",
+			fn:   func(tx *Tx) { tx.Add(track("d")) },
+			want: [][2]string{{"", "d"}},
+		},
+		{
+			name: "suppression",
+			fn:   func(tx *Tx) { tx.Remove("b", models.FavoriteTypeTrack) },
+			want: [][2]string{{"b", ""}},
+		},
+		{
+			name: "modification",
+			fn:   func(tx *Tx) { tx.Add(noted("a", "nouvelle note")) },
+			want: [][2]string{{"a", "a"}},
+		},
+		{
+			name: "ajout puis suppression",
+			fn: func(tx *Tx) {
+				tx.Add(track("d"))
+				tx.Remove("d", models.FavoriteTypeTrack)
+			},
+		},
+		{
+			name: "retour à l'état initial",
+			fn: func(tx *Tx) {
+				tx.Add(noted("a", "provisoire"))
+				tx.Add(noted("a", ""))
+			},
+		},
+		{
+			name: "déplacement ignoré",
+			fn:   func(tx *Tx) { tx.Move("c", models.FavoriteTypeTrack, 0) },
+		},
+		{
+			name: "ordre de première modification",
+			fn: func(tx *Tx) {
+				tx.Remove("c", models.FavoriteTypeTrack)
+				tx.Add(track("d"))
+				tx.Add(noted("a", "note"))
+				tx.Add(noted("c", "recréé"))
+			},
+			want: [][2]string{{"c", "c"}, {"", "d"}, {"a", "a"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := newTx(initialFavorites())
+			tt.fn(tx)
+
+			var got [][2]string
+			for _, change := range tx.Changes() {
+				var pair [2]string
+				if change.Old != nil {
+					pair[0] = change.Old.ID
+				}
+				if change.New != nil {
+					pair[1] = change.New.ID
+				}
+				got = append(got, pair)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Changes() = %v, attendu %v", got, tt.want)
+			}
+		})
+	}
+}
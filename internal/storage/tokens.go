@@ -0,0 +1,169 @@
+package storage
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/yourusername/melody-explorer/internal/models"
+)
+
+// ErrTokenNotFound est renvoyée lorsqu'un jeton d'accès n'existe pas
+var ErrTokenNotFound = errors.New("jeton introuvable")
+
+// tokenTouchInterval limite l'écriture de la date de dernière utilisation
+// d'un jeton, pour ne pas réécrire le fichier à chaque requête
+const tokenTouchInterval = time.Minute
+
+// TokensStorage stocke les jetons d'accès personnels dans tokens.json,
+// lisible par le seul propriétaire du fichier. Les jetons révoqués sont
+// conservés pour l'affichage de la page du compte.
+type TokensStorage struct {
+	filename string
+	tokens   []models.APIToken
+	lock     *fileLock
+
+	mu sync.RWMutex
+}
+
+// NewTokensStorage crée un nouveau TokensStorage dans dataDir
+func NewTokensStorage(dataDir string) (*TokensStorage, error) {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, err
+	}
+
+	storage := &TokensStorage{
+		filename: filepath.Join(dataDir, "tokens.json"),
+		tokens:   []models.APIToken{},
+		lock:     newFileLock(filepath.Join(dataDir, ".tokens.lock")),
+	}
+
+	data, err := os.ReadFile(storage.filename)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, fmt.Errorf("lecture de %s : %w", storage.filename, err)
+	default:
+		if err := json.Unmarshal(data, &storage.tokens); err != nil {
+			return nil, fmt.Errorf("fichier des jetons %s invalide : %w", storage.filename, err)
+		}
+		log.Printf("Chargement de %d jetons d'accès depuis %s", len(storage.tokens), storage.filename)
+	}
+
+	return storage, nil
+}
+
+// All renvoie une copie de tous les jetons, dans l'ordre de création
+func (s *TokensStorage) All() []models.APIToken {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tokens := make([]models.APIToken, len(s.tokens))
+	copy(tokens, s.tokens)
+	return tokens
+}
+
+// Lookup renvoie le jeton correspondant à un secret, qu'il soit actif ou non
+func (s *TokensStorage) Lookup(plain string) (models.APIToken, bool) {
+	hash := []byte(models.HashAPIToken(plain))
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, token := range s.tokens {
+		if subtle.ConstantTimeCompare(hash, []byte(token.Hash)) == 1 {
+			return token, true
+		}
+	}
+	return models.APIToken{}, false
+}
+
+// Create enregistre un nouveau jeton
+func (s *TokensStorage) Create(token models.APIToken) error {
+	return s.update(func(tokens []models.APIToken) ([]models.APIToken, error) {
+		return append(tokens, token), nil
+	})
+}
+
+// Revoke révoque un jeton ; révoquer un jeton déjà révoqué est sans effet
+func (s *TokensStorage) Revoke(id string) (models.APIToken, error) {
+	var revoked models.APIToken
+	err := s.update(func(tokens []models.APIToken) ([]models.APIToken, error) {
+		for i := range tokens {
+			if tokens[i].ID != id {
+				continue
+			}
+			if tokens[i].RevokedAt == nil {
+				now := time.Now()
+				tokens[i].RevokedAt = &now
+			}
+			revoked = tokens[i]
+			return tokens, nil
+		}
+		return nil, ErrTokenNotFound
+	})
+	return revoked, err
+}
+
+// Touch enregistre l'utilisation d'un jeton, au plus une fois par minute
+func (s *TokensStorage) Touch(id string, at time.Time) {
+	s.mu.RLock()
+	stale := false
+	for _, token := range s.tokens {
+		if token.ID == id {
+			stale = token.LastUsedAt == nil || at.Sub(*token.LastUsedAt) >= tokenTouchInterval
+			break
+		}
+	}
+	s.mu.RUnlock()
+	if !stale {
+		return
+	}
+
+	err := s.update(func(tokens []models.APIToken) ([]models.APIToken, error) {
+		for i := range tokens {
+			if tokens[i].ID == id {
+				tokens[i].LastUsedAt = &at
+			}
+		}
+		return tokens, nil
+	})
+	if err != nil {
+		log.Printf("Erreur lors de l'enregistrement de l'utilisation du jeton %s : %v", id, err)
+	}
+}
+
+// update applique fn à une copie des jetons puis l'enregistre ; en cas
+// d'erreur, les jetons restent inchangés
+func (s *TokensStorage) update(fn func(tokens []models.APIToken) ([]models.APIToken, error)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.lock.Lock(); err != nil {
+		return fmt.Errorf("verrouillage des jetons : %w", err)
+	}
+	defer s.lock.Unlock()
+
+	tokens := make([]models.APIToken, len(s.tokens))
+	copy(tokens, s.tokens)
+	tokens, err := fn(tokens)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(tokens, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(s.filename, data, 0600); err != nil {
+		return err
+	}
+	s.tokens = tokens
+	return nil
+}
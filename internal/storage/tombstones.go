@@ -0,0 +1,156 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yourusername/melody-explorer/internal/hlc"
+	"github.com/yourusername/melody-explorer/internal/models"
+)
+
+// DefaultTombstoneRetention est la durée de conservation des traces de
+// suppression. Un appareil qui ne s'est pas synchronisé depuis plus
+// longtemps doit reprendre l'état complet des favoris.
+const DefaultTombstoneRetention = 90 * 24 * time.Hour
+
+// tombstonesFile est le contenu de tombstones.json
+type tombstonesFile struct {
+	// PurgedBefore est la révision la plus récente des traces purgées
+	PurgedBefore hlc.Timestamp      `json:"purged_before,omitempty"`
+	Tombstones   []models.Tombstone `json:"tombstones"`
+}
+
+// TombstonesStorage conserve les traces des favoris supprimés dans
+// tombstones.json, pour la synchronisation entre appareils
+type TombstonesStorage struct {
+	filename  string
+	retention time.Duration
+	data      tombstonesFile
+	lock      *fileLock
+
+	mu sync.RWMutex
+}
+
+// NewTombstonesStorage crée un nouveau TombstonesStorage dans dataDir ; les
+// traces plus anciennes que retention sont purgées
+func NewTombstonesStorage(dataDir string, retention time.Duration) (*TombstonesStorage, error) {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, err
+	}
+	if retention <= 0 {
+		retention = DefaultTombstoneRetention
+	}
+
+	storage := &TombstonesStorage{
+		filename:  filepath.Join(dataDir, "tombstones.json"),
+		retention: retention,
+		data:      tombstonesFile{Tombstones: []models.Tombstone{}},
+		lock:      newFileLock(filepath.Join(dataDir, ".tombstones.lock")),
+	}
+
+	data, err := os.ReadFile(storage.filename)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, fmt.Errorf("lecture de %s : %w", storage.filename, err)
+	default:
+		if err := json.Unmarshal(data, &storage.data); err != nil {
+			return nil, fmt.Errorf("fichier des suppressions %s invalide : %w", storage.filename, err)
+		}
+		log.Printf("Chargement de %d suppressions depuis %s", len(storage.data.Tombstones), storage.filename)
+	}
+
+	return storage, nil
+}
+
+// Get renvoie la trace de suppression d'un favori
+func (s *TombstonesStorage) Get(key models.FavoriteKey) (models.Tombstone, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, tombstone := range s.data.Tombstones {
+		if tombstone.Key() == key {
+			return tombstone, true
+		}
+	}
+	return models.Tombstone{}, false
+}
+
+// Since renvoie les traces enregistrées après la révision donnée, dans
+// l'ordre des révisions
+func (s *TombstonesStorage) Since(revision hlc.Timestamp) []models.Tombstone {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tombstones := []models.Tombstone{}
+	for _, tombstone := range s.data.Tombstones {
+		if tombstone.Revision.After(revision) {
+			tombstones = append(tombstones, tombstone)
+		}
+	}
+	sort.Slice(tombstones, func(i, j int) bool {
+		return tombstones[j].Revision.After(tombstones[i].Revision)
+	})
+	return tombstones
+}
+
+// PurgedBefore renvoie la révision la plus récente des traces purgées : un
+// curseur antérieur ne permet plus de connaître toutes les suppressions
+func (s *TombstonesStorage) PurgedBefore() hlc.Timestamp {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.PurgedBefore
+}
+
+// Apply enregistre des traces de suppression et efface celles des favoris
+// de nouveau présents, puis purge les traces expirées
+func (s *TombstonesStorage) Apply(put []models.Tombstone, clear []models.FavoriteKey) error {
+	if len(put) == 0 && len(clear) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.lock.Lock(); err != nil {
+		return fmt.Errorf("verrouillage des suppressions : %w", err)
+	}
+	defer s.lock.Unlock()
+
+	replaced := make(map[models.FavoriteKey]bool, len(put)+len(clear))
+	for _, tombstone := range put {
+		replaced[tombstone.Key()] = true
+	}
+	for _, key := range clear {
+		replaced[key] = true
+	}
+
+	next := tombstonesFile{PurgedBefore: s.data.PurgedBefore, Tombstones: make([]models.Tombstone, 0, len(s.data.Tombstones)+len(put))}
+	expiry := time.Now().Add(-s.retention)
+	for _, tombstone := range s.data.Tombstones {
+		switch {
+		case replaced[tombstone.Key()]:
+		case tombstone.DeletedAt.Before(expiry):
+			next.PurgedBefore = hlc.Max(next.PurgedBefore, tombstone.Revision)
+		default:
+			next.Tombstones = append(next.Tombstones, tombstone)
+		}
+	}
+	next.Tombstones = append(next.Tombstones, put...)
+
+	data, err := json.MarshalIndent(next, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(s.filename, data, 0644); err != nil {
+		return err
+	}
+	s.data = next
+	return nil
+}
@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"os"
+	"time"
+)
+
+// fileState décrit un fichier de données tel qu'il a été lu ou écrit pour la
+// dernière fois par ce processus
+type fileState struct {
+	modTime time.Time
+	size    int64
+	sum     [sha256.Size]byte
+}
+
+// statChanged indique si la date de modification ou la taille du fichier
+// diffèrent de l'état connu. Un fichier absent est considéré comme vide.
+func (st fileState) statChanged(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return st.size != 0 || !st.modTime.IsZero()
+	}
+	return !info.ModTime().Equal(st.modTime) || info.Size() != st.size
+}
+
+// readFileState lit un fichier et renvoie son contenu avec son état
+func readFileState(path string) ([]byte, fileState, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fileState{}, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fileState{}, err
+	}
+
+	return data, fileState{
+		modTime: info.ModTime(),
+		size:    info.Size(),
+		sum:     sha256.Sum256(data),
+	}, nil
+}
+
+// poller exécute une fonction à intervalle régulier jusqu'à son arrêt
+type poller struct {
+	stop chan struct{}
+	done chan struct{}
+}
+
+// startPoller lance fn toutes les interval ; renvoie nil si interval est nul
+func startPoller(interval time.Duration, fn func()) *poller {
+	if interval <= 0 {
+		return nil
+	}
+
+	p := &poller{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	go func() {
+		defer close(p.done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				fn()
+			case <-p.stop:
+				return
+			}
+		}
+	}()
+
+	return p
+}
+
+// Stop arrête le poller et attend la fin de l'exécution en cours
+func (p *poller) Stop() {
+	if p == nil {
+		return
+	}
+	close(p.stop)
+	<-p.done
+}
@@ -0,0 +1,323 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yourusername/melody-explorer/internal/models"
+)
+
+// Erreurs du stockage des webhooks
+var (
+	ErrWebhookNotFound  = errors.New("webhook introuvable")
+	ErrDeliveryNotFound = errors.New("notification introuvable")
+)
+
+// maxFinishedDeliveries est le nombre de notifications terminées (envoyées
+// ou abandonnées) conservées dans le journal ; les notifications en attente
+// sont toujours conservées
+const maxFinishedDeliveries = 1000
+
+// maxPendingDeliveries est le nombre de notifications en attente conservées
+// par webhook : au-delà, les plus anciennes sont abandonnées, pour qu'un
+// destinataire injoignable ne fasse pas grossir la file indéfiniment
+const maxPendingDeliveries = 500
+
+// webhooksFile est le contenu de webhooks.json
+type webhooksFile struct {
+	Webhooks   []models.Webhook         `json:"webhooks"`
+	Deliveries []models.WebhookDelivery `json:"deliveries"`
+}
+
+// WebhooksStorage stocke dans webhooks.json les abonnements des webhooks,
+// lisible par le seul propriétaire du fichier puisqu'il contient leurs
+// secrets, ainsi que la file et le journal des notifications
+type WebhooksStorage struct {
+	filename string
+	data     webhooksFile
+	lock     *fileLock
+
+	mu sync.RWMutex
+}
+
+// NewWebhooksStorage crée un nouveau WebhooksStorage dans dataDir
+func NewWebhooksStorage(dataDir string) (*WebhooksStorage, error) {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, err
+	}
+
+	storage := &WebhooksStorage{
+		filename: filepath.Join(dataDir, "webhooks.json"),
+		data:     webhooksFile{Webhooks: []models.Webhook{}, Deliveries: []models.WebhookDelivery{}},
+		lock:     newFileLock(filepath.Join(dataDir, ".webhooks.lock")),
+	}
+
+	data, err := os.ReadFile(storage.filename)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, fmt.Errorf("lecture de %s : %w", storage.filename, err)
+	default:
+		if err := json.Unmarshal(data, &storage.data); err != nil {
+			return nil, fmt.Errorf("fichier des webhooks %s invalide : %w", storage.filename, err)
+		}
+		log.Printf("Chargement de %d webhooks et %d notifications depuis %s",
+			len(storage.data.Webhooks), len(storage.data.Deliveries), storage.filename)
+	}
+
+	return storage, nil
+}
+
+// All renvoie une copie de tous les webhooks, dans l'ordre de création
+func (s *WebhooksStorage) All() []models.Webhook {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	webhooks := make([]models.Webhook, len(s.data.Webhooks))
+	copy(webhooks, s.data.Webhooks)
+	return webhooks
+}
+
+// Get renvoie un webhook
+func (s *WebhooksStorage) Get(id string) (models.Webhook, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, webhook := range s.data.Webhooks {
+		if webhook.ID == id {
+			return webhook, true
+		}
+	}
+	return models.Webhook{}, false
+}
+
+// Create enregistre un nouveau webhook
+func (s *WebhooksStorage) Create(webhook models.Webhook) error {
+	return s.update(func(data *webhooksFile) error {
+		data.Webhooks = append(data.Webhooks, webhook)
+		return nil
+	})
+}
+
+// UpdateWebhook modifie un webhook avec fn et renvoie le webhook modifié
+func (s *WebhooksStorage) UpdateWebhook(id string, fn func(webhook *models.Webhook) error) (models.Webhook, error) {
+	var updated models.Webhook
+	err := s.update(func(data *webhooksFile) error {
+		for i := range data.Webhooks {
+			if data.Webhooks[i].ID != id {
+				continue
+			}
+			if err := fn(&data.Webhooks[i]); err != nil {
+				return err
+			}
+			updated = data.Webhooks[i]
+			return nil
+		}
+		return ErrWebhookNotFound
+	})
+	return updated, err
+}
+
+// Delete supprime un webhook et ses notifications
+func (s *WebhooksStorage) Delete(id string) error {
+	return s.update(func(data *webhooksFile) error {
+		found := false
+		webhooks := data.Webhooks[:0]
+		for _, webhook := range data.Webhooks {
+			if webhook.ID == id {
+				found = true
+				continue
+			}
+			webhooks = append(webhooks, webhook)
+		}
+		if !found {
+			return ErrWebhookNotFound
+		}
+		data.Webhooks = webhooks
+
+		deliveries := data.Deliveries[:0]
+		for _, delivery := range data.Deliveries {
+			if delivery.WebhookID != id {
+				deliveries = append(deliveries, delivery)
+			}
+		}
+		data.Deliveries = deliveries
+		return nil
+	})
+}
+
+// Enqueue ajoute des notifications à la file, en abandonnant les plus
+// anciennes notifications en attente d'un webhook au-delà de
+// maxPendingDeliveries
+func (s *WebhooksStorage) Enqueue(deliveries ...models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return s.update(func(data *webhooksFile) error {
+		data.Deliveries = append(data.Deliveries, deliveries...)
+		if dropped := dropExcessPending(data.Deliveries, maxPendingDeliveries); dropped > 0 {
+			log.Printf("File des webhooks pleine : %d notifications en attente abandonnées", dropped)
+		}
+		return nil
+	})
+}
+
+// Delivery renvoie une notification
+func (s *WebhooksStorage) Delivery(id string) (models.WebhookDelivery, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, delivery := range s.data.Deliveries {
+		if delivery.ID == id {
+			return delivery, true
+		}
+	}
+	return models.WebhookDelivery{}, false
+}
+
+// Deliveries renvoie les notifications d'un webhook, les plus récentes d'abord
+func (s *WebhooksStorage) Deliveries(webhookID string) []models.WebhookDelivery {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	deliveries := []models.WebhookDelivery{}
+	for i := len(s.data.Deliveries) - 1; i >= 0; i-- {
+		if s.data.Deliveries[i].WebhookID == webhookID {
+			deliveries = append(deliveries, s.data.Deliveries[i])
+		}
+	}
+	return deliveries
+}
+
+// Due renvoie les notifications en attente dont la prochaine tentative est
+// échue, dans l'ordre de leur échéance, ainsi que la date de la prochaine
+// échéance parmi les autres (zéro s'il n'y en a pas)
+func (s *WebhooksStorage) Due(now time.Time) ([]models.WebhookDelivery, time.Time) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var due []models.WebhookDelivery
+	var next time.Time
+	for _, delivery := range s.data.Deliveries {
+		if delivery.Status != models.DeliveryPending || delivery.NextAttemptAt == nil {
+			continue
+		}
+		if !delivery.NextAttemptAt.After(now) {
+			due = append(due, delivery)
+		} else if next.IsZero() || delivery.NextAttemptAt.Before(next) {
+			next = *delivery.NextAttemptAt
+		}
+	}
+	sort.SliceStable(due, func(i, j int) bool {
+		return due[i].NextAttemptAt.Before(*due[j].NextAttemptAt)
+	})
+	return due, next
+}
+
+// RecordAttempt enregistre une tentative d'envoi et le nouvel état de la
+// notification ; next est la date de la prochaine tentative si elle reste
+// en attente
+func (s *WebhooksStorage) RecordAttempt(id string, attempt models.DeliveryAttempt, status models.DeliveryStatus, next *time.Time) error {
+	return s.update(func(data *webhooksFile) error {
+		for i := range data.Deliveries {
+			if data.Deliveries[i].ID != id {
+				continue
+			}
+			data.Deliveries[i].Attempts = append(data.Deliveries[i].Attempts, attempt)
+			data.Deliveries[i].Status = status
+			data.Deliveries[i].NextAttemptAt = next
+			return nil
+		}
+		return ErrDeliveryNotFound
+	})
+}
+
+// update applique fn à une copie des données, purge les notifications
+// terminées les plus anciennes puis enregistre ; en cas d'erreur, les
+// données restent inchangées
+func (s *WebhooksStorage) update(fn func(data *webhooksFile) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.lock.Lock(); err != nil {
+		return fmt.Errorf("verrouillage des webhooks : %w", err)
+	}
+	defer s.lock.Unlock()
+
+	next := webhooksFile{
+		Webhooks:   make([]models.Webhook, len(s.data.Webhooks)),
+		Deliveries: make([]models.WebhookDelivery, len(s.data.Deliveries)),
+	}
+	copy(next.Webhooks, s.data.Webhooks)
+	copy(next.Deliveries, s.data.Deliveries)
+	if err := fn(&next); err != nil {
+		return err
+	}
+	next.Deliveries = pruneDeliveries(next.Deliveries)
+
+	data, err := json.MarshalIndent(next, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(s.filename, data, 0600); err != nil {
+		return err
+	}
+	s.data = next
+	return nil
+}
+
+// dropExcessPending marque comme abandonnées les notifications en attente
+// les plus anciennes de chaque webhook au-delà de limit, et renvoie leur nombre
+func dropExcessPending(deliveries []models.WebhookDelivery, limit int) int {
+	pending := make(map[string]int)
+	for _, delivery := range deliveries {
+		if delivery.Status == models.DeliveryPending {
+			pending[delivery.WebhookID]++
+		}
+	}
+
+	dropped := 0
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		if delivery.Status != models.DeliveryPending || pending[delivery.WebhookID] <= limit {
+			continue
+		}
+		pending[delivery.WebhookID]--
+		delivery.Status = models.DeliveryFailed
+		delivery.NextAttemptAt = nil
+		dropped++
+	}
+	return dropped
+}
+
+// pruneDeliveries ne conserve que les maxFinishedDeliveries notifications
+// terminées les plus récentes, dans l'ordre d'origine
+func pruneDeliveries(deliveries []models.WebhookDelivery) []models.WebhookDelivery {
+	finished := 0
+	for _, delivery := range deliveries {
+		if delivery.Status != models.DeliveryPending {
+			finished++
+		}
+	}
+	excess := finished - maxFinishedDeliveries
+	if excess <= 0 {
+		return deliveries
+	}
+
+	kept := make([]models.WebhookDelivery, 0, len(deliveries)-excess)
+	for _, delivery := range deliveries {
+		if excess > 0 && delivery.Status != models.DeliveryPending {
+			excess--
+			continue
+		}
+		kept = append(kept, delivery)
+	}
+	return kept
+}
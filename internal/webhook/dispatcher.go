@@ -0,0 +1,340 @@
+// Package webhook envoie les événements de l'application aux outils
+// externes abonnés. Les notifications sont signées (HMAC-SHA256),
+// enregistrées dans une file persistante et renvoyées avec un délai
+// exponentiel tant que le destinataire ne répond pas avec un code 2xx.
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/yourusername/melody-explorer/internal/models"
+	"github.com/yourusername/melody-explorer/internal/storage"
+)
+
+// En-têtes des notifications
+const (
+	HeaderEvent     = "X-Melody-Event"
+	HeaderDelivery  = "X-Melody-Delivery"
+	HeaderTimestamp = "X-Melody-Timestamp"
+	// HeaderSignature vaut sha256=<HMAC-SHA256 hexadécimal de "<timestamp>.<corps>">
+	HeaderSignature = "X-Melody-Signature"
+)
+
+// Valeurs par défaut des tentatives
+const (
+	DefaultMaxAttempts = 8
+	DefaultBaseDelay   = 30 * time.Second
+	DefaultMaxDelay    = 6 * time.Hour
+	DefaultTimeout     = 10 * time.Second
+)
+
+// Payload est le corps JSON d'une notification. ID identifie l'événement :
+// il est identique pour tous les webhooks et pour les renvois manuels.
+type Payload struct {
+	ID        string              `json:"id"`
+	Event     models.WebhookEvent `json:"event"`
+	CreatedAt time.Time           `json:"created_at"`
+	Data      interface{}         `json:"data"`
+}
+
+// Sign renvoie la signature d'une notification envoyée à l'instant timestamp
+// (secondes Unix), au format de l'en-tête HeaderSignature
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Dispatcher met en file les événements pour les webhooks abonnés et les
+// envoie en arrière-plan
+type Dispatcher struct {
+	store  *storage.WebhooksStorage
+	client *http.Client
+	// MaxAttempts est le nombre de tentatives avant l'abandon d'une notification
+	MaxAttempts int
+	// BaseDelay est le délai avant la deuxième tentative, doublé à chaque
+	// échec jusqu'à MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewDispatcher crée un répartiteur dont les requêtes expirent après timeout
+func NewDispatcher(store *storage.WebhooksStorage, timeout time.Duration) *Dispatcher {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Dispatcher{
+		store:       store,
+		client:      &http.Client{Timeout: timeout},
+		MaxAttempts: DefaultMaxAttempts,
+		BaseDelay:   DefaultBaseDelay,
+		MaxDelay:    DefaultMaxDelay,
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// Start lance l'envoi des notifications en arrière-plan. Les notifications
+// restées en attente lors de l'arrêt précédent sont reprises.
+func (d *Dispatcher) Start() {
+	d.stop = make(chan struct{})
+	d.done = make(chan struct{})
+
+	go func() {
+		defer close(d.done)
+
+		timer := time.NewTimer(0)
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-timer.C:
+			case <-d.wake:
+			case <-d.stop:
+				return
+			}
+
+			next := d.deliverDue()
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			if !next.IsZero() {
+				timer.Reset(max(time.Until(next), 0))
+			}
+		}
+	}()
+}
+
+// Stop arrête l'envoi ; les notifications en attente restent dans la file
+func (d *Dispatcher) Stop() {
+	if d.stop == nil {
+		return
+	}
+	close(d.stop)
+	<-d.done
+}
+
+// Message est un événement à transmettre aux webhooks abonnés
+type Message struct {
+	Event models.WebhookEvent
+	Data  interface{}
+}
+
+// Publish met en file un événement pour chaque webhook actif abonné et
+// renvoie le nombre de notifications créées
+func (d *Dispatcher) Publish(event models.WebhookEvent, data interface{}) (int, error) {
+	return d.PublishAll([]Message{{Event: event, Data: data}})
+}
+
+// PublishAll met en file plusieurs événements en une seule écriture de la
+// file, pour chaque webhook actif abonné, et renvoie le nombre de
+// notifications créées
+func (d *Dispatcher) PublishAll(messages []Message) (int, error) {
+	webhooks := d.store.All()
+	var deliveries []models.WebhookDelivery
+	for _, message := range messages {
+		var payload json.RawMessage
+		for _, webhook := range webhooks {
+			if !webhook.Subscribed(message.Event) {
+				continue
+			}
+			if payload == nil {
+				var err error
+				if payload, err = newPayload(message.Event, message.Data); err != nil {
+					return 0, err
+				}
+			}
+			delivery, err := models.NewWebhookDelivery(webhook.ID, message.Event, payload)
+			if err != nil {
+				return 0, err
+			}
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	if len(deliveries) == 0 {
+		return 0, nil
+	}
+
+	if err := d.store.Enqueue(deliveries...); err != nil {
+		return 0, err
+	}
+	d.notify()
+	return len(deliveries), nil
+}
+
+// Ping met en file un événement de test pour un webhook, même inactif ou
+// non abonné
+func (d *Dispatcher) Ping(webhookID string) (models.WebhookDelivery, error) {
+	if _, ok := d.store.Get(webhookID); !ok {
+		return models.WebhookDelivery{}, storage.ErrWebhookNotFound
+	}
+	payload, err := newPayload(models.WebhookPing, map[string]string{"webhook_id": webhookID})
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	return d.enqueue(webhookID, models.WebhookPing, payload, "")
+}
+
+// Redeliver met en file une nouvelle notification identique à une
+// notification existante, quel que soit son état
+func (d *Dispatcher) Redeliver(deliveryID string) (models.WebhookDelivery, error) {
+	original, ok := d.store.Delivery(deliveryID)
+	if !ok {
+		return models.WebhookDelivery{}, storage.ErrDeliveryNotFound
+	}
+	return d.enqueue(original.WebhookID, original.Event, original.Payload, original.ID)
+}
+
+// enqueue met en file une notification pour un webhook
+func (d *Dispatcher) enqueue(webhookID string, event models.WebhookEvent, payload json.RawMessage, redeliveryOf string) (models.WebhookDelivery, error) {
+	delivery, err := models.NewWebhookDelivery(webhookID, event, payload)
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	delivery.RedeliveryOf = redeliveryOf
+	if err := d.store.Enqueue(delivery); err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	d.notify()
+	return delivery, nil
+}
+
+// notify réveille la boucle d'envoi
+func (d *Dispatcher) notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// deliverDue envoie les notifications échues et renvoie la date de la
+// prochaine échéance (zéro s'il n'y en a pas)
+func (d *Dispatcher) deliverDue() time.Time {
+	due, next := d.store.Due(time.Now())
+	for _, delivery := range due {
+		select {
+		case <-d.stop:
+			return time.Time{}
+		default:
+		}
+
+		retryAt := d.deliver(delivery)
+		if !retryAt.IsZero() && (next.IsZero() || retryAt.Before(next)) {
+			next = retryAt
+		}
+	}
+	return next
+}
+
+// deliver effectue une tentative d'envoi, l'enregistre et renvoie la date
+// de la prochaine tentative, zéro si la notification est terminée
+func (d *Dispatcher) deliver(delivery models.WebhookDelivery) time.Time {
+	webhook, ok := d.store.Get(delivery.WebhookID)
+	if !ok {
+		// Webhook supprimé entre-temps : ses notifications ont été retirées
+		return time.Time{}
+	}
+
+	attempt := d.send(webhook, delivery)
+	status := models.DeliveryDelivered
+	var next *time.Time
+	if attempt.Error != "" {
+		status = models.DeliveryFailed
+		if count := len(delivery.Attempts) + 1; count < d.MaxAttempts {
+			status = models.DeliveryPending
+			retryAt := time.Now().Add(d.backoff(count))
+			next = &retryAt
+		}
+	}
+
+	if err := d.store.RecordAttempt(delivery.ID, attempt, status, next); err != nil {
+		log.Printf("Erreur lors de l'enregistrement de la notification %s : %v", delivery.ID, err)
+	}
+	switch status {
+	case models.DeliveryPending:
+		log.Printf("Échec de la notification %s (%s) vers %s : %s ; nouvelle tentative à %s",
+			delivery.ID, delivery.Event, webhook.URL, attempt.Error, next.Format(time.RFC3339))
+		return *next
+	case models.DeliveryFailed:
+		log.Printf("Notification %s (%s) vers %s abandonnée après %d tentatives : %s",
+			delivery.ID, delivery.Event, webhook.URL, len(delivery.Attempts)+1, attempt.Error)
+	}
+	return time.Time{}
+}
+
+// send envoie une notification signée
+func (d *Dispatcher) send(webhook models.Webhook, delivery models.WebhookDelivery) models.DeliveryAttempt {
+	start := time.Now()
+	attempt := models.DeliveryAttempt{At: start}
+
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		attempt.Error = err.Error()
+		attempt.DurationMs = time.Since(start).Milliseconds()
+		return attempt
+	}
+	timestamp := start.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "MelodyExplorer-Webhook/1.0")
+	req.Header.Set(HeaderEvent, string(delivery.Event))
+	req.Header.Set(HeaderDelivery, delivery.ID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(webhook.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		attempt.Error = err.Error()
+		attempt.DurationMs = time.Since(start).Milliseconds()
+		return attempt
+	}
+	defer resp.Body.Close()
+	// Lire (partiellement) la réponse pour réutiliser la connexion
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	attempt.StatusCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		attempt.Error = fmt.Sprintf("réponse %d", resp.StatusCode)
+	}
+	attempt.DurationMs = time.Since(start).Milliseconds()
+	return attempt
+}
+
+// backoff renvoie le délai avant la tentative suivant la n-ième
+func (d *Dispatcher) backoff(n int) time.Duration {
+	delay := d.BaseDelay
+	for i := 1; i < n && delay < d.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, d.MaxDelay)
+}
+
+// newPayload encode le corps d'une notification
+func newPayload(event models.WebhookEvent, data interface{}) (json.RawMessage, error) {
+	id, err := models.NewWebhookEventID()
+	if err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(Payload{ID: id, Event: event, CreatedAt: time.Now(), Data: data})
+	if err != nil {
+		return nil, fmt.Errorf("encodage de l'événement %s : %w", event, err)
+	}
+	return payload, nil
+}
@@ -0,0 +1,69 @@
+// Page du compte : jetons d'accès personnels
+document.addEventListener('DOMContentLoaded', function() {
+    const page = document.querySelector('.account-page');
+    if (!page) {
+        return;
+    }
+    
+    // Afficher une notification via favorites.js s'il est chargé
+    function notify(message, type) {
+        if (window.showNotification) {
+            window.showNotification(message, type);
+        }
+    }
+    
+    // Envoyer une requête JSON à l'API des jetons
+    function requestToken(method, url, data) {
+        const options = {
+            method: method,
+            headers: csrfHeaders({
+                'Content-Type': 'application/json'
+            })
+        };
+        if (data !== undefined) {
+            options.body = JSON.stringify(data);
+        }
+        
+        return fetch(url, options)
+        .then(response => response.json().then(body => ({ ok: response.ok, body })))
+        .then(({ ok, body }) => {
+            if (!ok || body.success === false) {
+                throw new Error(body.error || 'Échec de la requête');
+            }
+            return body;
+        });
+    }
+    
+    document.getElementById('create-token-form').addEventListener('submit', function(e) {
+        e.preventDefault();
+        const form = this;
+        requestToken('POST', '/api/tokens', {
+            name: form.elements.name.value,
+            scope: form.elements.scope.value,
+            expires_in_days: parseInt(form.elements.expires_in_days.value, 10) || 0
+        })
+        .then(body => {
+            // Le secret n'est renvoyé qu'une fois : l'afficher sans recharger la page
+            const secret = page.querySelector('.token-secret');
+            secret.querySelector('.token-secret-value').textContent = body.secret;
+            secret.hidden = false;
+            form.reset();
+            notify(`Jeton « ${body.token.name} » créé`, 'success');
+        })
+        .catch(error => notify(error.message, 'error'));
+    });
+    
+    page.querySelectorAll('.btn-revoke-token').forEach(button => {
+        button.addEventListener('click', function() {
+            if (!confirm(`Révoquer le jeton « ${this.dataset.name} » ? Les scripts qui l'utilisent n'auront plus accès à l'API.`)) {
+                return;
+            }
+            requestToken('DELETE', `/api/tokens/${encodeURIComponent(this.dataset.id)}`)
+            .then(() => {
+                notify('Jeton révoqué', 'success');
+                setTimeout(() => window.location.reload(), 500);
+            })
+            .catch(error => notify(error.message, 'error'));
+        });
+    });
+});
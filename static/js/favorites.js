@@ -1,4 +1,14 @@
 // Fonctionnalité des favoris
+
+// Ajouter aux en-têtes d'une requête le jeton CSRF de la session, injecté
+// par base.html ; les autres scripts l'utilisent pour leurs requêtes
+function csrfHeaders(headers) {
+    const meta = document.querySelector('meta[name="csrf-token"]');
+    const token = meta ? meta.content : '';
+    return Object.assign({}, headers, token ? { 'X-CSRF-Token': token } : {});
+}
+window.csrfHeaders = csrfHeaders;
+
 document.addEventListener('DOMContentLoaded', function() {
     // Récupérer tous les boutons de favoris
     const favoriteButtons = document.querySelectorAll('.btn-favorite');
@@ -44,6 +54,115 @@ document.addEventListener('DOMContentLoaded', function() {
         });
     }
     
+    // Récupérer tous les formulaires d'annotation des favoris
+    const annotationForms = document.querySelectorAll('.favorite-annotations-form');
+    
+    annotationForms.forEach(form => {
+        form.addEventListener('submit', function(event) {
+            event.preventDefault();
+            updateAnnotations(this);
+        });
+    });
+    
+    // Autocomplétion des étiquettes
+    const tagInputs = document.querySelectorAll('input[list="tag-suggestions"]');
+    let tagRequest = null;
+    
+    tagInputs.forEach(input => {
+        input.addEventListener('input', function() {
+            clearTimeout(tagRequest);
+            tagRequest = setTimeout(() => suggestTags(this), 200);
+        });
+    });
+    
+    // Fonction pour enregistrer la note, l'évaluation et les étiquettes d'un favori
+    function updateAnnotations(form) {
+        const container = form.closest('.favorite-annotations');
+        const id = container.getAttribute('data-id');
+        const type = container.getAttribute('data-type');
+        
+        // Préparer les données de la requête
+        const data = {
+            note: form.elements.note.value,
+            rating: parseInt(form.elements.rating.value, 10) || 0,
+            tags: form.elements.tags.value.split(',').map(tag => tag.trim()).filter(tag => tag !== '')
+        };
+        
+        fetch(`/api/favorites/${encodeURIComponent(type)}/${encodeURIComponent(id)}`, {
+            method: 'PATCH',
+            headers: csrfHeaders({
+                'Content-Type': 'application/json'
+            }),
+            body: JSON.stringify(data)
+        })
+        .then(response => response.json().then(body => ({ ok: response.ok, body })))
+        .then(({ ok, body }) => {
+            if (!ok || !body.success) {
+                throw new Error(body.error || 'Échec de l\'enregistrement');
+            }
+            renderAnnotations(container, body.item);
+            form.closest('details').open = false;
+            showNotification('Annotations enregistrées !', 'success');
+        })
+        .catch(error => {
+            console.error('Erreur:', error);
+            showNotification(error.message, 'error');
+        });
+    }
+    
+    // Fonction pour afficher les annotations d'un favori
+    function renderAnnotations(container, item) {
+        const rating = container.querySelector('.favorite-rating');
+        rating.hidden = !item.rating;
+        rating.setAttribute('aria-label', `Note : ${item.rating || 0}/5`);
+        rating.querySelectorAll('i').forEach((star, index) => {
+            star.className = `${index < item.rating ? 'fas' : 'far'} fa-star`;
+        });
+        
+        const note = container.querySelector('.favorite-note');
+        note.textContent = item.note || '';
+        note.hidden = !item.note;
+        
+        const tags = container.querySelector('.favorite-tags');
+        tags.innerHTML = '';
+        (item.tags || []).forEach(tag => {
+            const link = document.createElement('a');
+            link.href = `/favorites?tag=${encodeURIComponent(tag)}`;
+            link.className = 'favorite-tag';
+            link.textContent = tag;
+            tags.appendChild(link);
+        });
+        tags.hidden = !item.tags || item.tags.length === 0;
+        
+        const form = container.querySelector('.favorite-annotations-form');
+        form.elements.tags.value = (item.tags || []).join(', ');
+    }
+    
+    // Fonction pour proposer des étiquettes existantes
+    function suggestTags(input) {
+        const datalist = document.getElementById('tag-suggestions');
+        if (!datalist) {
+            return;
+        }
+        
+        // Seule la dernière étiquette saisie est complétée
+        const parts = input.value.split(',');
+        const current = parts.pop().trim();
+        const prefix = parts.length > 0 ? parts.map(part => part.trim()).join(', ') + ', ' : '';
+        
+        fetch(`/api/favorites/tags?q=${encodeURIComponent(current)}`)
+        .then(response => response.json())
+        .then(data => {
+            datalist.innerHTML = '';
+            (data.tags || []).forEach(({ tag }) => {
+                const option = document.createElement('option');
+                option.value = prefix + tag;
+                datalist.appendChild(option);
+            });
+        })
+        .catch(error => console.error('Erreur:', error));
+    }
+    
     // Fonction pour ajouter un favori
     function addFavorite(id, type, name, imageURL, button) {
         if (!id || !type) {
@@ -65,9 +184,9 @@ document.addEventListener('DOMContentLoaded', function() {
         // Faire la requête API
         fetch('/api/favorites/add', {
             method: 'POST',
-            headers: {
+            headers: csrfHeaders({
                 'Content-Type': 'application/json'
-            },
+            }),
             body: JSON.stringify(data)
         })
         .then(response => {
@@ -79,18 +198,7 @@ document.addEventListener('DOMContentLoaded', function() {
         .then(data => {
             if (data.success) {
                 // Mettre à jour le bouton
-                button.classList.add('active');
-                const icon = button.querySelector('i');
-                if (icon) {
-                    icon.className = 'fas fa-heart';
-                }
-                
-                // Mettre à jour le texte du bouton s'il en a
-                if (button.innerText.includes('Add to Favorites')) {
-                    button.innerText = '';
-                    button.appendChild(icon);
-                    button.appendChild(document.createTextNode('Remove from Favorites'));
-                }
+                setFavoriteButton(button, true);
                 
                 // Afficher un message de succès
                 showNotification('Ajouté aux favoris !', 'success');
@@ -121,9 +229,9 @@ document.addEventListener('DOMContentLoaded', function() {
         // Faire la requête API
         fetch('/api/favorites/remove', {
             method: 'POST',
-            headers: {
+            headers: csrfHeaders({
                 'Content-Type': 'application/json'
-            },
+            }),
             body: JSON.stringify(data)
         })
         .then(response => {
@@ -135,21 +243,13 @@ document.addEventListener('DOMContentLoaded', function() {
         .then(data => {
             if (data.success) {
                 // Mettre à jour le bouton
-                button.classList.remove('active');
-                const icon = button.querySelector('i');
-                if (icon) {
-                    icon.className = 'far fa-heart';
-                }
-                
-                // Mettre à jour le texte du bouton s'il en a
-                if (button.innerText.includes('Remove from Favorites')) {
-                    button.innerText = '';
-                    button.appendChild(icon);
-                    button.appendChild(document.createTextNode('Add to Favorites'));
-                }
+                setFavoriteButton(button, false);
                 
-                // Afficher un message de succès
-                showNotification('Supprimé des favoris !', 'success');
+                // Afficher un message de succès avec la possibilité d'annuler
+                showNotification('Supprimé des favoris !', 'success', {
+                    label: 'Annuler',
+                    onClick: undoLastChange
+                });
             }
         })
         .catch(error => {
@@ -158,24 +258,48 @@ document.addEventListener('DOMContentLoaded', function() {
         });
     }
     
+    // Fonction pour afficher l'état d'un bouton de favori
+    function setFavoriteButton(button, active) {
+        button.classList.toggle('active', active);
+        const icon = button.querySelector('i');
+        if (icon) {
+            icon.className = `${active ? 'fas' : 'far'} fa-heart`;
+        }
+        
+        // Mettre à jour le texte du bouton s'il en a
+        const from = active ? 'Add to Favorites' : 'Remove from Favorites';
+        if (button.innerText.includes(from)) {
+            button.innerText = '';
+            button.appendChild(icon);
+            button.appendChild(document.createTextNode(active ? 'Remove from Favorites' : 'Add to Favorites'));
+        }
+    }
+    
     // Fonction pour supprimer un favori de la page des favoris
     function removeFavoriteFromPage(id, type, button) {
         // D'abord supprimer du backend
         removeFavorite(id, type, button);
         
-        // Puis supprimer de l'interface avec une animation
-        const card = button.closest('.artist-card, .album-card, .track-card');
-        if (card) {
+        // Puis supprimer de l'interface
+        removeCard(button);
+    }
+    
+    // Fonction pour retirer de la page, avec une animation, la carte contenant l'élément
+    function removeCard(element) {
+        const card = element.closest('.favorite-card');
+        // Une carte peut être retirée à la fois par l'action et par le flux d'événements
+        if (card && !card.classList.contains('removing')) {
+            card.classList.add('removing');
+            const section = card.closest('.favorites-section');
             card.style.transition = 'opacity 0.3s ease';
             card.style.opacity = '0';
             
             setTimeout(() => {
                 card.remove();
                 
-                // Vérifier s'il n'y a plus d'éléments de ce type
-                const section = card.closest('.favorites-section');
+                // Vérifier s'il n'y a plus d'éléments dans la section
                 if (section) {
-                    const remainingCards = section.querySelectorAll('.artist-card, .album-card, .track-card');
+                    const remainingCards = section.querySelectorAll('.favorite-card');
                     if (remainingCards.length === 0) {
                         section.remove();
                     }
@@ -199,8 +323,214 @@ document.addEventListener('DOMContentLoaded', function() {
         }
     }
     
-    // Fonction pour afficher une notification
-    function showNotification(message, type) {
+    // Fonction pour envoyer un lot d'opérations sur les favoris
+    function sendBatch(operations) {
+        return fetch('/api/favorites/batch', {
+            method: 'POST',
+            headers: csrfHeaders({
+                'Content-Type': 'application/json'
+            }),
+            body: JSON.stringify({ operations })
+        })
+        .then(response => response.json().then(body => ({ ok: response.ok, body })))
+        .then(({ ok, body }) => {
+            if (!ok || !body.success) {
+                // Remonter la première opération en erreur, s'il y en a une
+                const failed = (body.results || []).find(result => result.status === 'error');
+                throw new Error(failed ? `${body.error} (${failed.id} : ${failed.error})` : (body.error || 'Échec du lot'));
+            }
+            return body.results;
+        });
+    }
+
+    // Ajouter toutes les pistes d'un album aux favoris
+    const favoriteAllButton = document.querySelector('.btn-favorite-all');
+
+    if (favoriteAllButton) {
+        favoriteAllButton.addEventListener('click', function() {
+            const imageURL = this.getAttribute('data-image') || '';
+            const operations = Array.from(document.querySelectorAll('.tracks-list .track-item[data-id]')).map(track => ({
+                op: 'add',
+                type: 'track',
+                id: track.getAttribute('data-id'),
+                name: track.getAttribute('data-name') || 'Sans titre',
+                image_url: imageURL
+            }));
+
+            this.disabled = true;
+            sendBatch(operations)
+            .then(results => {
+                const added = results.filter(result => result.status === 'ok').length;
+                this.querySelector('i').className = 'fas fa-heart';
+                showNotification(`${added} piste(s) ajoutée(s) aux favoris !`, 'success', {
+                    label: 'Annuler',
+                    onClick: undoLastChange
+                });
+            })
+            .catch(error => {
+                console.error('Erreur:', error);
+                showNotification(error.message, 'error');
+            })
+            .finally(() => {
+                this.disabled = false;
+            });
+        });
+    }
+
+    // Sélection multiple sur la page des favoris
+    const selectionBar = document.querySelector('.favorites-selection');
+
+    if (selectionBar) {
+        const checkboxes = () => document.querySelectorAll('.favorite-select');
+        const selected = () => Array.from(checkboxes()).filter(checkbox => checkbox.checked);
+
+        const updateSelection = () => {
+            const count = selected().length;
+            selectionBar.hidden = count === 0;
+            selectionBar.querySelector('.selection-count').textContent = `${count} favori(s) sélectionné(s)`;
+        };
+
+        checkboxes().forEach(checkbox => checkbox.addEventListener('change', updateSelection));
+
+        selectionBar.querySelector('.btn-clear-selection').addEventListener('click', function() {
+            checkboxes().forEach(checkbox => {
+                checkbox.checked = false;
+            });
+            updateSelection();
+        });
+
+        selectionBar.querySelector('.btn-remove-selected').addEventListener('click', function() {
+            const items = selected();
+            const operations = items.map(checkbox => ({
+                op: 'remove',
+                type: checkbox.getAttribute('data-type'),
+                id: checkbox.getAttribute('data-id')
+            }));
+
+            sendBatch(operations)
+            .then(() => {
+                items.forEach(checkbox => removeCard(checkbox));
+                updateSelection();
+                showNotification(`${operations.length} favori(s) supprimé(s) !`, 'success', {
+                    label: 'Annuler',
+                    onClick: undoLastChange
+                });
+            })
+            .catch(error => {
+                console.error('Erreur:', error);
+                showNotification(error.message, 'error');
+            });
+        });
+    }
+
+    // Épingler une playlist à partir de son lien ou de son URI Spotify
+    const pinPlaylistForm = document.querySelector('.pin-playlist-form');
+
+    if (pinPlaylistForm) {
+        pinPlaylistForm.addEventListener('submit', function(event) {
+            event.preventDefault();
+
+            fetch('/api/favorites/add', {
+                method: 'POST',
+                headers: csrfHeaders({
+                    'Content-Type': 'application/json'
+                }),
+                body: JSON.stringify({ id: this.elements.playlist.value, type: 'playlist' })
+            })
+            .then(response => {
+                if (response.ok) {
+                    return response.json();
+                }
+                return response.text().then(text => {
+                    throw new Error(text.trim() || 'Échec de l\'épinglage de la playlist');
+                });
+            })
+            .then(() => {
+                showNotification('Playlist épinglée !', 'success');
+                setTimeout(() => window.location.reload(), 500);
+            })
+            .catch(error => {
+                console.error('Erreur:', error);
+                showNotification(error.message, 'error');
+            });
+        });
+    }
+
+    // Fonction pour annuler la dernière modification des favoris
+    function undoLastChange() {
+        fetch('/api/favorites/undo', {
+            method: 'POST',
+            headers: csrfHeaders()
+        })
+        .then(response => response.json().then(body => ({ ok: response.ok, body })))
+        .then(({ ok, body }) => {
+            if (!ok || body.success === false) {
+                throw new Error(body.error || 'Échec de l\'annulation');
+            }
+            showNotification('Modification annulée', 'success');
+            // Recharger pour refléter l'état restauré
+            setTimeout(() => window.location.reload(), 500);
+        })
+        .catch(error => {
+            console.error('Erreur:', error);
+            showNotification(error.message, 'error');
+        });
+    }
+    
+    // Rendre l'annulation disponible pour les autres scripts
+    window.undoLastChange = undoLastChange;
+    
+    // Mises à jour en direct : les modifications des favoris et des listes
+    // faites dans un autre onglet ou sur un autre appareil arrivent par le
+    // flux d'événements, qui reprend après une coupure là où il s'était arrêté
+    const livePage = document.querySelector('.btn-favorite, .favorites-page, .lists-page');
+    
+    if (window.EventSource && livePage) {
+        const stream = new EventSource('/api/events');
+        const favoritesPage = document.querySelector('.favorites-page:not(.list-page)');
+        const listPage = document.querySelector('.list-page');
+        const reload = { label: 'Recharger', onClick: () => window.location.reload() };
+        
+        // Sélecteur des éléments portant l'identifiant et le type d'un favori
+        const matching = (selector, change) =>
+            document.querySelectorAll(`${selector}[data-id="${CSS.escape(change.id)}"][data-type="${CSS.escape(change.type)}"]`);
+        
+        stream.addEventListener('favorite', function(event) {
+            const change = JSON.parse(event.data);
+            
+            matching('.btn-favorite', change).forEach(button => setFavoriteButton(button, change.action !== 'remove'));
+            
+            if (!favoritesPage) {
+                return;
+            }
+            if (change.action === 'remove') {
+                matching('.favorite-select', change).forEach(checkbox => removeCard(checkbox));
+            } else if (change.action === 'update') {
+                matching('.favorite-annotations', change).forEach(container => renderAnnotations(container, change.item));
+            } else if (matching('.favorite-select', change).length === 0) {
+                showNotification(`« ${change.name} » a été ajouté aux favoris`, 'success', reload);
+            }
+        });
+        
+        stream.addEventListener('list', function(event) {
+            const change = JSON.parse(event.data);
+            const current = listPage && listPage.getAttribute('data-list') === change.id;
+            if (document.querySelector('.lists-page') || current) {
+                showNotification(`La liste « ${change.name} » a été modifiée`, 'success', reload);
+            }
+        });
+        
+        // Des événements ont été perdus (redémarrage du serveur, longue coupure)
+        stream.addEventListener('resync', function() {
+            showNotification('Vos favoris ont été modifiés ailleurs', 'success', reload);
+        });
+        
+        window.addEventListener('beforeunload', () => stream.close());
+    }
+    
+    // Fonction pour afficher une notification, avec une action facultative
+    // ({ label, onClick }) affichée sous forme de bouton
+    function showNotification(message, type, action) {
         // Vérifier si le conteneur de notification existe
         let notificationContainer = document.querySelector('.notification-container');
         
@@ -224,20 +554,34 @@ document.addEventListener('DOMContentLoaded', function() {
             notification.remove();
         });
         
+        if (action) {
+            const actionButton = document.createElement('button');
+            actionButton.className = 'notification-action';
+            actionButton.textContent = action.label;
+            actionButton.addEventListener('click', function() {
+                notification.remove();
+                action.onClick();
+            });
+            notification.appendChild(actionButton);
+        }
+        
         notification.appendChild(closeButton);
         
         // Ajouter la notification au conteneur
         notificationContainer.appendChild(notification);
         
-        // Supprimer la notification après 3 secondes
+        // Supprimer la notification après 3 secondes, 6 si elle propose une action
         setTimeout(() => {
             notification.classList.add('fade-out');
             setTimeout(() => {
                 notification.remove();
             }, 300);
-        }, 3000);
+        }, action ? 6000 : 3000);
     }
     
+    // Rendre les notifications disponibles pour les autres scripts
+    window.showNotification = showNotification;
+    
     // Ajouter les styles de notification s'ils ne sont pas déjà ajoutés
     if (!document.getElementById('notification-styles')) {
         const style = document.createElement('style');
@@ -285,6 +629,15 @@ document.addEventListener('DOMContentLoaded', function() {
                 color: #888;
             }
             
+            .notification-action {
+                margin-left: 10px;
+                background: none;
+                border: none;
+                color: #1DB954;
+                font-weight: 600;
+                cursor: pointer;
+            }
+            
             @keyframes slide-in {
                 from {
                     transform: translateX(100%);
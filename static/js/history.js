@@ -0,0 +1,60 @@
+// Historique des favoris : annulation et restauration
+document.addEventListener('DOMContentLoaded', function() {
+    const page = document.querySelector('.history-page');
+    if (!page) {
+        return;
+    }
+    
+    // Afficher une notification via favorites.js s'il est chargé
+    function notify(message, type) {
+        if (window.showNotification) {
+            window.showNotification(message, type);
+        }
+    }
+    
+    // Ramener les favoris à leur état à la date donnée
+    function restore(at) {
+        fetch('/api/favorites/restore', {
+            method: 'POST',
+            headers: csrfHeaders({
+                'Content-Type': 'application/json'
+            }),
+            body: JSON.stringify({ at: at })
+        })
+        .then(response => response.json().then(body => ({ ok: response.ok, body })))
+        .then(({ ok, body }) => {
+            if (!ok || body.success === false) {
+                throw new Error(body.error || 'Échec de la restauration');
+            }
+            const count = (body.entries || []).length;
+            notify(count > 0 ? `${count} favoris restaurés` : 'Les favoris étaient déjà dans cet état', 'success');
+            setTimeout(() => window.location.reload(), 500);
+        })
+        .catch(error => notify(error.message, 'error'));
+    }
+    
+    page.querySelector('.btn-undo-favorites').addEventListener('click', function() {
+        if (window.undoLastChange) {
+            window.undoLastChange();
+        }
+    });
+    
+    page.querySelectorAll('.btn-restore-favorites').forEach(button => {
+        button.addEventListener('click', function() {
+            if (confirm('Revenir à l\'état des favoris juste après cette modification ?')) {
+                restore(this.dataset.at);
+            }
+        });
+    });
+    
+    document.getElementById('restore-favorites-form').addEventListener('submit', function(e) {
+        e.preventDefault();
+        // Le champ est en heure locale : le convertir au format RFC 3339
+        const at = new Date(this.elements.at.value);
+        if (isNaN(at.getTime())) {
+            notify('Date invalide', 'error');
+            return;
+        }
+        restore(at.toISOString());
+    });
+});
@@ -0,0 +1,258 @@
+// Import de bibliothèques externes dans les favoris
+document.addEventListener('DOMContentLoaded', function() {
+    const form = document.getElementById('import-form');
+    const container = document.getElementById('import-report');
+    if (!form || !container) {
+        return;
+    }
+    
+    const confidenceLabels = {
+        exact: 'Exacte',
+        high: 'Élevée',
+        medium: 'Moyenne',
+        low: 'Faible',
+        none: 'Aucune'
+    };
+    const typeLabels = {
+        artist: 'Artiste',
+        album: 'Album',
+        track: 'Piste'
+    };
+    
+    let reportID = container.dataset.report || '';
+    let pollTimer = null;
+    
+    // Afficher une notification via favorites.js s'il est chargé
+    function notify(message, type) {
+        if (window.showNotification) {
+            window.showNotification(message, type);
+        }
+    }
+    
+    // Lire une réponse JSON et lever une erreur en cas d'échec
+    function parseResponse(response) {
+        return response.json().then(body => {
+            if (!response.ok || body.success === false) {
+                throw new Error(body.error || 'Échec de la requête');
+            }
+            return body;
+        });
+    }
+    
+    // Décrire un candidat Spotify
+    function describeCandidate(item) {
+        const meta = item.metadata || {};
+        const artists = (meta.artists || []).map(artist => artist.name).join(', ');
+        let label = item.name;
+        if (artists) {
+            label = `${artists} - ${label}`;
+        }
+        if (meta.album && meta.album.name) {
+            label += ` (${meta.album.name})`;
+        }
+        return label;
+    }
+    
+    // Afficher le rapport de correspondance
+    function renderReport(report) {
+        container.hidden = false;
+        container.querySelector('.import-report-title').textContent = report.filename || 'Import';
+        
+        const summary = report.summary;
+        const status = container.querySelector('.import-status');
+        switch (report.status) {
+        case 'matching':
+            status.textContent = `Recherche des correspondances… ${summary.done}/${summary.total}`;
+            break;
+        case 'ready':
+            status.textContent = `Analyse terminée : ${summary.total} entrées`;
+            break;
+        case 'committed':
+            status.textContent = `Import validé : ${report.added} favoris ajoutés`;
+            break;
+        default:
+            status.textContent = `Échec de l'import : ${report.error || 'erreur inconnue'}`;
+        }
+        
+        const levels = summary.levels || {};
+        container.querySelector('.import-summary').textContent = Object.keys(confidenceLabels)
+            .filter(level => levels[level])
+            .map(level => `${confidenceLabels[level]} : ${levels[level]}`)
+            .join(' · ');
+        
+        const tbody = container.querySelector('tbody');
+        // Conserver les choix de l'utilisateur lors des actualisations
+        const choices = {};
+        tbody.querySelectorAll('tr').forEach(row => {
+            choices[row.dataset.index] = {
+                checked: row.querySelector('input[type="checkbox"]').checked,
+                id: row.querySelector('select') ? row.querySelector('select').value : ''
+            };
+        });
+        tbody.innerHTML = '';
+        
+        report.matches.forEach(match => {
+            const row = document.createElement('tr');
+            row.dataset.index = match.index;
+            row.dataset.confidence = match.confidence || '';
+            
+            const check = document.createElement('input');
+            check.type = 'checkbox';
+            check.checked = match.selected;
+            check.disabled = !match.candidates || match.candidates.length === 0 || report.status !== 'ready';
+            
+            const entry = document.createElement('td');
+            entry.textContent = describeEntry(match.entry);
+            if (match.entry.count > 1) {
+                const count = document.createElement('span');
+                count.className = 'favorite-meta';
+                count.textContent = ` ×${match.entry.count}`;
+                entry.appendChild(count);
+            }
+            
+            const confidence = document.createElement('td');
+            if (match.confidence) {
+                const badge = document.createElement('span');
+                badge.className = `confidence-badge confidence-${match.confidence}`;
+                badge.textContent = confidenceLabels[match.confidence];
+                confidence.appendChild(badge);
+            }
+            
+            const candidate = document.createElement('td');
+            if (match.error) {
+                candidate.className = 'unavailable-reason';
+                candidate.textContent = match.error;
+            } else if (match.candidates && match.candidates.length > 0) {
+                const select = document.createElement('select');
+                match.candidates.forEach(c => {
+                    const option = document.createElement('option');
+                    option.value = c.item.id;
+                    option.textContent = `${typeLabels[c.item.type]} : ${describeCandidate(c.item)} — ${Math.round(c.score * 100)} %`;
+                    select.appendChild(option);
+                });
+                select.disabled = report.status !== 'ready';
+                candidate.appendChild(select);
+                if (match.already_favorite) {
+                    const note = document.createElement('span');
+                    note.className = 'favorite-meta';
+                    note.textContent = ' Déjà en favori';
+                    candidate.appendChild(note);
+                }
+            } else if (match.confidence) {
+                candidate.textContent = 'Aucun résultat';
+            }
+            
+            const previous = choices[match.index];
+            if (previous && report.status === 'ready') {
+                check.checked = previous.checked && !check.disabled;
+                const select = candidate.querySelector('select');
+                if (select && previous.id) {
+                    select.value = previous.id;
+                }
+            }
+            
+            const checkCell = document.createElement('td');
+            checkCell.appendChild(check);
+            row.append(checkCell, entry, confidence, candidate);
+            tbody.appendChild(row);
+        });
+        
+        container.querySelector('.btn-commit-import').disabled = report.status !== 'ready';
+    }
+    
+    // Décrire une entrée du fichier importé
+    function describeEntry(entry) {
+        const parts = [entry.artist, entry.title || entry.album].filter(Boolean);
+        return parts.length > 0 ? parts.join(' - ') : entry.uri;
+    }
+    
+    // Charger le rapport et l'actualiser tant que la recherche est en cours
+    function loadReport() {
+        fetch(`/api/favorites/import/${encodeURIComponent(reportID)}`)
+        .then(parseResponse)
+        .then(body => {
+            renderReport(body.report);
+            if (body.report.status === 'matching') {
+                pollTimer = setTimeout(loadReport, 2000);
+            }
+        })
+        .catch(error => {
+            container.hidden = true;
+            notify(error.message, 'error');
+        });
+    }
+    
+    form.addEventListener('submit', function(e) {
+        e.preventDefault();
+        clearTimeout(pollTimer);
+        
+        fetch('/api/favorites/import', {
+            method: 'POST',
+            headers: csrfHeaders(),
+            body: new FormData(form)
+        })
+        .then(parseResponse)
+        .then(body => {
+            reportID = body.report.id;
+            history.replaceState(null, '', `/favorites/import?report=${encodeURIComponent(reportID)}`);
+            renderReport(body.report);
+            pollTimer = setTimeout(loadReport, 1000);
+        })
+        .catch(error => notify(error.message, 'error'));
+    });
+    
+    // Sélection rapide des correspondances
+    container.querySelectorAll('[data-select]').forEach(button => {
+        button.addEventListener('click', function() {
+            const mode = this.dataset.select;
+            container.querySelectorAll('tbody tr').forEach(row => {
+                const check = row.querySelector('input[type="checkbox"]');
+                if (!check.disabled) {
+                    check.checked = mode === 'high' && ['exact', 'high'].includes(row.dataset.confidence);
+                }
+            });
+        });
+    });
+    
+    container.querySelector('.btn-commit-import').addEventListener('click', function() {
+        const items = [];
+        container.querySelectorAll('tbody tr').forEach(row => {
+            const check = row.querySelector('input[type="checkbox"]');
+            const select = row.querySelector('select');
+            if (check.checked && select) {
+                items.push({ index: parseInt(row.dataset.index, 10), id: select.value });
+            }
+        });
+        
+        fetch(`/api/favorites/import/${encodeURIComponent(reportID)}/commit`, {
+            method: 'POST',
+            headers: csrfHeaders({
+                'Content-Type': 'application/json'
+            }),
+            body: JSON.stringify({ items: items })
+        })
+        .then(parseResponse)
+        .then(body => {
+            notify(`${body.added} favoris ajoutés, ${body.skipped} déjà présents`, 'success');
+            loadReport();
+        })
+        .catch(error => notify(error.message, 'error'));
+    });
+    
+    container.querySelector('.btn-discard-import').addEventListener('click', function() {
+        fetch(`/api/favorites/import/${encodeURIComponent(reportID)}`, { method: 'DELETE', headers: csrfHeaders() })
+        .then(parseResponse)
+        .then(() => {
+            clearTimeout(pollTimer);
+            container.hidden = true;
+            reportID = '';
+            history.replaceState(null, '', '/favorites/import');
+            notify('Import abandonné', 'success');
+        })
+        .catch(error => notify(error.message, 'error'));
+    });
+    
+    if (reportID) {
+        loadReport();
+    }
+});
@@ -0,0 +1,194 @@
+// Fonctionnalité des listes personnalisées
+document.addEventListener('DOMContentLoaded', function() {
+    // Afficher une notification via favorites.js s'il est chargé
+    function notify(message, type) {
+        if (window.showNotification) {
+            window.showNotification(message, type);
+        }
+    }
+    
+    // Envoyer une requête JSON à l'API des listes
+    function requestList(method, url, data) {
+        const options = {
+            method: method,
+            headers: csrfHeaders({
+                'Content-Type': 'application/json'
+            })
+        };
+        if (data !== undefined) {
+            options.body = JSON.stringify(data);
+        }
+        
+        return fetch(url, options)
+        .then(response => response.json().then(body => ({ ok: response.ok, body })))
+        .then(({ ok, body }) => {
+            if (!ok || body.success === false) {
+                throw new Error(body.error || 'Échec de la requête');
+            }
+            return body;
+        });
+    }
+    
+    // Lire les champs d'un formulaire de liste
+    function listFormData(form) {
+        const data = {
+            name: form.elements.name.value,
+            description: form.elements.description.value,
+            cover_url: form.elements.cover_url.value
+        };
+        
+        const smart = form.elements.smart;
+        if (smart && (smart.type === 'hidden' || smart.checked)) {
+            data.rule = ruleFormData(form);
+        }
+        return data;
+    }
+    
+    // Lire les champs de la règle d'une liste automatique
+    function ruleFormData(form) {
+        const number = name => parseInt(form.elements[name].value, 10) || 0;
+        return {
+            source: form.elements.rule_source.value,
+            type: form.elements.rule_type.value,
+            year: form.elements.rule_year.value,
+            genre: form.elements.rule_genre.value,
+            tags: form.elements.rule_tags.value.split(',').map(tag => tag.trim()).filter(tag => tag !== ''),
+            min_rating: number('rule_min_rating'),
+            popularity_below: number('rule_popularity_below'),
+            added_within_days: number('rule_added_within_days'),
+            from_favorite_artists: form.elements.rule_from_favorite_artists.checked
+        };
+    }
+    
+    // Création d'une liste
+    const createForm = document.getElementById('create-list-form');
+    if (createForm) {
+        createForm.addEventListener('submit', function(event) {
+            event.preventDefault();
+            requestList('POST', '/api/lists', listFormData(this))
+            .then(data => {
+                window.location.href = `/lists/${encodeURIComponent(data.list.id)}`;
+            })
+            .catch(error => notify(error.message, 'error'));
+        });
+    }
+    
+    // Modification d'une liste
+    const editForm = document.getElementById('edit-list-form');
+    if (editForm) {
+        editForm.addEventListener('submit', function(event) {
+            event.preventDefault();
+            const listID = this.getAttribute('data-list');
+            requestList('PATCH', `/api/lists/${encodeURIComponent(listID)}`, listFormData(this))
+            .then(() => window.location.reload())
+            .catch(error => notify(error.message, 'error'));
+        });
+    }
+    
+    // Réévaluation d'une liste automatique
+    document.querySelectorAll('.btn-refresh-list').forEach(button => {
+        button.addEventListener('click', function() {
+            const listID = this.getAttribute('data-list');
+            this.disabled = true;
+            requestList('POST', `/api/lists/${encodeURIComponent(listID)}/refresh`)
+            .then(() => window.location.reload())
+            .catch(error => {
+                this.disabled = false;
+                notify(error.message, 'error');
+            });
+        });
+    });
+    
+    // Suppression d'une liste
+    document.querySelectorAll('.btn-delete-list').forEach(button => {
+        button.addEventListener('click', function() {
+            const listID = this.getAttribute('data-list');
+            const name = this.getAttribute('data-name');
+            if (!confirm(`Supprimer la liste « ${name} » ?`)) {
+                return;
+            }
+            
+            requestList('DELETE', `/api/lists/${encodeURIComponent(listID)}`)
+            .then(() => {
+                const card = this.closest('.list-card');
+                if (card) {
+                    card.remove();
+                }
+                notify('Liste supprimée !', 'success');
+            })
+            .catch(error => notify(error.message, 'error'));
+        });
+    });
+    
+    // Ajout d'un favori à une liste
+    document.querySelectorAll('.add-to-list').forEach(select => {
+        select.addEventListener('change', function() {
+            const listID = this.value;
+            if (!listID) {
+                return;
+            }
+            
+            const listName = this.options[this.selectedIndex].text;
+            const data = {
+                id: this.getAttribute('data-id'),
+                type: this.getAttribute('data-type'),
+                name: this.getAttribute('data-name'),
+                image_url: this.getAttribute('data-image')
+            };
+            
+            requestList('POST', `/api/lists/${encodeURIComponent(listID)}/items`, data)
+            .then(() => notify(`Ajouté à « ${listName} » !`, 'success'))
+            .catch(error => notify(error.message, 'error'))
+            .finally(() => {
+                this.value = '';
+            });
+        });
+    });
+    
+    // URL d'un élément d'une liste
+    function listItemURL(button) {
+        const listID = button.getAttribute('data-list');
+        const type = button.getAttribute('data-type');
+        const id = button.getAttribute('data-id');
+        return `/api/lists/${encodeURIComponent(listID)}/items/${encodeURIComponent(type)}/${encodeURIComponent(id)}`;
+    }
+    
+    // Déplacement d'un élément dans une liste
+    document.querySelectorAll('.btn-move-list-item').forEach(button => {
+        button.addEventListener('click', function() {
+            const card = this.closest('.favorite-card');
+            const grid = card.parentElement;
+            const cards = Array.from(grid.children);
+            const position = cards.indexOf(card) + parseInt(this.getAttribute('data-direction'), 10);
+            if (position < 0 || position >= cards.length) {
+                return;
+            }
+            
+            requestList('PATCH', listItemURL(this), { position: position })
+            .then(() => {
+                const sibling = cards[position];
+                if (position < cards.indexOf(card)) {
+                    grid.insertBefore(card, sibling);
+                } else {
+                    grid.insertBefore(card, sibling.nextSibling);
+                }
+            })
+            .catch(error => notify(error.message, 'error'));
+        });
+    });
+    
+    // Retrait d'un élément d'une liste
+    document.querySelectorAll('.btn-remove-list-item').forEach(button => {
+        button.addEventListener('click', function() {
+            requestList('DELETE', listItemURL(this))
+            .then(() => {
+                const card = this.closest('.favorite-card');
+                if (card) {
+                    card.remove();
+                }
+                notify('Retiré de la liste !', 'success');
+            })
+            .catch(error => notify(error.message, 'error'));
+        });
+    });
+});